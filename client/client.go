@@ -0,0 +1,371 @@
+// Package client is typed Go client of tracker API.
+// It mirrors OpenAPI specification served at /openapi.json
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const defaultTimeout = 10 * time.Second
+
+// Error is returned for every non-2xx response
+type Error struct {
+	StatusCode int
+	StatusResponse
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("tracker api: %d %s: %s (request %s)", e.StatusCode, e.Code, e.Message, e.RequestID)
+}
+
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	// Value of Authorization header. Requests are anonymous if empty
+	authorization string
+}
+
+type Option func(*Client)
+
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// Authenticates server-to-server client
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.authorization = "Bearer " + key
+	}
+}
+
+// Authenticates telegram user by initData of Telegram Web App
+func WithTelegramWebApp(initData string) Option {
+	return func(c *Client) {
+		c.authorization = "tma " + initData
+	}
+}
+
+// Authenticates telegram user by fields of Telegram Login Widget
+func WithTelegramLogin(fields url.Values) Option {
+	return func(c *Client) {
+		c.authorization = "TelegramLogin " + fields.Encode()
+	}
+}
+
+// baseURL is address of API, e.g. https://tracker.example.com
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    baseURL,
+		httpClient: &http.Client{Timeout: defaultTimeout},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+func (c *Client) Subscribe(ctx context.Context, req *SubscribeRequest) (*StatusResponse, error) {
+	var out StatusResponse
+	if err := c.do(ctx, http.MethodPost, "/subscribe", nil, req, &out); err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+func (c *Client) SetSubscriptionChannels(ctx context.Context, req *SubscriptionChannelsRequest) (*StatusResponse, error) {
+	var out StatusResponse
+	if err := c.do(ctx, http.MethodPut, "/subscribe/channels", nil, req, &out); err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+// Zero limit means default page size
+func (c *Client) ListSubscriptions(ctx context.Context, telegramID int64, limit, offset int) (*SubscriptionListResponse, error) {
+	query := telegramQuery(telegramID)
+	if limit != 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	if offset != 0 {
+		query.Set("offset", strconv.Itoa(offset))
+	}
+
+	var out SubscriptionListResponse
+	if err := c.do(ctx, http.MethodGet, "/subscriptions", query, nil, &out); err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+func (c *Client) GetSubscription(ctx context.Context, telegramID int64, advertID string) (*SubscriptionResponse, error) {
+	var out SubscriptionResponse
+	if err := c.do(ctx, http.MethodGet, subscriptionPath(advertID), telegramQuery(telegramID), nil, &out); err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+func (c *Client) DeleteSubscription(ctx context.Context, telegramID int64, advertID string) error {
+	return c.do(ctx, http.MethodDelete, subscriptionPath(advertID), telegramQuery(telegramID), nil, nil)
+}
+
+func (c *Client) UpdateSubscription(ctx context.Context, telegramID int64, advertID string, req *UpdateSubscriptionRequest) (*SubscriptionResponse, error) {
+	var out SubscriptionResponse
+	if err := c.do(ctx, http.MethodPatch, subscriptionPath(advertID), telegramQuery(telegramID), req, &out); err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+func (c *Client) SetChannels(ctx context.Context, req *ChannelsRequest) (*StatusResponse, error) {
+	var out StatusResponse
+	if err := c.do(ctx, http.MethodPut, "/channels", nil, req, &out); err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+func (c *Client) SetLocale(ctx context.Context, req *LocaleRequest) (*StatusResponse, error) {
+	var out StatusResponse
+	if err := c.do(ctx, http.MethodPut, "/locale", nil, req, &out); err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+func (c *Client) SetSchedule(ctx context.Context, req *ScheduleRequest) (*StatusResponse, error) {
+	var out StatusResponse
+	if err := c.do(ctx, http.MethodPut, "/schedule", nil, req, &out); err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+func (c *Client) RequestEmail(ctx context.Context, req *EmailRequest) (*StatusResponse, error) {
+	var out StatusResponse
+	if err := c.do(ctx, http.MethodPost, "/email", nil, req, &out); err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+func (c *Client) ConfirmEmail(ctx context.Context, token string) (*StatusResponse, error) {
+	var out StatusResponse
+	if err := c.do(ctx, http.MethodGet, "/email/confirm", url.Values{"token": {token}}, nil, &out); err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+// Secret of returned webhook is shown only once
+func (c *Client) RegisterWebhook(ctx context.Context, req *WebhookRequest) (*WebhookResponse, error) {
+	var out WebhookResponse
+	if err := c.do(ctx, http.MethodPost, "/webhooks", nil, req, &out); err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+func (c *Client) ListWebhooks(ctx context.Context, telegramID int64) ([]*WebhookResponse, error) {
+	var out []*WebhookResponse
+	if err := c.do(ctx, http.MethodGet, "/webhooks", telegramQuery(telegramID), nil, &out); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (c *Client) DeleteWebhook(ctx context.Context, telegramID int64, webhookID string) error {
+	query := telegramQuery(telegramID)
+	query.Set("webhook_id", webhookID)
+
+	return c.do(ctx, http.MethodDelete, "/webhooks", query, nil, nil)
+}
+
+// Plain key of returned API key is shown only once
+func (c *Client) CreateAPIKey(ctx context.Context, req *APIKeyRequest) (*APIKeyResponse, error) {
+	var out APIKeyResponse
+	if err := c.do(ctx, http.MethodPost, "/api-keys", nil, req, &out); err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+func (c *Client) ListAPIKeys(ctx context.Context) ([]*APIKeyResponse, error) {
+	var out []*APIKeyResponse
+	if err := c.do(ctx, http.MethodGet, "/api-keys", nil, nil, &out); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (c *Client) RevokeAPIKey(ctx context.Context, apiKeyID string) error {
+	return c.do(ctx, http.MethodDelete, "/api-keys/"+url.PathEscape(apiKeyID), nil, nil, nil)
+}
+
+// Snapshots of every URL if snapshotURL is empty. Zero limit means default page size
+func (c *Client) ListSnapshots(ctx context.Context, snapshotURL string, limit, offset int) ([]*SnapshotResponse, error) {
+	query := url.Values{}
+	if snapshotURL != "" {
+		query.Set("url", snapshotURL)
+	}
+	if limit != 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	if offset != 0 {
+		query.Set("offset", strconv.Itoa(offset))
+	}
+
+	var out []*SnapshotResponse
+	if err := c.do(ctx, http.MethodGet, "/admin/snapshots", query, nil, &out); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// Returns html of parsed page
+func (c *Client) DownloadSnapshot(ctx context.Context, snapshotID int64) ([]byte, error) {
+	var out []byte
+	if err := c.do(ctx, http.MethodGet, "/admin/snapshots/"+strconv.FormatInt(snapshotID, 10), nil, nil, &out); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// Returns OpenAPI specification client mirrors
+func (c *Client) OpenAPI(ctx context.Context) (json.RawMessage, error) {
+	var out json.RawMessage
+	if err := c.do(ctx, http.MethodGet, "/openapi.json", nil, nil, &out); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// Failed liveness check is returned as *Error with 503 status
+func (c *Client) Healthz(ctx context.Context) (*HealthResponse, error) {
+	var out HealthResponse
+	if err := c.do(ctx, http.MethodGet, "/healthz", nil, nil, &out); err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+// Failed readiness check is returned as *Error with 503 status
+func (c *Client) Readyz(ctx context.Context) (*HealthResponse, error) {
+	var out HealthResponse
+	if err := c.do(ctx, http.MethodGet, "/readyz", nil, nil, &out); err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+// Sends in as JSON and decodes response into out.
+// Either could be nil. Body is read as is into out of *[]byte
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		buff, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("json.Marshal: %w", err)
+		}
+
+		body = bytes.NewReader(buff)
+	}
+
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return fmt.Errorf("http.NewRequestWithContext: %w", err)
+	}
+
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if c.authorization != "" {
+		req.Header.Set("Authorization", c.authorization)
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusBadRequest {
+		apiErr := &Error{StatusCode: res.StatusCode}
+		// Proxies in front of API could respond with anything
+		json.NewDecoder(res.Body).Decode(&apiErr.StatusResponse)
+
+		return apiErr
+	}
+
+	if out == nil || res.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	if raw, ok := out.(*[]byte); ok {
+		*raw, err = io.ReadAll(res.Body)
+		if err != nil {
+			return fmt.Errorf("read response: %w", err)
+		}
+
+		return nil
+	}
+
+	err = json.NewDecoder(res.Body).Decode(out)
+	if err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+
+	return nil
+}
+
+// Telegram users may omit their ID
+func telegramQuery(telegramID int64) url.Values {
+	query := url.Values{}
+	if telegramID != 0 {
+		query.Set("telegram_id", strconv.FormatInt(telegramID, 10))
+	}
+
+	return query
+}
+
+func subscriptionPath(advertID string) string {
+	return "/subscriptions/" + url.PathEscape(advertID)
+}
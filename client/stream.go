@@ -0,0 +1,132 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrStreamReset is returned by PriceStream.Next when server can't replay
+// every change since LastEventID. Refetch subscriptions, the stream goes on
+var ErrStreamReset = errors.New("price stream reset, refetch subscriptions")
+
+// Filters of price stream. Zero values are omitted
+type PriceStreamQuery struct {
+	// Optional for telegram users and when AdvertID is set
+	TelegramID int64
+	AdvertID   string
+	// Changes after it are replayed first
+	LastEventID int64
+}
+
+// PriceStream reads events of /prices/stream
+type PriceStream struct {
+	body   io.ReadCloser
+	reader *bufio.Reader
+}
+
+// Opens stream of price changes. Caller closes it.
+// Timeout of http client doesn't apply, stream lives as long as ctx
+func (c *Client) StreamPrices(ctx context.Context, q *PriceStreamQuery) (*PriceStream, error) {
+	query := telegramQuery(q.TelegramID)
+	if q.AdvertID != "" {
+		query.Set("advert_id", q.AdvertID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/prices/stream?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("http.NewRequestWithContext: %w", err)
+	}
+
+	req.Header.Set("Accept", "text/event-stream")
+	if q.LastEventID != 0 {
+		req.Header.Set("Last-Event-ID", strconv.FormatInt(q.LastEventID, 10))
+	}
+
+	if c.authorization != "" {
+		req.Header.Set("Authorization", c.authorization)
+	}
+
+	streamClient := *c.httpClient
+	streamClient.Timeout = 0
+
+	// Body is closed by PriceStream.Close
+	res, err := streamClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode >= http.StatusBadRequest {
+		defer res.Body.Close()
+
+		apiErr := &Error{StatusCode: res.StatusCode}
+		json.NewDecoder(res.Body).Decode(&apiErr.StatusResponse)
+
+		return nil, apiErr
+	}
+
+	return &PriceStream{body: res.Body, reader: bufio.NewReader(res.Body)}, nil
+}
+
+// Next blocks until the next price event.
+// io.EOF means server closed the stream, reconnect with ChangeID of the last event.
+// See ErrStreamReset
+func (s *PriceStream) Next() (*PriceEvent, error) {
+	var event, data string
+
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+
+		// Blank line dispatches event
+		if line == "" {
+			if event == "reset" {
+				return nil, ErrStreamReset
+			}
+
+			if event == "price" && data != "" {
+				var out PriceEvent
+				if err := json.Unmarshal([]byte(data), &out); err != nil {
+					return nil, fmt.Errorf("decode event: %w", err)
+				}
+
+				return &out, nil
+			}
+
+			event, data = "", ""
+			continue
+		}
+
+		// Comments are heartbeats
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			if data != "" {
+				data += "\n"
+			}
+			data += value
+		}
+	}
+}
+
+func (s *PriceStream) Close() error {
+	return s.body.Close()
+}
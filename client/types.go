@@ -0,0 +1,167 @@
+package client
+
+import "time"
+
+// Types mirror schemas of internal/http/openapi.json under the same names
+
+// Envelope of every error and of responses that carry only a message
+type StatusResponse struct {
+	// Machine-readable, e.g. "not_found"
+	Code string `json:"code"`
+	// Code of error, e.g. "subscription_exists". Only client errors have it
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message"`
+	// Same as X-Request-ID header. Helps to find request in logs
+	RequestID string `json:"request_id"`
+}
+
+// TelegramID of requests is optional for telegram users,
+// they could only act as themselves
+
+type SubscribeRequest struct {
+	TelegramID int64  `json:"telegram_id,omitempty"`
+	AdvertURL  string `json:"advert_url"`
+	// Optional. Overrides subscriber's channels for this advert
+	Channels []string `json:"channels,omitempty"`
+}
+
+// nil Channels resets override to subscriber's channels
+type SubscriptionChannelsRequest struct {
+	TelegramID int64    `json:"telegram_id,omitempty"`
+	AdvertURL  string   `json:"advert_url"`
+	Channels   []string `json:"channels"`
+}
+
+type ChannelsRequest struct {
+	TelegramID int64    `json:"telegram_id,omitempty"`
+	Channels   []string `json:"channels"`
+}
+
+type LocaleRequest struct {
+	TelegramID int64 `json:"telegram_id,omitempty"`
+	// e.g. "ru", "en"
+	Locale string `json:"locale"`
+}
+
+type ScheduleRequest struct {
+	TelegramID int64 `json:"telegram_id,omitempty"`
+	// IANA name, e.g. "Europe/Moscow"
+	Timezone string `json:"timezone,omitempty"`
+	// nil disables quiet hours
+	QuietHours *QuietHours `json:"quiet_hours"`
+	// off | hourly | daily
+	Digest string `json:"digest,omitempty"`
+}
+
+// Local time of day, e.g. "23:00" - "08:00"
+type QuietHours struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type EmailRequest struct {
+	TelegramID int64  `json:"telegram_id,omitempty"`
+	Email      string `json:"email"`
+}
+
+type WebhookRequest struct {
+	TelegramID int64  `json:"telegram_id,omitempty"`
+	URL        string `json:"url"`
+}
+
+// Fields that are not set are left unchanged
+type UpdateSubscriptionRequest struct {
+	Channels []string `json:"channels,omitempty"`
+	// Takes precedence over Channels
+	ResetChannels bool  `json:"reset_channels,omitempty"`
+	Muted         *bool `json:"muted,omitempty"`
+}
+
+type APIKeyRequest struct {
+	Name string `json:"name"`
+	// subscriptions:read | subscriptions:write | webhooks | admin
+	Scopes []string `json:"scopes"`
+}
+
+type WebhookResponse struct {
+	WebhookID string `json:"webhook_id"`
+	URL       string `json:"url"`
+	// Shown only once when webhook is registered
+	Secret   string `json:"secret,omitempty"`
+	IsActive bool   `json:"is_active"`
+	Failures int    `json:"failures"`
+}
+
+type SubscriptionResponse struct {
+	AdvertID string `json:"advert_id"`
+	// nil if subscriber's channels are used
+	Channels  []string        `json:"channels"`
+	Muted     bool            `json:"muted"`
+	CreatedAt time.Time       `json:"created_at"`
+	Advert    *AdvertResponse `json:"advert"`
+}
+
+type AdvertResponse struct {
+	URL          string  `json:"url"`
+	Title        string  `json:"title"`
+	CurrentPrice float64 `json:"current_price"`
+	LastPrice    float64 `json:"last_price"`
+	// False until advert is parsed for the first time
+	IsParsed bool `json:"is_parsed"`
+}
+
+type SubscriptionListResponse struct {
+	Items  []*SubscriptionResponse `json:"items"`
+	Total  int                     `json:"total"`
+	Limit  int                     `json:"limit"`
+	Offset int                     `json:"offset"`
+}
+
+type SnapshotResponse struct {
+	SnapshotID int64  `json:"snapshot_id"`
+	URL        string `json:"url"`
+	// Parser couldn't extract advert from page
+	Failed bool `json:"failed"`
+	// Error of parser, empty if page was parsed fine
+	Error string `json:"error"`
+	// Length of html in bytes
+	Size      int       `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type APIKeyResponse struct {
+	APIKeyID string `json:"api_key_id"`
+	Name     string `json:"name"`
+	// Shown only once on creation
+	Key        string     `json:"key,omitempty"`
+	Hint       string     `json:"hint"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+// Data of "price" event of price stream
+type PriceEvent struct {
+	// Pass to PriceStreamQuery.LastEventID to resume stream
+	ChangeID  int64     `json:"change_id"`
+	AdvertID  string    `json:"advert_id"`
+	Title     string    `json:"title"`
+	URL       string    `json:"url"`
+	OldPrice  float64   `json:"old_price"`
+	NewPrice  float64   `json:"new_price"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+type HealthResponse struct {
+	// "ok" or "fail"
+	Status     string             `json:"status"`
+	Components []*ComponentHealth `json:"components"`
+}
+
+type ComponentHealth struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	// Empty if component is healthy
+	Error      string  `json:"error,omitempty"`
+	DurationMS float64 `json:"duration_ms"`
+}
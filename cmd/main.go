@@ -2,11 +2,21 @@ package main
 
 import (
 	"log"
+	"os"
 
 	"parser/internal/app"
 )
 
 func main() {
+	// Offline tools don't need config of service
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		if err := app.Replay(os.Args[2:], os.Stdout); err != nil {
+			log.Fatal(err.Error())
+		}
+
+		return
+	}
+
 	if err := app.Bootstrap(); err != nil {
 		log.Fatal(err.Error())
 	}
@@ -7,15 +7,22 @@ import (
 	"os"
 	"os/signal"
 	"parser/internal/config"
+	domain "parser/internal/domain/models"
 	"parser/internal/domain/repositories"
 	"parser/internal/domain/services"
+	"parser/internal/email"
+	"parser/internal/health"
 	"parser/internal/http"
+	"parser/internal/logger"
+	"parser/internal/messages"
+	"parser/internal/metrics"
 	"parser/internal/notify"
 	"parser/internal/parser"
 	"parser/internal/postgres"
 	"parser/internal/proxy"
-	"parser/internal/telegram"
+	tgclient "parser/internal/telegram"
 	"parser/internal/timer"
+	"parser/internal/tracing"
 	"parser/internal/urlcache"
 	"syscall"
 	"time"
@@ -28,19 +35,45 @@ func Bootstrap() error {
 
 	configPath, debug := parseFlags()
 
+	// JSON for collectors in production, readable lines in debug mode
+	log, err := logger.New(debug)
+	if err != nil {
+		return fmt.Errorf("logger: %w", err)
+	}
+
+	defer log.Sync()
+
 	// Read config
 	cfg, err := config.Load(configPath)
 	if err != nil {
 		return fmt.Errorf("config: %w", err)
 	}
 
+	// Components trace through global provider, so it is set up before them
+	shutdownTracing, err := tracing.Setup(ctx, &tracing.Options{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		return fmt.Errorf("tracing: %w", err)
+	}
+
 	pg, err := postgres.FromConnectionString(ctx, cfg.Database.Url)
 	if err != nil {
 		return fmt.Errorf("postgres: %w", err)
 	}
 
-	telegram := telegram.NewTelegram(debug)
-	telegramNotifier := notify.NewTelegramNotifier(telegram)
+	// Components report to it through their own Metrics interfaces
+	prometheus := metrics.NewPrometheus()
+	prometheus.RegisterPool(pg)
+
+	telegram := tgclient.NewTelegram(debug, log.Named("telegram"))
+	// Every notification channel is registered here
+	notifier := notify.NewMultiplexer()
+	notifier.SetMetrics(prometheus)
+	notifier.Register(domain.ChannelTelegram, notify.NewTelegramNotifier(telegram))
 
 	chromedpParser, err := parser.NewChromeParser()
 	if err != nil {
@@ -49,38 +82,151 @@ func Bootstrap() error {
 
 	ringParser := parser.NewRingParser(&parser.RingParserOptions{
 		Parser:         chromedpParser,
-		ParsingTimeout: cfg.Parsing.Timeout * time.Second,
-		Timer:          new(timer.AppTimer),
+		ParsingTimeout: cfg.Parsing.Timeout,
+		Timer:          timer.NewAppTimer(),
 		OutChanBuff:    cfg.Parsing.ChanBuff,
 		UrlCache:       urlcache.NewUrlCache(time.Minute * 5 /* cache TTL */), // TODO: config
+		Metrics:        prometheus,
+		Logger:         log.Named("parser"),
+	})
+
+	var mailer email.Mailer
+
+	if cfg.Email.Enabled {
+		mailer, err = email.NewSMTPMailer(&email.SMTPOptions{
+			Addr:     cfg.Email.SMTPAddr,
+			From:     cfg.Email.From,
+			Username: cfg.Email.SMTPUsername,
+			Password: cfg.Email.SMTPPassword,
+		})
+		if err != nil {
+			return fmt.Errorf("smtp: %w", err)
+		}
+
+		notifier.Register(domain.ChannelEmail, notify.NewEmailNotifier(mailer))
+	}
+
+	notifier.Register(domain.ChannelWebhook, notify.NewWebhookNotifier(&notify.WebhookNotifierOptions{
+		Timeout: cfg.Webhooks.Timeout,
+		Retries: cfg.Webhooks.Retries,
+	}))
+
+	overrides := make(map[messages.Locale]map[string]string)
+	for locale, templates := range cfg.Messages.Templates {
+		overrides[messages.Locale(locale)] = templates
+	}
+
+	renderer, err := messages.NewRenderer(&messages.Options{
+		DefaultLocale: messages.Locale(cfg.Messages.DefaultLocale),
+		Overrides:     overrides,
 	})
+	if err != nil {
+		return fmt.Errorf("messages: %w", err)
+	}
+
+	// Alerts of failing adverts are only logged without chats
+	var alerter services.Alerter
+	if len(cfg.Failures.AlertChatIDs) > 0 {
+		alerter = notify.NewTelegramAlerter(telegram, cfg.Failures.AlertChatIDs)
+	}
 
-	repositories := repositories.NewRepositories(pg)
-	services := services.NewServices(repositories, telegramNotifier, ringParser)
+	repos := repositories.NewRepositories(pg)
+	repos.SnapshotRepo = snapshotRepo(cfg, pg)
+
+	var snapshotRetention *domain.SnapshotRetention
+	if cfg.Snapshots.Backend != "" {
+		snapshotRetention = &domain.SnapshotRetention{
+			KeepPerURL:       cfg.Snapshots.KeepPerURL,
+			KeepFailedPerURL: cfg.Snapshots.KeepFailedPerURL,
+			FailedMaxAge:     cfg.Snapshots.FailedRetention,
+		}
+	}
+
+	services := services.NewServices(&services.Options{
+		Repositories:         repos,
+		RingParser:           ringParser,
+		Notifier:             notifier,
+		Messages:             renderer,
+		Mailer:               mailer,
+		EmailConfirmURL:      cfg.Email.ConfirmURL,
+		EmailConfirmationTTL: cfg.Email.ConfirmationTTL,
+		MaxWebhookFailures:   cfg.Webhooks.MaxFailures,
+		DigestTimer:          timer.NewAppTimer(),
+		CoalesceWindow:       cfg.Digests.CoalesceWindow,
+		BotToken:             cfg.Telegram.Token,
+		TelegramAuthMaxAge:   cfg.Auth.TelegramMaxAge,
+		StreamBuffer:         cfg.Stream.Buffer,
+		Alerter:              alerter,
+		AlertAfter:           cfg.Failures.AlertAfter,
+		FailureMetrics:       prometheus,
+		SnapshotRetention:    snapshotRetention,
+		Logger:               log.Named("services"),
+	})
+
+	// Operator creates other API keys with admin one
+	if cfg.Auth.AdminKey != "" {
+		err = services.AuthService.EnsureAPIKey(ctx, "admin", cfg.Auth.AdminKey, []domain.Scope{domain.ScopeAdmin})
+		if err != nil {
+			return fmt.Errorf("admin api key: %w", err)
+		}
+	}
 
 	// Adds all URLs for parsing to ringParser
 	fetcher := services.SubscriptionService.GetURLFetcher()
-	if err := addInitialUrls(ctx, ringParser, fetcher); err != nil {
+	if err := addInitialUrls(ctx, ringParser, fetcher, log); err != nil {
 		return fmt.Errorf("add initial urls: %w", err)
 	}
 
 	ringParser.Run(cfg.Parsing.Interval)
 
 	updateHandler := services.SubscriptionService.GetUpdateHandler()
-	proxy := proxy.NewProxy(ringParser.Out(), updateHandler, func(err error) /* err handl. callback */ {
-		// Placeholder
-		// TODO: replace with proper error handler
-		fmt.Printf("proxy error: %v\n", err)
-	})
+	proxy := proxy.NewProxy(ringParser.Out(), updateHandler, services.FailureService, services.SnapshotService, prometheus, log.Named("proxy"))
 	// Start reading from ringParser output and executing updateHandler
 	go proxy.Run()
 
+	// Delivers alerts held during quiet hours or for digest
+	services.DigestService.Run(cfg.Digests.Interval)
+
+	// Reactivates subscribers that have blocked the bot before and detects locale
+	telegram.HandleCommand("start", func(ctx context.Context, sender *tgclient.Sender) error {
+		return services.SubscriptionService.HandleStart(ctx, sender.TelegramID, sender.LanguageCode)
+	})
+
+	// Postgres and Telegram outages are transient, restart wouldn't help.
+	// Dead browser and stuck loops only recover with restart
+	checks := health.NewRegistry()
+	for _, check := range []*health.Check{
+		{Name: "postgres", Run: pg.Ping},
+		{Name: "telegram", Run: telegram.Health},
+		{Name: "chrome", Run: chromedpParser.Health, Liveness: true},
+		{Name: "ring_parser", Run: ringParser.Health, Liveness: true},
+		{Name: "proxy", Run: proxy.Health, Liveness: true},
+	} {
+		check.Timeout = cfg.Health.Timeout
+		checks.Register(check)
+	}
+
+	var telegramWebhook *http.TelegramWebhook
+	if cfg.Telegram.Mode == config.TelegramModeWebhook {
+		telegramWebhook = &http.TelegramWebhook{
+			Path:    cfg.Telegram.Webhook.Path,
+			Handler: telegram.WebhookHandler(cfg.Telegram.Webhook.SecretToken),
+		}
+	}
+
 	server := http.NewHTTPServer(&http.ServerConfig{
-		Router:       http.NewMuxRouter(),
-		Services:     services,
-		Addr:         cfg.Net.Addr,
-		WriteTimeout: cfg.Net.RWTimeout,
-		ReadTimeout:  cfg.Net.RWTimeout,
+		Router:          http.NewMuxRouter(),
+		Services:        services,
+		Addr:            cfg.Net.Addr,
+		WriteTimeout:    cfg.Net.RWTimeout,
+		ReadTimeout:     cfg.Net.RWTimeout,
+		BodyLimit:       cfg.Net.BodyLimit,
+		CORSOrigins:     cfg.Net.CORSOrigins,
+		StreamHeartbeat: cfg.Stream.Heartbeat,
+		Health:          checks,
+		Metrics:         prometheus.Handler(),
+		TelegramWebhook: telegramWebhook,
+		Logger:          log.Named("http"),
 	})
 
 	go func() {
@@ -91,47 +237,76 @@ func Bootstrap() error {
 
 	// Avoid data race
 	token := cfg.Telegram.Token
-	go func() {
-		if err := telegram.Connect(token); err != nil {
-			panic(fmt.Sprintf("unable to connect to telegram: %v\n", err))
+	if cfg.Telegram.Mode == config.TelegramModeWebhook {
+		err = telegram.ConnectWebhook(token, &tgclient.WebhookOptions{
+			URL:         cfg.Telegram.Webhook.URL,
+			SecretToken: cfg.Telegram.Webhook.SecretToken,
+		})
+		if err != nil {
+			return fmt.Errorf("unable to connect to telegram: %w", err)
 		}
-	}()
+	} else {
+		go func() {
+			if err := telegram.Connect(token); err != nil {
+				panic(fmt.Sprintf("unable to connect to telegram: %v\n", err))
+			}
+		}()
+	}
+
+	log.Info("started", logger.String("addr", cfg.Net.Addr), logger.String("telegram_mode", cfg.Telegram.Mode))
 
-	exit := make(chan os.Signal)
+	exit := make(chan os.Signal, 1)
 	signal.Notify(exit, syscall.SIGINT, syscall.SIGTERM)
 
 	// Gracefull shutdown
-	<-exit
+	sig := <-exit
+	log.Info("shutting down", logger.String("signal", sig.String()))
 
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 
 	if err := server.Shutdown(shutdownCtx); err != nil {
-		// replace with Warn
-		fmt.Printf("server was unable to shutdown gracefully: %v", err)
+		log.Warn("server was unable to shutdown gracefully", logger.Err(err))
 	}
 
 	ringParser.Close()
+	services.DigestService.Close()
+	services.SubscriptionService.Close()
 	pg.Close()
 	telegram.Close()
 
+	// Spans of the last updates are still buffered
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Warn("unable to flush spans", logger.Err(err))
+	}
+
 	return nil
 }
 
-func addInitialUrls(ctx context.Context, ringParser *parser.RingParser, fetcher func(ctx context.Context) ([]string, error)) error {
+func addInitialUrls(ctx context.Context, ringParser *parser.RingParser, fetcher func(ctx context.Context) ([]string, error), log logger.Logger) error {
 	urls, err := fetcher(ctx)
 	if err != nil {
 		return err
 	}
 
 	for _, url := range urls {
-		fmt.Printf("adding url: %s\n", url)
 		ringParser.AddTarget(url)
 	}
 
+	log.Info("initial urls added", logger.Int("count", len(urls)))
+
 	return nil
 }
 
+// Archive of snapshots.backend. pg is only used by postgres backend
+func snapshotRepo(cfg *config.Config, pg *postgres.Postgres) repositories.SnapshotRepository {
+	if cfg.Snapshots.Backend == config.SnapshotsBackendFS {
+		return repositories.NewFSSnapshotRepo(cfg.Snapshots.Dir)
+	}
+
+	return repositories.NewSnapshotRepo(pg)
+}
+
 func parseFlags() (string, bool) {
 	configPath := flag.String("config", "", "path to config.yaml")
 	debug := flag.Bool("debug", false, "set debug mode (more logging)")
@@ -0,0 +1,153 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"parser/internal/config"
+	"parser/internal/domain/repositories"
+	"parser/internal/parser"
+	"parser/internal/postgres"
+	"parser/internal/replay"
+)
+
+var (
+	ErrRegressions       = errors.New("candidate extractor regressed")
+	ErrNoReplayPages     = errors.New("missing --dir, --snapshots or --config")
+	ErrSnapshotsDisabled = errors.New("snapshots.backend is not set, nothing is archived")
+)
+
+// Version of extractor built from --title-pattern and --price-pattern
+const patternCandidate = "candidate"
+
+// Replay re-runs extractors over archived pages offline and writes report to out.
+// Fails with ErrRegressions if candidate misses fields baseline extracted.
+//
+// Fixed matchers are checked before shipping by passing them as patterns,
+// they're compared with extractor used in production:
+//
+//	replay --dir ./pages --price-pattern 'style-price-value.*?<'
+//	replay --snapshots ./snapshots --failed-only --candidate v2 --baseline v1
+//	replay --config ./configs/prod.yml --failed-only
+func Replay(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("replay", flag.ContinueOnError)
+	flags.SetOutput(out)
+
+	var (
+		dir          = flags.String("dir", "", "directory of .html files")
+		snapshotsDir = flags.String("snapshots", "", "directory of fs snapshot archive (snapshots.dir)")
+		configPath   = flags.String("config", "", "config of service, archive of its snapshots.backend is replayed")
+		failedOnly   = flags.Bool("failed-only", false, "replay only snapshots parser failed on")
+		candidate    = flags.String("candidate", "", "extractor version being checked. Current one if empty")
+		titlePattern = flags.String("title-pattern", "", "title matcher of candidate, overrides --candidate")
+		pricePattern = flags.String("price-pattern", "", "price matcher of candidate, overrides --candidate")
+		baseline     = flags.String("baseline", "", "extractor version to compare with. "+
+			"Current one for patterns, previous one for --candidate if empty")
+	)
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	candidateExtractor, baselineExtractor, err := replayExtractors(*candidate, *titlePattern, *pricePattern)
+	if err != nil {
+		return err
+	}
+
+	if *baseline != "" {
+		baselineExtractor, err = parser.ExtractorVersion(*baseline)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Nothing to compare with
+	if baselineExtractor != nil && baselineExtractor.Version == candidateExtractor.Version {
+		baselineExtractor = nil
+	}
+
+	var pages []*replay.Page
+	switch {
+	case *dir != "":
+		pages, err = replay.LoadDir(*dir)
+	case *snapshotsDir != "":
+		pages, err = replay.LoadSnapshots(context.Background(), repositories.NewFSSnapshotRepo(*snapshotsDir), *failedOnly)
+	case *configPath != "":
+		pages, err = loadConfiguredSnapshots(context.Background(), *configPath, *failedOnly)
+	default:
+		return ErrNoReplayPages
+	}
+
+	if err != nil {
+		return fmt.Errorf("load pages: %w", err)
+	}
+
+	report := replay.Run(pages, candidateExtractor, baselineExtractor)
+	report.Write(out)
+
+	if len(report.Regressions()) > 0 {
+		return ErrRegressions
+	}
+
+	return nil
+}
+
+// Reads archive of service the same way it's written, see Bootstrap
+func loadConfiguredSnapshots(ctx context.Context, configPath string, failedOnly bool) ([]*replay.Page, error) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+
+	var pg *postgres.Postgres
+
+	switch cfg.Snapshots.Backend {
+	case "":
+		return nil, ErrSnapshotsDisabled
+	case config.SnapshotsBackendPostgres:
+		pg, err = postgres.FromConnectionString(ctx, cfg.Database.Url)
+		if err != nil {
+			return nil, fmt.Errorf("postgres: %w", err)
+		}
+
+		defer pg.Close()
+	}
+
+	return replay.LoadSnapshots(ctx, snapshotRepo(cfg, pg), failedOnly)
+}
+
+// Returns candidate and its default baseline. Candidate built from patterns
+// is compared with current extractor, missing pattern is taken from it
+func replayExtractors(version, titlePattern, pricePattern string) (*parser.Extractor, *parser.Extractor, error) {
+	current := parser.CurrentExtractor()
+
+	if titlePattern != "" || pricePattern != "" {
+		if titlePattern == "" {
+			titlePattern = current.TitlePattern()
+		}
+
+		if pricePattern == "" {
+			pricePattern = current.PricePattern()
+		}
+
+		candidate, err := parser.NewExtractor(patternCandidate, titlePattern, pricePattern)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return candidate, current, nil
+	}
+
+	if version == "" {
+		return current, parser.PreviousExtractor(), nil
+	}
+
+	candidate, err := parser.ExtractorVersion(version)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return candidate, parser.PreviousExtractor(), nil
+}
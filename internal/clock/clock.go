@@ -0,0 +1,44 @@
+// Package clock lets schedulers and caches run on fake time in tests, see Fake
+package clock
+
+import (
+	"time"
+)
+
+type Clock interface {
+	Now() time.Time
+	// Ticks every d like time.NewTicker does
+	NewTicker(d time.Duration) Ticker
+}
+
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Real is system clock
+func Real() Clock {
+	return realClock{}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return &realTicker{ticker: time.NewTicker(d)}
+}
+
+type realTicker struct {
+	ticker *time.Ticker
+}
+
+func (rt *realTicker) C() <-chan time.Time {
+	return rt.ticker.C
+}
+
+func (rt *realTicker) Stop() {
+	rt.ticker.Stop()
+}
@@ -0,0 +1,98 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Fake is clock that moves only by Advance
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*fakeTicker
+}
+
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.now
+}
+
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ticker := &fakeTicker{
+		clock:  f,
+		c:      make(chan time.Time, 1),
+		period: d,
+		next:   f.now.Add(d),
+	}
+
+	f.tickers = append(f.tickers, ticker)
+
+	return ticker
+}
+
+// Tickers is number of tickers that are not stopped yet
+func (f *Fake) Tickers() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.tickers)
+}
+
+// Advance moves time forward and fires tickers that are due.
+// Like with time.Ticker, ticks aren't queued: ones nobody has received
+// before the next one are dropped. Advance by one period at a time to get every tick
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+
+	for _, ticker := range f.tickers {
+		for !ticker.next.After(f.now) {
+			select {
+			case ticker.c <- ticker.next:
+			default:
+			}
+
+			ticker.next = ticker.next.Add(ticker.period)
+		}
+	}
+}
+
+type fakeTicker struct {
+	clock  *Fake
+	c      chan time.Time
+	period time.Duration
+	// Time of the next tick
+	next time.Time
+}
+
+func (ft *fakeTicker) C() <-chan time.Time {
+	return ft.c
+}
+
+// Stop takes effect immediately, Advance doesn't fire stopped tickers
+func (ft *fakeTicker) Stop() {
+	ft.clock.mu.Lock()
+	defer ft.clock.mu.Unlock()
+
+	for i, ticker := range ft.clock.tickers {
+		if ticker == ft {
+			ft.clock.tickers = append(ft.clock.tickers[:i], ft.clock.tickers[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2023, 1, 20, 12, 0, 0, 0, time.UTC)
+
+	t.Run("moves only by advance", func(t *testing.T) {
+		clock := NewFake(start)
+		require.Equal(t, start, clock.Now())
+
+		clock.Advance(time.Minute)
+		require.Equal(t, start.Add(time.Minute), clock.Now())
+	})
+
+	t.Run("fires due tickers", func(t *testing.T) {
+		clock := NewFake(start)
+		ticker := clock.NewTicker(time.Second)
+
+		clock.Advance(999 * time.Millisecond)
+		requireNoTick(t, ticker)
+
+		clock.Advance(time.Millisecond)
+		require.Equal(t, start.Add(time.Second), <-ticker.C())
+		requireNoTick(t, ticker)
+	})
+
+	t.Run("drops ticks nobody received", func(t *testing.T) {
+		clock := NewFake(start)
+		ticker := clock.NewTicker(time.Second)
+
+		clock.Advance(3 * time.Second)
+		require.Equal(t, start.Add(time.Second), <-ticker.C())
+		requireNoTick(t, ticker)
+
+		// Schedule is kept
+		clock.Advance(time.Second)
+		require.Equal(t, start.Add(4*time.Second), <-ticker.C())
+	})
+
+	t.Run("stopped ticker doesn't fire", func(t *testing.T) {
+		clock := NewFake(start)
+		stopped := clock.NewTicker(time.Second)
+		running := clock.NewTicker(time.Second)
+
+		require.Equal(t, 2, clock.Tickers())
+
+		stopped.Stop()
+		clock.Advance(time.Second)
+
+		requireNoTick(t, stopped)
+		<-running.C()
+		require.Equal(t, 1, clock.Tickers())
+	})
+}
+
+func requireNoTick(t *testing.T, ticker Ticker) {
+	t.Helper()
+
+	select {
+	case tick := <-ticker.C():
+		t.Fatalf("unexpected tick at %s", tick)
+	default:
+	}
+}
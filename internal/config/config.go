@@ -11,9 +11,52 @@ import (
 
 const (
 	defaultRwTimeout       = 5
+	defaultBodyLimit       = 1024
 	defaultParsingTimeout  = 10
 	defaultParsingInterval = 10
 	defaultParsingChanBuff = 2
+
+	defaultWebhookPath = "/telegram/webhook"
+
+	defaultEmailConfirmationTTL = 24
+
+	defaultWebhooksTimeout     = 5
+	defaultWebhooksRetries     = 3
+	defaultWebhooksMaxFailures = 10
+
+	defaultMessagesLocale = "ru"
+
+	defaultDigestsInterval       = 60
+	defaultDigestsCoalesceWindow = 120
+
+	defaultAuthTelegramMaxAge = 86400
+
+	defaultStreamHeartbeat = 15
+	defaultStreamBuffer    = 64
+
+	defaultHealthTimeout = 2
+
+	defaultTracingSampleRatio = 1.0
+
+	defaultFailuresAlertAfter = 10
+
+	defaultSnapshotsKeepPerURL      = 3
+	defaultSnapshotsKeepFailed      = 10
+	defaultSnapshotsFailedRetention = 14
+)
+
+const (
+	// Bot receives updates via long polling
+	TelegramModePolling = "polling"
+	// Bot receives updates via webhook served by http server
+	TelegramModeWebhook = "webhook"
+)
+
+const (
+	// Snapshots are files in snapshots.dir
+	SnapshotsBackendFS = "fs"
+	// Snapshots are large objects of database
+	SnapshotsBackendPostgres = "postgres"
 )
 
 var (
@@ -21,6 +64,16 @@ var (
 	ErrNoTelegramToken = errors.New("missing BOT_TOKEN")
 	ErrNoNetAddr       = errors.New("missing ADDR")
 
+	ErrInvalidTelegramMode = errors.New("telegram.mode should be either polling or webhook")
+	ErrNoWebhookURL        = errors.New("missing telegram.webhook.url")
+	ErrNoWebhookSecret     = errors.New("missing WEBHOOK_SECRET")
+
+	ErrNoEmailFrom       = errors.New("missing email.from")
+	ErrNoEmailConfirmURL = errors.New("missing email.confirm_url")
+
+	ErrInvalidSnapshotsBackend = errors.New("snapshots.backend should be either fs or postgres")
+	ErrNoSnapshotsDir          = errors.New("missing snapshots.dir")
+
 	ErrConfigNotFound = errors.New("config file not found")
 )
 
@@ -33,11 +86,37 @@ type Config struct {
 		// Read Write http timeout.
 		// Represented in seconds.
 		RWTimeout time.Duration
+
+		// Maximum size of request body.
+		// Represented in kilobytes.
+		BodyLimit int64
+
+		// Browser origins allowed to call API.
+		// e.g. https://example.com. "*" allows any.
+		CORSOrigins []string
 	}
 
 	Telegram struct {
 		// Telegram API bot token.
 		Token string
+
+		// How bot receives updates.
+		// Either TelegramModePolling or TelegramModeWebhook.
+		Mode string
+
+		// Used only in TelegramModeWebhook.
+		Webhook struct {
+			// Public URL telegram sends updates to.
+			// e.g. https://example.com/telegram/webhook.
+			URL string
+
+			// Path of http route that receives updates.
+			Path string
+
+			// Sent by telegram in X-Telegram-Bot-Api-Secret-Token header
+			// to prove that request is genuine.
+			SecretToken string
+		}
 	}
 
 	Parsing struct {
@@ -60,6 +139,138 @@ type Config struct {
 		// Database connection string
 		Url string
 	}
+
+	Email struct {
+		// Email notifications are disabled if false.
+		Enabled bool
+
+		// SMTP server address.
+		// e.g. smtp.example.com:587.
+		SMTPAddr string
+
+		// SMTP credentials. Could be empty.
+		SMTPUsername string
+		SMTPPassword string
+
+		// Address in From header.
+		// e.g. Avito Tracker <tracker@example.com>.
+		From string
+
+		// Public URL of email confirmation route.
+		// e.g. https://example.com/email/confirm.
+		ConfirmURL string
+
+		// How long confirmation link is valid.
+		// Represented in hours.
+		ConfirmationTTL time.Duration
+	}
+
+	Webhooks struct {
+		// Maximum amount of time for one delivery attempt.
+		// Represented in seconds.
+		Timeout time.Duration
+
+		// Amount of extra attempts after failed one.
+		Retries int
+
+		// Webhook is deactivated after that many failed deliveries in a row.
+		MaxFailures int
+	}
+
+	Messages struct {
+		// Used when subscriber's locale is unsupported.
+		// e.g. ru.
+		DefaultLocale string
+
+		// Overrides of built-in notification templates.
+		// locale -> template name -> text/template text.
+		Templates map[string]map[string]string
+	}
+
+	Digests struct {
+		// How often held alerts are checked for delivery.
+		// Represented in seconds.
+		Interval time.Duration
+
+		// Telegram alerts are held that long to merge
+		// rapid changes of advert into one alert.
+		// Zero disables coalescing.
+		// Represented in seconds.
+		CoalesceWindow time.Duration
+	}
+
+	Auth struct {
+		// Plain API key with admin scope registered on start.
+		// Other API keys are created with it. Could be empty.
+		AdminKey string
+
+		// Data signed by Telegram Login Widget or Web App
+		// is accepted that long after sign in.
+		// Represented in seconds.
+		TelegramMaxAge time.Duration
+	}
+
+	Stream struct {
+		// How often idle price stream sends heartbeat
+		// so proxies don't close connection.
+		// Represented in seconds.
+		Heartbeat time.Duration
+
+		// Price changes client could fall behind by
+		// before its stream is closed.
+		Buffer int
+	}
+
+	Health struct {
+		// Maximum amount of time for health check of one component.
+		// Represented in seconds.
+		Timeout time.Duration
+	}
+
+	Tracing struct {
+		// Where spans are sent. Either otlp or stdout.
+		// Tracing is disabled if empty.
+		Exporter string
+
+		// Address of OTLP/HTTP collector.
+		// e.g. localhost:4318. OTEL_EXPORTER_OTLP_ENDPOINT is used if empty.
+		Endpoint string
+
+		// Send spans over plain http.
+		Insecure bool
+
+		// Fraction of traces recorded, from 0 to 1.
+		SampleRatio float64
+	}
+
+	Failures struct {
+		// Operators are alerted after that many
+		// failed updates of advert in a row.
+		AlertAfter int
+
+		// Telegram chats operators are alerted in.
+		// Alerts are only logged if empty.
+		AlertChatIDs []int64
+	}
+
+	Snapshots struct {
+		// Where html of parsed pages is kept. Either fs or postgres.
+		// Snapshots are disabled if empty.
+		Backend string
+
+		// Directory of fs backend.
+		Dir string
+
+		// Pages parsed fine kept per advert.
+		KeepPerURL int
+
+		// Pages that failed to parse kept per advert.
+		KeepFailedPerURL int
+
+		// Pages that failed to parse are kept that long.
+		// Represented in days.
+		FailedRetention time.Duration
+	}
 }
 
 func Load(path string) (*Config, error) {
@@ -93,11 +304,186 @@ func Load(path string) (*Config, error) {
 		return nil, ErrNoTelegramToken
 	}
 
-	var netRwTimeout = viper.GetInt64("net.rw_timeout")
+	var (
+		telegramMode  = viper.GetString("telegram.mode")
+		webhookURL    = viper.GetString("telegram.webhook.url")
+		webhookPath   = viper.GetString("telegram.webhook.path")
+		webhookSecret string
+	)
+
+	if telegramMode == "" {
+		telegramMode = TelegramModePolling
+	}
+
+	if telegramMode != TelegramModePolling && telegramMode != TelegramModeWebhook {
+		return nil, ErrInvalidTelegramMode
+	}
+
+	if telegramMode == TelegramModeWebhook {
+		if webhookURL == "" {
+			return nil, ErrNoWebhookURL
+		}
+
+		// Empty secret would match requests without secret header
+		webhookSecret, ok = os.LookupEnv("WEBHOOK_SECRET")
+		if !ok || webhookSecret == "" {
+			return nil, ErrNoWebhookSecret
+		}
+
+		if webhookPath == "" {
+			webhookPath = defaultWebhookPath
+		}
+	}
+
+	var (
+		smtpAddr             = viper.GetString("email.smtp_addr")
+		emailFrom            = viper.GetString("email.from")
+		emailConfirmURL      = viper.GetString("email.confirm_url")
+		emailConfirmationTTL = viper.GetInt64("email.confirmation_ttl")
+	)
+
+	// Email is enabled by setting smtp address
+	if smtpAddr != "" {
+		if emailFrom == "" {
+			return nil, ErrNoEmailFrom
+		}
+
+		if emailConfirmURL == "" {
+			return nil, ErrNoEmailConfirmURL
+		}
+	}
+
+	if emailConfirmationTTL == 0 {
+		emailConfirmationTTL = defaultEmailConfirmationTTL
+	}
+
+	var (
+		webhooksTimeout     = viper.GetInt64("webhooks.timeout")
+		webhooksRetries     = viper.GetInt("webhooks.retries")
+		webhooksMaxFailures = viper.GetInt("webhooks.max_failures")
+	)
+
+	if webhooksTimeout == 0 {
+		webhooksTimeout = defaultWebhooksTimeout
+	}
+
+	// Explicit zero disables retries
+	if !viper.IsSet("webhooks.retries") {
+		webhooksRetries = defaultWebhooksRetries
+	}
+
+	if webhooksMaxFailures == 0 {
+		webhooksMaxFailures = defaultWebhooksMaxFailures
+	}
+
+	var messagesLocale = viper.GetString("messages.default_locale")
+	if messagesLocale == "" {
+		messagesLocale = defaultMessagesLocale
+	}
+
+	messagesTemplates := make(map[string]map[string]string)
+	for locale := range viper.GetStringMap("messages.templates") {
+		messagesTemplates[locale] = viper.GetStringMapString("messages.templates." + locale)
+	}
+
+	var (
+		digestsInterval       = viper.GetInt64("digests.interval")
+		digestsCoalesceWindow = viper.GetInt64("digests.coalesce_window")
+	)
+
+	if digestsInterval == 0 {
+		digestsInterval = defaultDigestsInterval
+	}
+
+	// Explicit zero disables coalescing
+	if !viper.IsSet("digests.coalesce_window") {
+		digestsCoalesceWindow = defaultDigestsCoalesceWindow
+	}
+
+	var authTelegramMaxAge = viper.GetInt64("auth.telegram_max_age")
+	if authTelegramMaxAge == 0 {
+		authTelegramMaxAge = defaultAuthTelegramMaxAge
+	}
+
+	var (
+		streamHeartbeat = viper.GetInt64("stream.heartbeat")
+		streamBuffer    = viper.GetInt("stream.buffer")
+	)
+
+	if streamHeartbeat == 0 {
+		streamHeartbeat = defaultStreamHeartbeat
+	}
+
+	if streamBuffer == 0 {
+		streamBuffer = defaultStreamBuffer
+	}
+
+	var healthTimeout = viper.GetInt64("health.timeout")
+	if healthTimeout == 0 {
+		healthTimeout = defaultHealthTimeout
+	}
+
+	var (
+		tracingExporter    = viper.GetString("tracing.exporter")
+		tracingSampleRatio = viper.GetFloat64("tracing.sample_ratio")
+	)
+
+	if tracingSampleRatio == 0 {
+		tracingSampleRatio = defaultTracingSampleRatio
+	}
+
+	var failuresAlertAfter = viper.GetInt("failures.alert_after")
+	if failuresAlertAfter == 0 {
+		failuresAlertAfter = defaultFailuresAlertAfter
+	}
+
+	var failuresAlertChatIDs []int64
+	for _, chatID := range viper.GetIntSlice("failures.alert_chat_ids") {
+		failuresAlertChatIDs = append(failuresAlertChatIDs, int64(chatID))
+	}
+
+	var (
+		snapshotsBackend         = viper.GetString("snapshots.backend")
+		snapshotsDir             = viper.GetString("snapshots.dir")
+		snapshotsKeepPerURL      = viper.GetInt("snapshots.keep_per_url")
+		snapshotsKeepFailed      = viper.GetInt("snapshots.keep_failed_per_url")
+		snapshotsFailedRetention = viper.GetInt64("snapshots.failed_retention")
+	)
+
+	if snapshotsBackend != "" && snapshotsBackend != SnapshotsBackendFS && snapshotsBackend != SnapshotsBackendPostgres {
+		return nil, ErrInvalidSnapshotsBackend
+	}
+
+	if snapshotsBackend == SnapshotsBackendFS && snapshotsDir == "" {
+		return nil, ErrNoSnapshotsDir
+	}
+
+	if snapshotsKeepPerURL == 0 {
+		snapshotsKeepPerURL = defaultSnapshotsKeepPerURL
+	}
+
+	if snapshotsKeepFailed == 0 {
+		snapshotsKeepFailed = defaultSnapshotsKeepFailed
+	}
+
+	if snapshotsFailedRetention == 0 {
+		snapshotsFailedRetention = defaultSnapshotsFailedRetention
+	}
+
+	var (
+		netRwTimeout   = viper.GetInt64("net.rw_timeout")
+		netBodyLimit   = viper.GetInt64("net.body_limit")
+		netCORSOrigins = viper.GetStringSlice("net.cors_origins")
+	)
+
 	if netRwTimeout == 0 {
 		netRwTimeout = defaultRwTimeout
 	}
 
+	if netBodyLimit == 0 {
+		netBodyLimit = defaultBodyLimit
+	}
+
 	var (
 		parsingInterval = viper.GetInt64("parsing.interval")
 		parsingTimeout  = viper.GetInt64("parsing.timeout")
@@ -116,16 +502,17 @@ func Load(path string) (*Config, error) {
 		parsingChanBuff = defaultParsingChanBuff
 	}
 
-	return &Config{
+	cfg := &Config{
 		Net: struct {
-			Addr      string
-			RWTimeout time.Duration
+			Addr        string
+			RWTimeout   time.Duration
+			BodyLimit   int64
+			CORSOrigins []string
 		}{
-			Addr:      netAddr,
-			RWTimeout: time.Duration(netRwTimeout) * time.Second,
-		},
-		Telegram: struct{ Token string }{
-			Token: token,
+			Addr:        netAddr,
+			RWTimeout:   time.Duration(netRwTimeout) * time.Second,
+			BodyLimit:   netBodyLimit * 1024,
+			CORSOrigins: netCORSOrigins,
 		},
 		Parsing: struct {
 			Interval time.Duration
@@ -139,6 +526,54 @@ func Load(path string) (*Config, error) {
 		Database: struct{ Url string }{
 			Url: dbUrl,
 		},
-	}, nil
+	}
+
+	cfg.Telegram.Token = token
+	cfg.Telegram.Mode = telegramMode
+	cfg.Telegram.Webhook.URL = webhookURL
+	cfg.Telegram.Webhook.Path = webhookPath
+	cfg.Telegram.Webhook.SecretToken = webhookSecret
+
+	cfg.Email.Enabled = smtpAddr != ""
+	cfg.Email.SMTPAddr = smtpAddr
+	cfg.Email.SMTPUsername = os.Getenv("SMTP_USERNAME")
+	cfg.Email.SMTPPassword = os.Getenv("SMTP_PASSWORD")
+	cfg.Email.From = emailFrom
+	cfg.Email.ConfirmURL = emailConfirmURL
+	cfg.Email.ConfirmationTTL = time.Duration(emailConfirmationTTL) * time.Hour
+
+	cfg.Webhooks.Timeout = time.Duration(webhooksTimeout) * time.Second
+	cfg.Webhooks.Retries = webhooksRetries
+	cfg.Webhooks.MaxFailures = webhooksMaxFailures
+
+	cfg.Messages.DefaultLocale = messagesLocale
+	cfg.Messages.Templates = messagesTemplates
+
+	cfg.Digests.Interval = time.Duration(digestsInterval) * time.Second
+	cfg.Digests.CoalesceWindow = time.Duration(digestsCoalesceWindow) * time.Second
+
+	cfg.Auth.AdminKey = os.Getenv("ADMIN_API_KEY")
+	cfg.Auth.TelegramMaxAge = time.Duration(authTelegramMaxAge) * time.Second
+
+	cfg.Stream.Heartbeat = time.Duration(streamHeartbeat) * time.Second
+	cfg.Stream.Buffer = streamBuffer
+
+	cfg.Health.Timeout = time.Duration(healthTimeout) * time.Second
+
+	cfg.Tracing.Exporter = tracingExporter
+	cfg.Tracing.Endpoint = viper.GetString("tracing.endpoint")
+	cfg.Tracing.Insecure = viper.GetBool("tracing.insecure")
+	cfg.Tracing.SampleRatio = tracingSampleRatio
+
+	cfg.Failures.AlertAfter = failuresAlertAfter
+	cfg.Failures.AlertChatIDs = failuresAlertChatIDs
+
+	cfg.Snapshots.Backend = snapshotsBackend
+	cfg.Snapshots.Dir = snapshotsDir
+	cfg.Snapshots.KeepPerURL = snapshotsKeepPerURL
+	cfg.Snapshots.KeepFailedPerURL = snapshotsKeepFailed
+	cfg.Snapshots.FailedRetention = time.Duration(snapshotsFailedRetention) * 24 * time.Hour
+
+	return cfg, nil
 
 }
@@ -1,13 +1,19 @@
 package domain
 
 import (
-	"errors"
+	apperrors "parser/internal/errors"
 
 	"github.com/google/uuid"
 )
 
 var (
-	ErrEmptyURL = errors.New("url must not be empty")
+	ErrEmptyURL = apperrors.Define(apperrors.ValidationKind, "empty_url", "url must not be empty")
+)
+
+// Names of advert fields that could change after parsing
+const (
+	FieldTitle = "title"
+	FieldPrice = "price"
 )
 
 type Advert struct {
@@ -17,6 +23,10 @@ type Advert struct {
 	currentPrice float64
 	lastPrice    float64
 	isParsed     bool
+
+	// Fields updated since advert was read from storage.
+	// Not persisted
+	changed []string
 }
 
 func NewAdvert(id, url, title string, currentPrice, lastPrice float64, isParsed bool) *Advert {
@@ -75,6 +85,22 @@ func (ad *Advert) HasTitle() bool {
 
 func (ad *Advert) UpdateTitle(title string) {
 	ad.title = title
+	ad.markChanged(FieldTitle)
+}
+
+// Returns fields that were updated. See FieldTitle, FieldPrice
+func (ad *Advert) ChangedFields() []string {
+	return ad.changed
+}
+
+func (ad *Advert) markChanged(field string) {
+	for _, f := range ad.changed {
+		if f == field {
+			return
+		}
+	}
+
+	ad.changed = append(ad.changed, field)
 }
 
 // Updates isParsed to TRUE
@@ -83,6 +109,8 @@ func (ad *Advert) Parsed() {
 }
 
 func (ad *Advert) UpdatePrice(price float64) {
+	ad.markChanged(FieldPrice)
+
 	// Advert is just created
 	if ad.lastPrice == 0 {
 		ad.lastPrice = price
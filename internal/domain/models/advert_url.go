@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"net/url"
+	apperrors "parser/internal/errors"
+	"regexp"
+	"strings"
+)
+
+// Host every advert URL is canonicalized to
+const CanonicalHost = "www.avito.ru"
+
+var (
+	ErrInvalidURL      = apperrors.Define(apperrors.ValidationKind, "invalid_url", "url should be a valid http(s) url")
+	ErrUnsupportedHost = apperrors.Define(apperrors.ValidationKind, "unsupported_host", "only avito.ru adverts are supported")
+	ErrNoItemID        = apperrors.Define(apperrors.ValidationKind, "no_item_id", "url should point to a single avito advert")
+)
+
+// Hosts that serve the same adverts as CanonicalHost
+var allowedHosts = map[string]struct{}{
+	"avito.ru":     {},
+	"www.avito.ru": {},
+	"m.avito.ru":   {},
+}
+
+// Item ID ends the last path segment, e.g. /moskva/telefony/iphone_13_2345678901
+var itemIDrx = regexp.MustCompile(`_(\d+)$`)
+
+// AdvertURL is validated advert URL in canonical form.
+// Different URLs of the same advert have equal canonical forms:
+//
+//	avito.ru/moskva/telefony/iphone_13_2345678901
+//	http://m.avito.ru/moskva/telefony/iphone_13_2345678901/?utm_source=tg
+//	https://www.avito.ru/moskva/telefony/iphone_13_2345678901#photos
+//
+// are all https://www.avito.ru/moskva/telefony/iphone_13_2345678901.
+// Query is dropped completely, advert page doesn't depend on it.
+// Canonical URLs of existing adverts were made by the same rule in migrations
+type AdvertURL struct {
+	canonical string
+	itemID    string
+}
+
+func ParseAdvertURL(raw string) (*AdvertURL, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, ErrEmptyURL
+	}
+
+	// e.g. avito.ru/moskva/...
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, ErrInvalidURL
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	if scheme != "http" && scheme != "https" {
+		return nil, ErrInvalidURL
+	}
+
+	// Port or credentials are never part of advert URL
+	if u.User != nil || u.Port() != "" {
+		return nil, ErrInvalidURL
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if _, ok := allowedHosts[host]; !ok {
+		return nil, ErrUnsupportedHost
+	}
+
+	path := strings.TrimRight(u.Path, "/")
+
+	match := itemIDrx.FindStringSubmatch(path)
+	if match == nil {
+		return nil, ErrNoItemID
+	}
+
+	canonical := url.URL{
+		Scheme: "https",
+		Host:   CanonicalHost,
+		Path:   path,
+	}
+
+	return &AdvertURL{
+		canonical: canonical.String(),
+		itemID:    match[1],
+	}, nil
+}
+
+func (au *AdvertURL) String() string {
+	return au.canonical
+}
+
+// Avito item ID, e.g. 2345678901
+func (au *AdvertURL) ItemID() string {
+	return au.itemID
+}
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAdvertURL(t *testing.T) {
+	const canonical = "https://www.avito.ru/moskva/telefony/iphone_13_2345678901"
+
+	t.Run("canonicalizes the same advert", func(t *testing.T) {
+		for _, raw := range []string{
+			canonical,
+			"avito.ru/moskva/telefony/iphone_13_2345678901",
+			"http://www.avito.ru/moskva/telefony/iphone_13_2345678901",
+			"https://m.avito.ru/moskva/telefony/iphone_13_2345678901/",
+			"https://WWW.Avito.RU/moskva/telefony/iphone_13_2345678901#photos",
+			"https://www.avito.ru/moskva/telefony/iphone_13_2345678901?utm_source=tg&context=H4sI&slocation=637640",
+			"  https://avito.ru/moskva/telefony/iphone_13_2345678901?UTM_Campaign=x  ",
+		} {
+			u, err := ParseAdvertURL(raw)
+			require.NoError(t, err, raw)
+			require.Equal(t, canonical, u.String(), raw)
+			require.Equal(t, "2345678901", u.ItemID(), raw)
+		}
+	})
+
+	t.Run("drops query like migration does", func(t *testing.T) {
+		for _, query := range []string{"?utm=1", "?a=1&b=2", "?utm_source=tg&b=2&a=1", "?"} {
+			u, err := ParseAdvertURL(canonical + query)
+			require.NoError(t, err, query)
+			require.Equal(t, canonical, u.String(), query)
+		}
+	})
+
+	t.Run("rejects invalid urls", func(t *testing.T) {
+		tests := map[string]error{
+			"":                                     ErrEmptyURL,
+			"ftp://avito.ru/moskva/item_1":         ErrInvalidURL,
+			"https://avito.ru:8080/moskva/item_1":  ErrInvalidURL,
+			"https://user@avito.ru/moskva/item_1":  ErrInvalidURL,
+			"https://avito.ru.evil.com/item_1":     ErrUnsupportedHost,
+			"https://www.ozon.ru/product/item_1":   ErrUnsupportedHost,
+			"https://www.avito.ru/moskva/telefony": ErrNoItemID,
+			"https://www.avito.ru/":                ErrNoItemID,
+		}
+
+		for raw, expected := range tests {
+			_, err := ParseAdvertURL(raw)
+			require.ErrorIs(t, err, expected, raw)
+		}
+	})
+}
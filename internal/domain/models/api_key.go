@@ -0,0 +1,190 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	apperrors "parser/internal/errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Scope is a set of API routes client is allowed to call
+type Scope string
+
+const (
+	ScopeSubscriptionsRead  Scope = "subscriptions:read"
+	ScopeSubscriptionsWrite Scope = "subscriptions:write"
+	ScopeWebhooks           Scope = "webhooks"
+	// Management of API keys and service itself
+	ScopeAdmin Scope = "admin"
+)
+
+const (
+	// Amount of random bytes in API key
+	apiKeySize = 32
+	// Plain API keys start with it, so they are easy to find in leaked configs
+	apiKeyPrefix = "avt_"
+	// Visible part of key that tells keys apart
+	apiKeyHintSize = 8
+
+	// Last use of API key is not recorded more often
+	apiKeyTouchInterval = time.Minute
+)
+
+var (
+	ErrUnknownScope    = apperrors.Define(apperrors.ValidationKind, "unknown_scope", "unknown scope")
+	ErrNoScopes        = apperrors.Define(apperrors.ValidationKind, "no_scopes", "at least one scope is required")
+	ErrEmptyAPIKeyName = apperrors.Define(apperrors.ValidationKind, "empty_api_key_name", "api key name must not be empty")
+	ErrNoAPIKey        = apperrors.Define(apperrors.NotFoundKind, "api_key_not_found", "api key does not exist")
+)
+
+// Scopes of end users that manage their own subscriptions
+func UserScopes() []Scope {
+	return []Scope{ScopeSubscriptionsRead, ScopeSubscriptionsWrite, ScopeWebhooks}
+}
+
+// Validates and deduplicates scopes
+func ParseScopes(raw []string) ([]Scope, error) {
+	if len(raw) == 0 {
+		return nil, ErrNoScopes
+	}
+
+	scopes := make([]Scope, 0, len(raw))
+	seen := make(map[Scope]struct{}, len(raw))
+
+	for _, r := range raw {
+		scope := Scope(r)
+
+		switch scope {
+		case ScopeSubscriptionsRead, ScopeSubscriptionsWrite, ScopeWebhooks, ScopeAdmin:
+		default:
+			return nil, ErrUnknownScope
+		}
+
+		if _, ok := seen[scope]; ok {
+			continue
+		}
+
+		seen[scope] = struct{}{}
+		scopes = append(scopes, scope)
+	}
+
+	return scopes, nil
+}
+
+func ScopesToStrings(scopes []Scope) []string {
+	out := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		out = append(out, string(scope))
+	}
+
+	return out
+}
+
+// APIKey authenticates server-to-server clients.
+// Only hash of key is stored, plain key is shown once on creation
+type APIKey struct {
+	APIKeyID string
+	name     string
+	// SHA-256 of plain key
+	hash string
+	// First characters of plain key
+	hint       string
+	scopes     []Scope
+	createdAt  time.Time
+	lastUsedAt time.Time
+}
+
+func NewAPIKey(id, name, hash, hint string, scopes []Scope, createdAt, lastUsedAt time.Time) *APIKey {
+	return &APIKey{
+		APIKeyID:   id,
+		name:       name,
+		hash:       hash,
+		hint:       hint,
+		scopes:     scopes,
+		createdAt:  createdAt,
+		lastUsedAt: lastUsedAt,
+	}
+}
+
+// Generates random key. Returns plain key along with APIKey
+func NewEmptyAPIKey(name string, scopes []Scope) (*APIKey, string, error) {
+	buff := make([]byte, apiKeySize)
+	if _, err := rand.Read(buff); err != nil {
+		return nil, "", err
+	}
+
+	plain := apiKeyPrefix + hex.EncodeToString(buff)
+
+	apiKey, err := NewAPIKeyFromPlain(name, plain, scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return apiKey, plain, nil
+}
+
+// Used for keys that are generated elsewhere, e.g. configured by operator
+func NewAPIKeyFromPlain(name, plain string, scopes []Scope) (*APIKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrEmptyAPIKeyName
+	}
+
+	if len(scopes) == 0 {
+		return nil, ErrNoScopes
+	}
+
+	hint := plain
+	if len(hint) > len(apiKeyPrefix)+apiKeyHintSize {
+		hint = hint[:len(apiKeyPrefix)+apiKeyHintSize]
+	}
+
+	return &APIKey{
+		APIKeyID:  uuid.NewString(),
+		name:      name,
+		hash:      HashAPIKey(plain),
+		hint:      hint,
+		scopes:    scopes,
+		createdAt: time.Now(),
+	}, nil
+}
+
+// Keys are random enough for plain SHA-256, no need for slow hashes
+func HashAPIKey(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+func (k *APIKey) Name() string {
+	return k.name
+}
+
+func (k *APIKey) Hash() string {
+	return k.hash
+}
+
+func (k *APIKey) Hint() string {
+	return k.hint
+}
+
+func (k *APIKey) Scopes() []Scope {
+	return k.scopes
+}
+
+func (k *APIKey) CreatedAt() time.Time {
+	return k.createdAt
+}
+
+// Zero if key has never been used
+func (k *APIKey) LastUsedAt() time.Time {
+	return k.lastUsedAt
+}
+
+// Reports whether use at now should be recorded
+func (k *APIKey) ShouldTouch(now time.Time) bool {
+	return now.Sub(k.lastUsedAt) >= apiKeyTouchInterval
+}
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	apperrors "parser/internal/errors"
+)
+
+// Channel is a way subscriber receives notifications
+type Channel string
+
+const (
+	ChannelTelegram Channel = "telegram"
+	ChannelEmail    Channel = "email"
+	ChannelWebhook  Channel = "webhook"
+)
+
+var (
+	ErrUnknownChannel = apperrors.Define(apperrors.ValidationKind, "unknown_channel", "unknown notification channel")
+	ErrNoChannels     = apperrors.Define(apperrors.ValidationKind, "no_channels", "at least one notification channel is required")
+)
+
+// Every channel is enabled by default.
+// Email and webhook are used only if subscriber has set them up
+func DefaultChannels() []Channel {
+	return []Channel{ChannelTelegram, ChannelEmail, ChannelWebhook}
+}
+
+// Validates and deduplicates channels
+func ParseChannels(raw []string) ([]Channel, error) {
+	if len(raw) == 0 {
+		return nil, ErrNoChannels
+	}
+
+	channels := make([]Channel, 0, len(raw))
+	seen := make(map[Channel]struct{}, len(raw))
+
+	for _, r := range raw {
+		channel := Channel(r)
+
+		switch channel {
+		case ChannelTelegram, ChannelEmail, ChannelWebhook:
+		default:
+			return nil, ErrUnknownChannel
+		}
+
+		if _, ok := seen[channel]; ok {
+			continue
+		}
+
+		seen[channel] = struct{}{}
+		channels = append(channels, channel)
+	}
+
+	return channels, nil
+}
+
+func ChannelsToStrings(channels []Channel) []string {
+	if channels == nil {
+		return nil
+	}
+
+	out := make([]string, 0, len(channels))
+	for _, channel := range channels {
+		out = append(out, string(channel))
+	}
+
+	return out
+}
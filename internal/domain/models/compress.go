@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+)
+
+// Stored pages are gzip compressed, they are mostly markup
+func compressPage(html string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	zw := gzip.NewWriter(&buf)
+	if _, err := io.WriteString(zw, html); err != nil {
+		return nil, err
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func decompressPage(page []byte) (string, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(page))
+	if err != nil {
+		return "", err
+	}
+
+	defer zr.Close()
+
+	html, err := io.ReadAll(zr)
+	if err != nil {
+		return "", err
+	}
+
+	return string(html), nil
+}
@@ -0,0 +1,57 @@
+package domain
+
+import "time"
+
+// DeadLetter is a page that failed to parse, kept for later inspection
+type DeadLetter struct {
+	// Zero until dead letter is stored
+	DeadLetterID int64
+	url          string
+	// Class of failure, e.g. "malformed". See parser.Outcome
+	class     string
+	err       string
+	page      []byte
+	createdAt time.Time
+}
+
+// NewDeadLetter compresses html of failed page
+func NewDeadLetter(url, class, err, html string, createdAt time.Time) (*DeadLetter, error) {
+	page, cerr := compressPage(html)
+	if cerr != nil {
+		return nil, cerr
+	}
+
+	return &DeadLetter{url: url, class: class, err: err, page: page, createdAt: createdAt}, nil
+}
+
+// DeadLetterFromDB takes page that is already compressed
+func DeadLetterFromDB(id int64, url, class, err string, page []byte, createdAt time.Time) *DeadLetter {
+	return &DeadLetter{DeadLetterID: id, url: url, class: class, err: err, page: page, createdAt: createdAt}
+}
+
+func (d *DeadLetter) URL() string {
+	return d.url
+}
+
+func (d *DeadLetter) Class() string {
+	return d.class
+}
+
+// Error is message of failure
+func (d *DeadLetter) Error() string {
+	return d.err
+}
+
+// Page is compressed html
+func (d *DeadLetter) Page() []byte {
+	return d.page
+}
+
+// HTML decompresses page
+func (d *DeadLetter) HTML() (string, error) {
+	return decompressPage(d.page)
+}
+
+func (d *DeadLetter) CreatedAt() time.Time {
+	return d.createdAt
+}
@@ -0,0 +1,12 @@
+package domain
+
+import (
+	"strconv"
+)
+
+// AlertFingerprint identifies content of alert about advert.
+// Alerts with equal fingerprints repeat each other, e.g.
+// the same price change is handled twice
+func AlertFingerprint(oldPrice, newPrice float64) string {
+	return strconv.FormatFloat(oldPrice, 'f', -1, 64) + "->" + strconv.FormatFloat(newPrice, 'f', -1, 64)
+}
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	apperrors "parser/internal/errors"
+	"time"
+)
+
+const (
+	// Amount of random bytes in confirmation token
+	confirmationTokenSize = 32
+)
+
+var (
+	ErrConfirmationNotFound = apperrors.Define(apperrors.NotFoundKind, "confirmation_not_found", "email confirmation does not exist")
+	ErrConfirmationExpired  = apperrors.Define(apperrors.DomainKind, "confirmation_expired", "email confirmation is expired")
+)
+
+// EmailConfirmation is created when subscriber wants to receive notifications via email.
+// Email is attached to subscriber only after confirmation link with Token is followed
+type EmailConfirmation struct {
+	Token        string
+	SubscriberID string
+	email        string
+	expiresAt    time.Time
+}
+
+func NewEmailConfirmation(token, subscriberID, email string, expiresAt time.Time) *EmailConfirmation {
+	return &EmailConfirmation{
+		Token:        token,
+		SubscriberID: subscriberID,
+		email:        email,
+		expiresAt:    expiresAt,
+	}
+}
+
+// Generates random token. Confirmation is valid within ttl
+func NewEmptyEmailConfirmation(subscriberID, email string, ttl time.Duration) (*EmailConfirmation, error) {
+	buff := make([]byte, confirmationTokenSize)
+	if _, err := rand.Read(buff); err != nil {
+		return nil, err
+	}
+
+	return &EmailConfirmation{
+		Token:        hex.EncodeToString(buff),
+		SubscriberID: subscriberID,
+		email:        email,
+		expiresAt:    time.Now().Add(ttl),
+	}, nil
+}
+
+func (ec *EmailConfirmation) Email() string {
+	return ec.email
+}
+
+func (ec *EmailConfirmation) ExpiresAt() time.Time {
+	return ec.expiresAt
+}
+
+func (ec *EmailConfirmation) IsExpired(now time.Time) bool {
+	return !now.Before(ec.expiresAt)
+}
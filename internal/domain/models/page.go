@@ -0,0 +1,31 @@
+package domain
+
+import apperrors "parser/internal/errors"
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+var (
+	ErrInvalidPage = apperrors.Define(apperrors.ValidationKind, "invalid_page", "limit should be within 1..100 and offset should not be negative")
+)
+
+// Page is a window of list results
+type Page struct {
+	Limit  uint64
+	Offset uint64
+}
+
+// Zero limit means DefaultPageLimit
+func NewPage(limit, offset int) (*Page, error) {
+	if limit == 0 {
+		limit = DefaultPageLimit
+	}
+
+	if limit < 0 || limit > MaxPageLimit || offset < 0 {
+		return nil, ErrInvalidPage
+	}
+
+	return &Page{Limit: uint64(limit), Offset: uint64(offset)}, nil
+}
@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PendingKind string
+
+const (
+	// Held until subscriber's digest. See Schedule
+	PendingDigest PendingKind = "digest"
+	// Held for coalescing window to merge rapid changes of advert
+	PendingCoalesce PendingKind = "coalesce"
+)
+
+// PendingNotification is an alert held until it's delivered
+// within digest or merged with following changes of advert
+type PendingNotification struct {
+	NotificationID string
+	SubscriberID   string
+	AdvertID       string
+	kind           PendingKind
+	title          string
+	url            string
+	oldPrice       float64
+	newPrice       float64
+	createdAt      time.Time
+}
+
+func NewPendingNotification(id, subscriberID, advertID string, kind PendingKind, title, url string, oldPrice, newPrice float64, createdAt time.Time) *PendingNotification {
+	return &PendingNotification{
+		NotificationID: id,
+		SubscriberID:   subscriberID,
+		AdvertID:       advertID,
+		kind:           kind,
+		title:          title,
+		url:            url,
+		oldPrice:       oldPrice,
+		newPrice:       newPrice,
+		createdAt:      createdAt,
+	}
+}
+
+// Holds current price change of advert
+func PendingFromAdvert(subscriberID string, ad *Advert, kind PendingKind, now time.Time) *PendingNotification {
+	return &PendingNotification{
+		NotificationID: uuid.NewString(),
+		SubscriberID:   subscriberID,
+		AdvertID:       ad.AdvertID,
+		kind:           kind,
+		title:          ad.Title(),
+		url:            ad.URL(),
+		oldPrice:       ad.LastPrice(),
+		newPrice:       ad.CurrentPrice(),
+		createdAt:      now,
+	}
+}
+
+func (pn *PendingNotification) Kind() PendingKind {
+	return pn.kind
+}
+
+func (pn *PendingNotification) Title() string {
+	return pn.title
+}
+
+func (pn *PendingNotification) URL() string {
+	return pn.url
+}
+
+func (pn *PendingNotification) OldPrice() float64 {
+	return pn.oldPrice
+}
+
+func (pn *PendingNotification) NewPrice() float64 {
+	return pn.newPrice
+}
+
+func (pn *PendingNotification) CreatedAt() time.Time {
+	return pn.createdAt
+}
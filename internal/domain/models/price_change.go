@@ -0,0 +1,61 @@
+package domain
+
+import "time"
+
+// PriceChange is a stored change of advert's price.
+// ChangeID grows monotonically across adverts, so it is used
+// as position in stream of changes. See Last-Event-ID of SSE
+type PriceChange struct {
+	// Zero until change is stored
+	ChangeID  int64
+	AdvertID  string
+	title     string
+	url       string
+	oldPrice  float64
+	newPrice  float64
+	changedAt time.Time
+}
+
+func NewPriceChange(id int64, advertID, title, url string, oldPrice, newPrice float64, changedAt time.Time) *PriceChange {
+	return &PriceChange{
+		ChangeID:  id,
+		AdvertID:  advertID,
+		title:     title,
+		url:       url,
+		oldPrice:  oldPrice,
+		newPrice:  newPrice,
+		changedAt: changedAt,
+	}
+}
+
+// Captures current price change of advert
+func PriceChangeFromAdvert(ad *Advert, now time.Time) *PriceChange {
+	return &PriceChange{
+		AdvertID:  ad.AdvertID,
+		title:     ad.Title(),
+		url:       ad.URL(),
+		oldPrice:  ad.LastPrice(),
+		newPrice:  ad.CurrentPrice(),
+		changedAt: now,
+	}
+}
+
+func (pc *PriceChange) Title() string {
+	return pc.title
+}
+
+func (pc *PriceChange) URL() string {
+	return pc.url
+}
+
+func (pc *PriceChange) OldPrice() float64 {
+	return pc.oldPrice
+}
+
+func (pc *PriceChange) NewPrice() float64 {
+	return pc.newPrice
+}
+
+func (pc *PriceChange) ChangedAt() time.Time {
+	return pc.changedAt
+}
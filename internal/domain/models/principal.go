@@ -0,0 +1,89 @@
+package domain
+
+import apperrors "parser/internal/errors"
+
+var (
+	ErrInvalidCredentials = apperrors.Define(apperrors.DomainKind, "invalid_credentials", "invalid credentials")
+	ErrCredentialsExpired = apperrors.Define(apperrors.DomainKind, "credentials_expired", "credentials are expired, sign in again")
+	// Principal lacks scope or acts on behalf of another telegram user
+	ErrForbidden = apperrors.Define(apperrors.DomainKind, "forbidden", "access denied")
+)
+
+type PrincipalKind string
+
+const (
+	// Server-to-server client, see APIKey
+	PrincipalAPIKey PrincipalKind = "api_key"
+	// End user signed in via Telegram Login Widget or Web App
+	PrincipalTelegram PrincipalKind = "telegram"
+)
+
+// Principal is an authenticated client of API
+type Principal struct {
+	kind PrincipalKind
+	// ID of api key or telegram user as string
+	id         string
+	telegramID int64
+	scopes     []Scope
+}
+
+func NewAPIKeyPrincipal(apiKey *APIKey) *Principal {
+	return &Principal{
+		kind:   PrincipalAPIKey,
+		id:     apiKey.APIKeyID,
+		scopes: apiKey.Scopes(),
+	}
+}
+
+// Telegram users have UserScopes
+func NewTelegramPrincipal(telegramID int64, id string) *Principal {
+	return &Principal{
+		kind:       PrincipalTelegram,
+		id:         id,
+		telegramID: telegramID,
+		scopes:     UserScopes(),
+	}
+}
+
+func (p *Principal) Kind() PrincipalKind {
+	return p.kind
+}
+
+func (p *Principal) ID() string {
+	return p.id
+}
+
+// Zero unless principal is telegram user
+func (p *Principal) TelegramID() int64 {
+	return p.telegramID
+}
+
+func (p *Principal) Scopes() []Scope {
+	return p.scopes
+}
+
+// Admin has every scope
+func (p *Principal) HasScope(scope Scope) bool {
+	for _, s := range p.scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Resolves subscriber principal acts on behalf of.
+// Telegram users could manage only themselves and may omit telegramID.
+// API keys act on behalf of any subscriber
+func (p *Principal) ActAs(telegramID int64) (int64, error) {
+	if p.kind != PrincipalTelegram {
+		return telegramID, nil
+	}
+
+	if telegramID != 0 && telegramID != p.telegramID {
+		return 0, ErrForbidden
+	}
+
+	return p.telegramID, nil
+}
@@ -0,0 +1,186 @@
+package domain
+
+import (
+	"fmt"
+	apperrors "parser/internal/errors"
+	"time"
+)
+
+type DigestMode string
+
+const (
+	// Alerts are sent immediately (unless quiet hours)
+	DigestOff DigestMode = "off"
+	// Alerts are batched and sent once an hour
+	DigestHourly DigestMode = "hourly"
+	// Alerts are batched and sent once a day at DailyDigestHour
+	DigestDaily DigestMode = "daily"
+
+	// Local hour daily digest is sent at
+	DailyDigestHour = 9
+
+	DefaultTimezone = "Europe/Moscow"
+
+	minutesInDay = 24 * 60
+)
+
+var (
+	ErrInvalidTimezone   = apperrors.Define(apperrors.ValidationKind, "invalid_timezone", "unknown timezone")
+	ErrInvalidQuietHours = apperrors.Define(apperrors.ValidationKind, "invalid_quiet_hours", "quiet hours should be HH:MM")
+	ErrInvalidDigestMode = apperrors.Define(apperrors.ValidationKind, "invalid_digest_mode", "digest should be one of off, hourly, daily")
+)
+
+// QuietHours is a daily period when alerts are held.
+// Represented in minutes since local midnight.
+// If From > To period wraps midnight, e.g. 23:00 - 08:00
+type QuietHours struct {
+	From int
+	To   int
+}
+
+// Parses "HH:MM" strings
+func ParseQuietHours(from, to string) (*QuietHours, error) {
+	fromMinutes, err := parseClock(from)
+	if err != nil {
+		return nil, err
+	}
+
+	toMinutes, err := parseClock(to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &QuietHours{From: fromMinutes, To: toMinutes}, nil
+}
+
+func (q *QuietHours) contains(minute int) bool {
+	if q.From <= q.To {
+		return minute >= q.From && minute < q.To
+	}
+
+	// Wraps midnight
+	return minute >= q.From || minute < q.To
+}
+
+func (q *QuietHours) String() string {
+	return fmt.Sprintf("%s-%s", formatClock(q.From), formatClock(q.To))
+}
+
+// Schedule describes when subscriber wants to receive alerts
+type Schedule struct {
+	timezone string
+	location *time.Location
+	// nil if subscriber has no quiet hours
+	quiet  *QuietHours
+	digest DigestMode
+}
+
+func NewSchedule(timezone string, quiet *QuietHours, digest DigestMode) (*Schedule, error) {
+	if timezone == "" {
+		timezone = DefaultTimezone
+	}
+
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, ErrInvalidTimezone
+	}
+
+	switch digest {
+	case "":
+		digest = DigestOff
+	case DigestOff, DigestHourly, DigestDaily:
+	default:
+		return nil, ErrInvalidDigestMode
+	}
+
+	if quiet != nil && (!validMinute(quiet.From) || !validMinute(quiet.To)) {
+		return nil, ErrInvalidQuietHours
+	}
+
+	return &Schedule{
+		timezone: timezone,
+		location: location,
+		quiet:    quiet,
+		digest:   digest,
+	}, nil
+}
+
+// Alerts are sent immediately at any time
+func DefaultSchedule() *Schedule {
+	schedule, _ := NewSchedule(DefaultTimezone, nil, DigestOff)
+	return schedule
+}
+
+func (s *Schedule) Timezone() string {
+	return s.timezone
+}
+
+func (s *Schedule) QuietHours() *QuietHours {
+	return s.quiet
+}
+
+func (s *Schedule) Digest() DigestMode {
+	return s.digest
+}
+
+// IsQuiet reports whether now is within subscriber's quiet hours
+func (s *Schedule) IsQuiet(now time.Time) bool {
+	if s.quiet == nil {
+		return false
+	}
+
+	local := now.In(s.location)
+	return s.quiet.contains(local.Hour()*60 + local.Minute())
+}
+
+// ShouldHold reports whether alert should be held until digest
+func (s *Schedule) ShouldHold(now time.Time) bool {
+	return s.digest != DigestOff || s.IsQuiet(now)
+}
+
+// IsDigestDue reports whether held alerts should be sent now.
+// lastDigestAt is zero if digest has never been sent
+func (s *Schedule) IsDigestDue(now, lastDigestAt time.Time) bool {
+	if s.IsQuiet(now) {
+		return false
+	}
+
+	switch s.digest {
+	case DigestOff:
+		// Alerts were held because of quiet hours that are over
+		return true
+	case DigestHourly:
+		return now.Sub(lastDigestAt) >= time.Hour
+	case DigestDaily:
+		local := now.In(s.location)
+		if local.Hour() < DailyDigestHour {
+			return false
+		}
+
+		lastLocal := lastDigestAt.In(s.location)
+		ly, lm, ld := lastLocal.Date()
+		y, m, d := local.Date()
+
+		// Not sent today yet
+		return ly != y || lm != m || ld != d
+	}
+
+	return false
+}
+
+func parseClock(clock string) (int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, ErrInvalidQuietHours
+	}
+
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func formatClock(minute int) string {
+	return fmt.Sprintf("%02d:%02d", minute/60, minute%60)
+}
+
+func validMinute(minute int) bool {
+	return minute >= 0 && minute < minutesInDay
+}
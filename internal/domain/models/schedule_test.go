@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedule(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2022, time.December, day, hour, minute, 0, 0, moscow)
+	}
+
+	t.Run("quiet hours wrap midnight", func(t *testing.T) {
+		quiet, err := ParseQuietHours("23:00", "08:00")
+		require.NoError(t, err)
+
+		schedule, err := NewSchedule("Europe/Moscow", quiet, DigestOff)
+		require.NoError(t, err)
+
+		require.True(t, schedule.IsQuiet(at(10, 3, 0)))
+		require.True(t, schedule.IsQuiet(at(10, 23, 0)))
+		require.False(t, schedule.IsQuiet(at(10, 8, 0)))
+		require.False(t, schedule.IsQuiet(at(10, 12, 0)))
+
+		// Same instant in UTC
+		require.True(t, schedule.IsQuiet(at(10, 3, 0).UTC()))
+
+		require.True(t, schedule.ShouldHold(at(10, 3, 0)))
+		require.False(t, schedule.ShouldHold(at(10, 12, 0)))
+
+		// Held alerts are sent once quiet hours are over
+		require.False(t, schedule.IsDigestDue(at(10, 7, 59), time.Time{}))
+		require.True(t, schedule.IsDigestDue(at(10, 8, 0), time.Time{}))
+	})
+
+	t.Run("hourly digest", func(t *testing.T) {
+		schedule, err := NewSchedule("", nil, DigestHourly)
+		require.NoError(t, err)
+
+		require.True(t, schedule.ShouldHold(at(10, 12, 0)))
+		require.True(t, schedule.IsDigestDue(at(10, 12, 0), time.Time{}))
+		require.False(t, schedule.IsDigestDue(at(10, 12, 30), at(10, 12, 0)))
+		require.True(t, schedule.IsDigestDue(at(10, 13, 0), at(10, 12, 0)))
+	})
+
+	t.Run("daily digest", func(t *testing.T) {
+		schedule, err := NewSchedule("Europe/Moscow", nil, DigestDaily)
+		require.NoError(t, err)
+
+		require.False(t, schedule.IsDigestDue(at(10, DailyDigestHour-1, 59), at(9, DailyDigestHour, 0)))
+		require.True(t, schedule.IsDigestDue(at(10, DailyDigestHour, 0), at(9, DailyDigestHour, 0)))
+		// Already sent today
+		require.False(t, schedule.IsDigestDue(at(10, 20, 0), at(10, DailyDigestHour, 0)))
+	})
+
+	t.Run("validates input", func(t *testing.T) {
+		_, err := ParseQuietHours("25:00", "08:00")
+		require.ErrorIs(t, err, ErrInvalidQuietHours)
+
+		_, err = NewSchedule("Mars/Olympus", nil, DigestOff)
+		require.ErrorIs(t, err, ErrInvalidTimezone)
+
+		_, err = NewSchedule("", nil, DigestMode("weekly"))
+		require.ErrorIs(t, err, ErrInvalidDigestMode)
+	})
+}
@@ -0,0 +1,122 @@
+package domain
+
+import (
+	apperrors "parser/internal/errors"
+	"sort"
+	"time"
+)
+
+var (
+	ErrNoSnapshot = apperrors.Define(apperrors.NotFoundKind, "snapshot_not_found", "snapshot doesn't exist")
+)
+
+// Snapshot is html of parsed page, kept to debug parser when markup changes
+type Snapshot struct {
+	// Zero until snapshot is stored
+	SnapshotID int64
+	url        string
+	// Error of parser. Empty if advert was extracted
+	err string
+	// Length of html before compression
+	size int
+	// Compressed html. Lists of snapshots come without it
+	page      []byte
+	createdAt time.Time
+}
+
+// NewSnapshot compresses html of parsed page. parseErr is nil if page was parsed fine
+func NewSnapshot(url, html string, parseErr error, createdAt time.Time) (*Snapshot, error) {
+	page, err := compressPage(html)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Snapshot{url: url, size: len(html), page: page, createdAt: createdAt}
+	if parseErr != nil {
+		s.err = parseErr.Error()
+	}
+
+	return s, nil
+}
+
+// SnapshotFromDB takes page that is already compressed, page is nil for lists
+func SnapshotFromDB(id int64, url, err string, size int, page []byte, createdAt time.Time) *Snapshot {
+	return &Snapshot{SnapshotID: id, url: url, err: err, size: size, page: page, createdAt: createdAt}
+}
+
+func (s *Snapshot) URL() string {
+	return s.url
+}
+
+// Failed reports page parser couldn't extract advert from
+func (s *Snapshot) Failed() bool {
+	return s.err != ""
+}
+
+func (s *Snapshot) Error() string {
+	return s.err
+}
+
+func (s *Snapshot) Size() int {
+	return s.size
+}
+
+// Page is compressed html
+func (s *Snapshot) Page() []byte {
+	return s.page
+}
+
+// HTML decompresses page
+func (s *Snapshot) HTML() (string, error) {
+	return decompressPage(s.page)
+}
+
+func (s *Snapshot) CreatedAt() time.Time {
+	return s.createdAt
+}
+
+// SnapshotRetention decides which snapshots of URL are deleted
+type SnapshotRetention struct {
+	// Pages parsed fine kept per URL
+	KeepPerURL int
+	// Failed pages kept per URL, so URL failing every cycle doesn't
+	// flood storage. Zero keeps all of them
+	KeepFailedPerURL int
+	// Failed pages are kept that long regardless of KeepPerURL
+	FailedMaxAge time.Duration
+}
+
+// Expired returns snapshots of single URL that should be deleted
+func (r *SnapshotRetention) Expired(snapshots []*Snapshot, now time.Time) []*Snapshot {
+	sorted := make([]*Snapshot, len(snapshots))
+	copy(sorted, snapshots)
+
+	// Newest first
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].createdAt.After(sorted[j].createdAt)
+	})
+
+	var (
+		expired    []*Snapshot
+		kept       int
+		keptFailed int
+	)
+
+	for _, s := range sorted {
+		if s.Failed() {
+			keptFailed++
+			if now.Sub(s.createdAt) > r.FailedMaxAge || (r.KeepFailedPerURL > 0 && keptFailed > r.KeepFailedPerURL) {
+				expired = append(expired, s)
+			}
+
+			continue
+		}
+
+		kept++
+		if kept > r.KeepPerURL {
+			expired = append(expired, s)
+		}
+	}
+
+	return expired
+}
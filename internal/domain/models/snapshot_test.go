@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	now := time.Date(2023, time.January, 16, 12, 0, 0, 0, time.UTC)
+
+	at := func(id int64, age time.Duration, err string) *Snapshot {
+		return SnapshotFromDB(id, "https://www.avito.ru/1", err, 0, nil, now.Add(-age))
+	}
+
+	t.Run("compresses page", func(t *testing.T) {
+		html := "<html><body><h1>iPhone 13</h1></body></html>"
+
+		snapshot, err := NewSnapshot("https://www.avito.ru/1", html, errors.New("no price"), now)
+		require.NoError(t, err)
+		require.True(t, snapshot.Failed())
+		require.Equal(t, len(html), snapshot.Size())
+
+		decompressed, err := snapshot.HTML()
+		require.NoError(t, err)
+		require.Equal(t, html, decompressed)
+	})
+
+	t.Run("keeps last pages and recent failures", func(t *testing.T) {
+		snapshots := []*Snapshot{
+			at(1, 5*time.Hour, ""),
+			at(2, 4*time.Hour, "no price"),
+			at(3, 3*time.Hour, ""),
+			at(4, 2*time.Hour, ""),
+			at(5, 48*time.Hour, "no title"),
+			at(6, time.Hour, ""),
+		}
+
+		retention := &SnapshotRetention{KeepPerURL: 2, FailedMaxAge: 24 * time.Hour}
+
+		var expired []int64
+		for _, s := range retention.Expired(snapshots, now) {
+			expired = append(expired, s.SnapshotID)
+		}
+
+		require.Equal(t, []int64{3, 1, 5}, expired)
+	})
+
+	t.Run("caps failed snapshots", func(t *testing.T) {
+		snapshots := []*Snapshot{
+			at(1, 3*time.Hour, "captcha"),
+			at(2, 2*time.Hour, "no title"),
+			at(3, time.Hour, "captcha"),
+			at(4, time.Hour, ""),
+		}
+
+		retention := &SnapshotRetention{KeepPerURL: 2, KeepFailedPerURL: 2, FailedMaxAge: 24 * time.Hour}
+
+		expired := retention.Expired(snapshots, now)
+		require.Len(t, expired, 1)
+		require.Equal(t, int64(1), expired[0].SnapshotID)
+	})
+}
@@ -1,30 +1,47 @@
 package domain
 
 import (
-	"errors"
+	apperrors "parser/internal/errors"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 var (
-	ErrNoSubscriptions = errors.New("empty subscriptions")
+	ErrNoSubscriptions = apperrors.Define(apperrors.DomainKind, "no_subscriptions", "empty subscriptions")
+	ErrNoSubscriber    = apperrors.Define(apperrors.NotFoundKind, "subscriber_not_found", "subscriber does not exist")
 )
 
 type Subscriber struct {
 	SubscriberID string
 	telegramID   int64
+	// User has blocked the bot or deleted an account.
+	// Other channels are still notified
+	telegramUnreachable bool
+	// Confirmed email. Empty if subscriber has not confirmed any
+	email string
+	// Channels used for subscriptions that don't override them
+	channels []Channel
+	// Language of notifications, e.g. "ru"
+	locale string
+	// When alerts are sent. See Schedule
+	schedule *Schedule
+	// Zero if digest has never been sent
+	lastDigestAt time.Time
 
 	subscriptions []*Subscription
 }
 
-func NewSubscriber(id string, telegramID int64) *Subscriber {
-	return &Subscriber{SubscriberID: id, telegramID: telegramID}
+func NewSubscriber(id string, telegramID int64, email string, channels []Channel, locale string) *Subscriber {
+	return &Subscriber{SubscriberID: id, telegramID: telegramID, email: email, channels: channels, locale: locale}
 }
 
+// Locale should be set with SetLocale before subscriber is saved
 func SubscriberFromTelegramID(telegramID int64) *Subscriber {
 	return &Subscriber{
 		SubscriberID:  uuid.NewString(),
 		telegramID:    telegramID,
+		channels:      DefaultChannels(),
 		subscriptions: nil,
 	}
 }
@@ -33,6 +50,81 @@ func (s *Subscriber) TelegramID() int64 {
 	return s.telegramID
 }
 
+func (s *Subscriber) TelegramReachable() bool {
+	return !s.telegramUnreachable
+}
+
+func (s *Subscriber) SetTelegramReachable(reachable bool) {
+	s.telegramUnreachable = !reachable
+}
+
+func (s *Subscriber) Email() string {
+	return s.email
+}
+
+func (s *Subscriber) HasEmail() bool {
+	return s.email != ""
+}
+
+func (s *Subscriber) Locale() string {
+	return s.locale
+}
+
+func (s *Subscriber) SetLocale(locale string) {
+	s.locale = locale
+}
+
+// Returns DefaultSchedule if schedule is not set
+func (s *Subscriber) Schedule() *Schedule {
+	if s.schedule == nil {
+		return DefaultSchedule()
+	}
+
+	return s.schedule
+}
+
+func (s *Subscriber) SetSchedule(schedule *Schedule) {
+	s.schedule = schedule
+}
+
+func (s *Subscriber) LastDigestAt() time.Time {
+	return s.lastDigestAt
+}
+
+func (s *Subscriber) SetLastDigestAt(lastDigestAt time.Time) {
+	s.lastDigestAt = lastDigestAt
+}
+
+func (s *Subscriber) Channels() []Channel {
+	return s.channels
+}
+
+// Returns channels notifications about advert are sent to.
+// Subscription's channels take precedence over subscriber's ones.
+// Telegram is left out while bot can't reach subscriber
+func (s *Subscriber) ChannelsFor(advertID string) []Channel {
+	channels := s.channels
+	for _, subscription := range s.subscriptions {
+		if subscription.AdvertID == advertID && subscription.HasChannels() {
+			channels = subscription.Channels()
+			break
+		}
+	}
+
+	if s.TelegramReachable() {
+		return channels
+	}
+
+	reachable := make([]Channel, 0, len(channels))
+	for _, channel := range channels {
+		if channel != ChannelTelegram {
+			reachable = append(reachable, channel)
+		}
+	}
+
+	return reachable
+}
+
 func (s *Subscriber) AddSubscription(subscriptions ...*Subscription) {
 	s.subscriptions = append(s.subscriptions, subscriptions...)
 }
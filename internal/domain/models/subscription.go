@@ -1,15 +1,29 @@
 package domain
 
-import "errors"
+import (
+	apperrors "parser/internal/errors"
+	"time"
+)
 
 var (
-	ErrSubscriptionExist = errors.New("subscription already exists")
+	ErrSubscriptionExist = apperrors.Define(apperrors.ConflictKind, "subscription_exists", "subscription already exists")
+	ErrNoSubscription    = apperrors.Define(apperrors.NotFoundKind, "subscription_not_found", "subscription does not exist")
 )
 
 // TODO: db model
 type Subscription struct {
 	SubscriberID string `db:"subscriber_id"`
 	AdvertID     string `db:"advert_id"`
+
+	// Overrides subscriber's channels for this advert.
+	// nil means subscriber's channels are used
+	channels []Channel
+	// Muted subscription doesn't receive alerts
+	isMuted   bool
+	createdAt time.Time
+
+	// Attached when subscription is read with its advert. Could be nil
+	advert *Advert
 }
 
 func NewSubscription(subscriberID, advertID string) *Subscription {
@@ -18,3 +32,40 @@ func NewSubscription(subscriberID, advertID string) *Subscription {
 		AdvertID:     advertID,
 	}
 }
+
+func (s *Subscription) Channels() []Channel {
+	return s.channels
+}
+
+func (s *Subscription) HasChannels() bool {
+	return s.channels != nil
+}
+
+// Pass nil to fallback to subscriber's channels
+func (s *Subscription) OverrideChannels(channels []Channel) {
+	s.channels = channels
+}
+
+func (s *Subscription) IsMuted() bool {
+	return s.isMuted
+}
+
+func (s *Subscription) SetMuted(isMuted bool) {
+	s.isMuted = isMuted
+}
+
+func (s *Subscription) CreatedAt() time.Time {
+	return s.createdAt
+}
+
+func (s *Subscription) SetCreatedAt(createdAt time.Time) {
+	s.createdAt = createdAt
+}
+
+func (s *Subscription) Advert() *Advert {
+	return s.advert
+}
+
+func (s *Subscription) AttachAdvert(ad *Advert) {
+	s.advert = ad
+}
@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"net/url"
+	apperrors "parser/internal/errors"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Amount of random bytes in webhook secret
+	webhookSecretSize = 32
+)
+
+var (
+	ErrInvalidWebhookURL = apperrors.Define(apperrors.ValidationKind, "invalid_webhook_url", "webhook url should be absolute http(s) url")
+	ErrNoWebhook         = apperrors.Define(apperrors.NotFoundKind, "webhook_not_found", "webhook does not exist")
+	// Webhooks must not reach services of our own network
+	ErrPrivateWebhookHost = apperrors.Define(apperrors.ValidationKind, "private_webhook_host", "webhook url should point to public host")
+)
+
+// Webhook is subscriber-registered URL that receives price changes as JSON.
+// Payloads are signed with secret
+type Webhook struct {
+	WebhookID    string
+	SubscriberID string
+	url          string
+	secret       string
+	isActive     bool
+	// Failed deliveries in a row
+	failures int
+}
+
+func NewWebhook(id, subscriberID, url, secret string, isActive bool, failures int) *Webhook {
+	return &Webhook{
+		WebhookID:    id,
+		SubscriberID: subscriberID,
+		url:          url,
+		secret:       secret,
+		isActive:     isActive,
+		failures:     failures,
+	}
+}
+
+// Validates URL and generates random secret
+func NewEmptyWebhook(subscriberID, URL string) (*Webhook, error) {
+	u, err := url.Parse(URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, ErrInvalidWebhookURL
+	}
+
+	// Names resolving to private addresses are rejected when dialed,
+	// see notify.NewWebhookNotifier
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return nil, ErrPrivateWebhookHost
+	}
+
+	if ip := net.ParseIP(host); ip != nil && !IsPublicIP(ip) {
+		return nil, ErrPrivateWebhookHost
+	}
+
+	buff := make([]byte, webhookSecretSize)
+	if _, err := rand.Read(buff); err != nil {
+		return nil, err
+	}
+
+	return &Webhook{
+		WebhookID:    uuid.NewString(),
+		SubscriberID: subscriberID,
+		url:          u.String(),
+		secret:       hex.EncodeToString(buff),
+		isActive:     true,
+		failures:     0,
+	}, nil
+}
+
+// IsPublicIP is false for loopback, private, link-local and other
+// addresses webhooks must not be delivered to
+func IsPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified())
+}
+
+func (w *Webhook) URL() string {
+	return w.url
+}
+
+func (w *Webhook) Secret() string {
+	return w.secret
+}
+
+func (w *Webhook) IsActive() bool {
+	return w.isActive
+}
+
+func (w *Webhook) Failures() int {
+	return w.failures
+}
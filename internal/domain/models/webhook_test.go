@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEmptyWebhook(t *testing.T) {
+	t.Run("accepts public urls", func(t *testing.T) {
+		for _, raw := range []string{
+			"https://example.com/hooks/price",
+			"http://93.184.216.34:8080/hook",
+		} {
+			webhook, err := NewEmptyWebhook("sub-1", raw)
+			require.NoError(t, err, raw)
+			require.Equal(t, raw, webhook.URL())
+			require.Len(t, webhook.Secret(), 2*webhookSecretSize)
+		}
+	})
+
+	t.Run("rejects private hosts", func(t *testing.T) {
+		for _, raw := range []string{
+			"http://localhost:8080/hook",
+			"http://api.localhost/hook",
+			"http://127.0.0.1/hook",
+			"http://10.0.0.5/hook",
+			"http://192.168.1.1/hook",
+			"http://169.254.169.254/latest/meta-data",
+			"http://[::1]/hook",
+			"http://[fd00::1]/hook",
+			"http://0.0.0.0/hook",
+		} {
+			_, err := NewEmptyWebhook("sub-1", raw)
+			require.ErrorIs(t, err, ErrPrivateWebhookHost, raw)
+		}
+	})
+
+	t.Run("rejects invalid urls", func(t *testing.T) {
+		for _, raw := range []string{"example.com/hook", "ftp://example.com", "http://"} {
+			_, err := NewEmptyWebhook("sub-1", raw)
+			require.ErrorIs(t, err, ErrInvalidWebhookURL, raw)
+		}
+	})
+}
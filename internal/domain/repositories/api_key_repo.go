@@ -0,0 +1,168 @@
+package repositories
+
+import (
+	"context"
+	domain "parser/internal/domain/models"
+	"parser/internal/postgres"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+type APIKeyRepository interface {
+	// Key with the same hash is left as is, even if revoked
+	Insert(ctx context.Context, apiKey *domain.APIKey) error
+	// Returns nil if there's no such key or it is revoked
+	GetByHash(ctx context.Context, hash string) (*domain.APIKey, error)
+	// Returns keys that are not revoked from oldest to newest
+	List(ctx context.Context) ([]*domain.APIKey, error)
+	// Returns false if there's no such key or it is revoked already
+	Revoke(ctx context.Context, apiKeyID string, revokedAt time.Time) (bool, error)
+	Touch(ctx context.Context, apiKeyID string, usedAt time.Time) error
+}
+
+type apiKeyRepo struct {
+	db *postgres.Postgres
+}
+
+func NewAPIKeyRepo(db *postgres.Postgres) APIKeyRepository {
+	return &apiKeyRepo{db: db}
+}
+
+func (a *apiKeyRepo) Insert(ctx context.Context, apiKey *domain.APIKey) error {
+	sql, args, err := sq.Insert("api_keys").
+		Columns("api_key_id", "name", "key_hash", "hint", "scopes", "created_at").
+		Values(
+			apiKey.APIKeyID,
+			apiKey.Name(),
+			apiKey.Hash(),
+			apiKey.Hint(),
+			domain.ScopesToStrings(apiKey.Scopes()),
+			apiKey.CreatedAt(),
+		).
+		Suffix("ON CONFLICT (key_hash) DO NOTHING").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, release, err := a.db.Exec(ctx, sql, args)
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	return nil
+}
+
+func (a *apiKeyRepo) GetByHash(ctx context.Context, hash string) (*domain.APIKey, error) {
+	sql, args, err := selectAPIKeys().
+		Where(sq.Eq{
+			"key_hash": hash,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, release, err := a.db.Query(ctx, sql, args)
+	if err != nil {
+		return nil, err
+	}
+
+	defer release()
+
+	var dbkey postgres.APIKeyDB
+	err = a.db.ScanOne(rows, &dbkey)
+	if err != nil {
+		return nil, postgres.CheckEmptyRows(err)
+	}
+
+	return dbkey.ToDomain(), nil
+}
+
+func (a *apiKeyRepo) List(ctx context.Context) ([]*domain.APIKey, error) {
+	sql, args, err := selectAPIKeys().
+		OrderBy("created_at").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, release, err := a.db.Query(ctx, sql, args)
+	if err != nil {
+		return nil, err
+	}
+
+	defer release()
+
+	var dbkeys []*postgres.APIKeyDB
+	err = a.db.ScanAll(rows, &dbkeys)
+	if err != nil {
+		return nil, postgres.CheckEmptyRows(err)
+	}
+
+	apiKeys := make([]*domain.APIKey, 0, len(dbkeys))
+	for _, dbkey := range dbkeys {
+		apiKeys = append(apiKeys, dbkey.ToDomain())
+	}
+
+	return apiKeys, nil
+}
+
+func (a *apiKeyRepo) Revoke(ctx context.Context, apiKeyID string, revokedAt time.Time) (bool, error) {
+	sql, args, err := sq.Update("api_keys").
+		Set("revoked_at", revokedAt).
+		Where(sq.Eq{
+			"api_key_id": apiKeyID,
+			"revoked_at": nil,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		return false, err
+	}
+
+	tag, release, err := a.db.Exec(ctx, sql, args)
+	if err != nil {
+		return false, err
+	}
+
+	defer release()
+
+	return tag.RowsAffected() > 0, nil
+}
+
+func (a *apiKeyRepo) Touch(ctx context.Context, apiKeyID string, usedAt time.Time) error {
+	sql, args := sq.Update("api_keys").
+		Set("last_used_at", usedAt).
+		Where(sq.Eq{
+			"api_key_id": apiKeyID,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		MustSql()
+
+	_, release, err := a.db.Exec(ctx, sql, args)
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	return nil
+}
+
+// Revoked keys are never selected
+func selectAPIKeys() sq.SelectBuilder {
+	return sq.Select("api_key_id, name, key_hash, hint, scopes, created_at, last_used_at").
+		From("api_keys").
+		Where("revoked_at IS NULL")
+}
@@ -0,0 +1,50 @@
+package repositories
+
+import (
+	"context"
+	domain "parser/internal/domain/models"
+	"parser/internal/postgres"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+type DeadLetterRepository interface {
+	// Returns ID assigned to dead letter
+	Insert(ctx context.Context, letter *domain.DeadLetter) (int64, error)
+}
+
+type deadLetterRepo struct {
+	db *postgres.Postgres
+}
+
+func NewDeadLetterRepo(db *postgres.Postgres) DeadLetterRepository {
+	return &deadLetterRepo{db: db}
+}
+
+func (d *deadLetterRepo) Insert(ctx context.Context, letter *domain.DeadLetter) (int64, error) {
+	sql, args, err := sq.Insert("dead_letters").
+		Columns("url", "class", "error", "page", "created_at").
+		Values(letter.URL(), letter.Class(), letter.Error(), letter.Page(), letter.CreatedAt()).
+		Suffix("RETURNING dead_letter_id").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		return 0, err
+	}
+
+	rows, release, err := d.db.Query(ctx, sql, args)
+	if err != nil {
+		return 0, err
+	}
+
+	defer release()
+
+	var deadLetterID int64
+	err = d.db.ScanOne(rows, &deadLetterID)
+	if err != nil {
+		return 0, err
+	}
+
+	return deadLetterID, nil
+}
@@ -0,0 +1,216 @@
+package repositories
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	domain "parser/internal/domain/models"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	fsSnapshotMeta = ".json"
+	fsSnapshotPage = ".html.gz"
+)
+
+// Metadata of snapshot stored next to its page
+type fsSnapshot struct {
+	URL       string    `json:"url"`
+	Error     string    `json:"error,omitempty"`
+	Size      int       `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Snapshots of URL are kept in directory named by hash of URL:
+// <dir>/<hash>/<snapshot_id>.json and <snapshot_id>.html.gz.
+// IDs are nanoseconds of creation, so they grow the same way serial ones do
+type fsSnapshotRepo struct {
+	dir string
+
+	mu     sync.Mutex
+	lastID int64
+}
+
+func NewFSSnapshotRepo(dir string) SnapshotRepository {
+	return &fsSnapshotRepo{dir: dir}
+}
+
+func (f *fsSnapshotRepo) Insert(ctx context.Context, snapshot *domain.Snapshot) error {
+	urlDir := filepath.Join(f.dir, urlHash(snapshot.URL()))
+	if err := os.MkdirAll(urlDir, 0o755); err != nil {
+		return err
+	}
+
+	id := f.nextID()
+	base := filepath.Join(urlDir, strconv.FormatInt(id, 10))
+
+	if err := os.WriteFile(base+fsSnapshotPage, snapshot.Page(), 0o644); err != nil {
+		return err
+	}
+
+	meta, err := json.Marshal(&fsSnapshot{
+		URL:       snapshot.URL(),
+		Error:     snapshot.Error(),
+		Size:      snapshot.Size(),
+		CreatedAt: snapshot.CreatedAt(),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Metadata is written last, so lists never see snapshot without page
+	if err := os.WriteFile(base+fsSnapshotMeta, meta, 0o644); err != nil {
+		return err
+	}
+
+	snapshot.SnapshotID = id
+
+	return nil
+}
+
+func (f *fsSnapshotRepo) List(ctx context.Context, url string, page *domain.Page) ([]*domain.Snapshot, error) {
+	pattern := filepath.Join(f.dir, "*", "*"+fsSnapshotMeta)
+	if url != "" {
+		pattern = filepath.Join(f.dir, urlHash(url), "*"+fsSnapshotMeta)
+	}
+
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]*domain.Snapshot, 0, len(paths))
+	for _, path := range paths {
+		snapshot, err := f.read(path, false)
+		if errors.Is(err, os.ErrNotExist) {
+			// Deleted meanwhile
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		snapshots = append(snapshots, snapshot)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].SnapshotID > snapshots[j].SnapshotID
+	})
+
+	if page == nil {
+		return snapshots, nil
+	}
+
+	if page.Offset >= uint64(len(snapshots)) {
+		return nil, nil
+	}
+
+	snapshots = snapshots[page.Offset:]
+	if page.Limit < uint64(len(snapshots)) {
+		snapshots = snapshots[:page.Limit]
+	}
+
+	return snapshots, nil
+}
+
+func (f *fsSnapshotRepo) Get(ctx context.Context, snapshotID int64) (*domain.Snapshot, error) {
+	path, err := f.find(snapshotID)
+	if err != nil || path == "" {
+		return nil, err
+	}
+
+	snapshot, err := f.read(path, true)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	return snapshot, err
+}
+
+func (f *fsSnapshotRepo) Delete(ctx context.Context, snapshotIDs []int64) error {
+	for _, snapshotID := range snapshotIDs {
+		path, err := f.find(snapshotID)
+		if err != nil {
+			return err
+		}
+
+		if path == "" {
+			continue
+		}
+
+		base := strings.TrimSuffix(path, fsSnapshotMeta)
+		for _, name := range []string{base + fsSnapshotMeta, base + fsSnapshotPage} {
+			if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Returns path of metadata. Empty if there is no such snapshot
+func (f *fsSnapshotRepo) find(snapshotID int64) (string, error) {
+	paths, err := filepath.Glob(filepath.Join(f.dir, "*", strconv.FormatInt(snapshotID, 10)+fsSnapshotMeta))
+	if err != nil || len(paths) == 0 {
+		return "", err
+	}
+
+	return paths[0], nil
+}
+
+func (f *fsSnapshotRepo) read(path string, withPage bool) (*domain.Snapshot, error) {
+	id, err := strconv.ParseInt(strings.TrimSuffix(filepath.Base(path), fsSnapshotMeta), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var meta fsSnapshot
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		return nil, err
+	}
+
+	var page []byte
+	if withPage {
+		page, err = os.ReadFile(strings.TrimSuffix(path, fsSnapshotMeta) + fsSnapshotPage)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return domain.SnapshotFromDB(id, meta.URL, meta.Error, meta.Size, page, meta.CreatedAt), nil
+}
+
+// Unique even if two snapshots are taken within the same nanosecond
+func (f *fsSnapshotRepo) nextID() int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	id := time.Now().UnixNano()
+	if id <= f.lastID {
+		id = f.lastID + 1
+	}
+
+	f.lastID = id
+
+	return id
+}
+
+func urlHash(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:8])
+}
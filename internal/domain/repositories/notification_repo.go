@@ -0,0 +1,301 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	domain "parser/internal/domain/models"
+	"parser/internal/postgres"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// NotificationRepository stores held alerts and the last delivered ones
+type NotificationRepository interface {
+	InsertPending(ctx context.Context, notification *domain.PendingNotification) error
+	DeletePending(ctx context.Context, notificationIDs []string) error
+
+	// Returns active subscribers that have held alerts.
+	// Subscribers carry schedule and time of the last digest
+	GetPendingSubscribers(ctx context.Context) ([]*domain.Subscriber, error)
+	// Returns held alerts of subscriber from oldest to newest
+	GetPending(ctx context.Context, subscriberID string) ([]*domain.PendingNotification, error)
+
+	// Deletes sent alerts and remembers when digest was sent
+	CompleteDigest(ctx context.Context, subscriberID string, notificationIDs []string, sentAt time.Time) error
+
+	// Returns fingerprint of the last alert delivered to subscriber about advert.
+	// Empty if there's none. See domain.AlertFingerprint
+	GetLastFingerprint(ctx context.Context, subscriberID, advertID string, channel domain.Channel) (string, error)
+	// Remembers alert as the last delivered one
+	RecordDelivery(ctx context.Context, subscriberID, advertID string, channel domain.Channel, fingerprint string, deliveredAt time.Time) error
+	// Counts alert that is suppressed as a repeat of the last delivered one
+	RecordSuppression(ctx context.Context, subscriberID, advertID string, channel domain.Channel, suppressedAt time.Time) error
+}
+
+type notificationRepo struct {
+	db *postgres.Postgres
+}
+
+func NewNotificationRepo(db *postgres.Postgres) NotificationRepository {
+	return &notificationRepo{db: db}
+}
+
+func (n *notificationRepo) InsertPending(ctx context.Context, notification *domain.PendingNotification) error {
+	sql, args, err := sq.Insert("pending_notifications").
+		Columns("notification_id", "subscriber_id", "advert_id", "kind", "title", "url", "old_price", "new_price", "created_at").
+		Values(
+			notification.NotificationID,
+			notification.SubscriberID,
+			notification.AdvertID,
+			string(notification.Kind()),
+			notification.Title(),
+			notification.URL(),
+			notification.OldPrice(),
+			notification.NewPrice(),
+			notification.CreatedAt(),
+		).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, release, err := n.db.Exec(ctx, sql, args)
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	return nil
+}
+
+func (n *notificationRepo) DeletePending(ctx context.Context, notificationIDs []string) error {
+	sql, args, err := sq.Delete("pending_notifications").
+		Where(sq.Eq{
+			"notification_id": notificationIDs,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, release, err := n.db.Exec(ctx, sql, args)
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	return nil
+}
+
+func (n *notificationRepo) GetPendingSubscribers(ctx context.Context) ([]*domain.Subscriber, error) {
+	sql, args, err := sq.Select("sub.*").
+		From("subscribers sub").
+		Where("sub.telegram_reachable = TRUE and exists (select 1 from pending_notifications pn where pn.subscriber_id = sub.subscriber_id)").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, release, err := n.db.Query(ctx, sql, args)
+	if err != nil {
+		return nil, err
+	}
+
+	defer release()
+
+	var dbsubscribers []*postgres.SubscriberDB
+	err = n.db.ScanAll(rows, &dbsubscribers)
+	if err != nil {
+		return nil, postgres.CheckEmptyRows(err)
+	}
+
+	subscribers := make([]*domain.Subscriber, 0, len(dbsubscribers))
+	for _, dbsub := range dbsubscribers {
+		subscribers = append(subscribers, dbsub.ToDomain())
+	}
+
+	return subscribers, nil
+}
+
+func (n *notificationRepo) GetPending(ctx context.Context, subscriberID string) ([]*domain.PendingNotification, error) {
+	sql, args, err := sq.Select("notification_id, subscriber_id, advert_id, kind, title, url, old_price, new_price, created_at").
+		From("pending_notifications").
+		Where("subscriber_id = $1", subscriberID).
+		OrderBy("created_at").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, release, err := n.db.Query(ctx, sql, args)
+	if err != nil {
+		return nil, err
+	}
+
+	defer release()
+
+	var dbnotifications []*postgres.PendingNotificationDB
+	err = n.db.ScanAll(rows, &dbnotifications)
+	if err != nil {
+		return nil, postgres.CheckEmptyRows(err)
+	}
+
+	notifications := make([]*domain.PendingNotification, 0, len(dbnotifications))
+	for _, dbnotification := range dbnotifications {
+		notifications = append(notifications, dbnotification.ToDomain())
+	}
+
+	return notifications, nil
+}
+
+func (n *notificationRepo) CompleteDigest(ctx context.Context, subscriberID string, notificationIDs []string, sentAt time.Time) error {
+
+	sqlDeletePending, argsDeletePending, err := sq.Delete("pending_notifications").
+		Where(sq.Eq{
+			"subscriber_id":   subscriberID,
+			"notification_id": notificationIDs,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	sqlUpdateSubscriber, argsUpdateSubscriber, err := sq.Update("subscribers").
+		Set("last_digest_at", sentAt).
+		Where(sq.Eq{
+			"subscriber_id": subscriberID,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	conn, err := n.db.ConnAcquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Release()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Executed within tx
+	{
+		_, err = tx.Exec(ctx, sqlDeletePending, argsDeletePending...)
+		if err != nil {
+			if txError := tx.Rollback(ctx); txError != nil {
+				return fmt.Errorf("%v: %v", txError, err)
+			}
+
+			return err
+		}
+
+		_, err = tx.Exec(ctx, sqlUpdateSubscriber, argsUpdateSubscriber...)
+		if err != nil {
+			if txError := tx.Rollback(ctx); txError != nil {
+				return fmt.Errorf("%v: %v", txError, err)
+			}
+
+			return err
+		}
+	}
+
+	if txError := tx.Commit(ctx); txError != nil {
+		return txError
+	}
+
+	return nil
+}
+
+func (n *notificationRepo) GetLastFingerprint(ctx context.Context, subscriberID, advertID string, channel domain.Channel) (string, error) {
+	sql, args, err := sq.Select("fingerprint").
+		From("delivered_notifications").
+		Where(sq.Eq{
+			"subscriber_id": subscriberID,
+			"advert_id":     advertID,
+			"channel":       string(channel),
+		}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		return "", err
+	}
+
+	rows, release, err := n.db.Query(ctx, sql, args)
+	if err != nil {
+		return "", err
+	}
+
+	defer release()
+
+	var fingerprint string
+	err = n.db.ScanOne(rows, &fingerprint)
+	if err != nil {
+		return "", postgres.CheckEmptyRows(err)
+	}
+
+	return fingerprint, nil
+}
+
+func (n *notificationRepo) RecordDelivery(ctx context.Context, subscriberID, advertID string, channel domain.Channel, fingerprint string, deliveredAt time.Time) error {
+	sql, args, err := sq.Insert("delivered_notifications").
+		Columns("subscriber_id", "advert_id", "channel", "fingerprint", "delivered_at").
+		Values(subscriberID, advertID, string(channel), fingerprint, deliveredAt).
+		Suffix("ON CONFLICT (subscriber_id, advert_id, channel) DO UPDATE SET fingerprint = EXCLUDED.fingerprint, delivered_at = EXCLUDED.delivered_at").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, release, err := n.db.Exec(ctx, sql, args)
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	return nil
+}
+
+func (n *notificationRepo) RecordSuppression(ctx context.Context, subscriberID, advertID string, channel domain.Channel, suppressedAt time.Time) error {
+	sql, args := sq.Update("delivered_notifications").
+		Set("suppressed", sq.Expr("suppressed + 1")).
+		Set("last_suppressed_at", suppressedAt).
+		Where(sq.Eq{
+			"subscriber_id": subscriberID,
+			"advert_id":     advertID,
+			"channel":       string(channel),
+		}).
+		PlaceholderFormat(sq.Dollar).
+		MustSql()
+
+	_, release, err := n.db.Exec(ctx, sql, args)
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"context"
+	domain "parser/internal/domain/models"
+	"parser/internal/postgres"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+type PriceHistoryRepository interface {
+	// Returns ID assigned to change
+	Insert(ctx context.Context, change *domain.PriceChange) (int64, error)
+	// Returns the newest changes of adverts after afterID, at most limit of them.
+	// Changes are ordered from oldest to newest
+	GetSince(ctx context.Context, advertIDs []string, afterID int64, limit uint64) ([]*domain.PriceChange, error)
+}
+
+type priceHistoryRepo struct {
+	db *postgres.Postgres
+}
+
+func NewPriceHistoryRepo(db *postgres.Postgres) PriceHistoryRepository {
+	return &priceHistoryRepo{db: db}
+}
+
+func (p *priceHistoryRepo) Insert(ctx context.Context, change *domain.PriceChange) (int64, error) {
+	sql, args, err := sq.Insert("price_history").
+		Columns("advert_id", "old_price", "new_price", "changed_at").
+		Values(change.AdvertID, change.OldPrice(), change.NewPrice(), change.ChangedAt()).
+		Suffix("RETURNING change_id").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		return 0, err
+	}
+
+	rows, release, err := p.db.Query(ctx, sql, args)
+	if err != nil {
+		return 0, err
+	}
+
+	defer release()
+
+	var changeID int64
+	err = p.db.ScanOne(rows, &changeID)
+	if err != nil {
+		return 0, err
+	}
+
+	return changeID, nil
+}
+
+func (p *priceHistoryRepo) GetSince(ctx context.Context, advertIDs []string, afterID int64, limit uint64) ([]*domain.PriceChange, error) {
+	if len(advertIDs) == 0 {
+		return nil, nil
+	}
+
+	// The newest ones are taken, then reversed
+	sql, args, err := sq.Select("ph.change_id, ph.advert_id, ad.title, ad.url, ph.old_price, ph.new_price, ph.changed_at").
+		From("price_history ph").
+		Join("adverts ad on ad.advert_id = ph.advert_id").
+		Where(sq.Eq{"ph.advert_id": advertIDs}).
+		Where(sq.Gt{"ph.change_id": afterID}).
+		OrderBy("ph.change_id DESC").
+		Limit(limit).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, release, err := p.db.Query(ctx, sql, args)
+	if err != nil {
+		return nil, err
+	}
+
+	defer release()
+
+	var dbchanges []*postgres.PriceChangeDB
+	err = p.db.ScanAll(rows, &dbchanges)
+	if err != nil {
+		return nil, postgres.CheckEmptyRows(err)
+	}
+
+	changes := make([]*domain.PriceChange, len(dbchanges))
+	for i, dbchange := range dbchanges {
+		changes[len(dbchanges)-1-i] = dbchange.ToDomain()
+	}
+
+	return changes, nil
+}
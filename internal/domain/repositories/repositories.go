@@ -5,15 +5,37 @@ import "parser/internal/postgres"
 type Repositories struct {
 	AdvertRepo     AdvertRepository
 	SubscriberRepo SubscriberRepository
+	WebhookRepo    WebhookRepository
+	// Alerts held until digest
+	NotificationRepo NotificationRepository
+	APIKeyRepo       APIKeyRepository
+	PriceHistoryRepo PriceHistoryRepository
+	// Pages that failed to parse
+	DeadLetterRepo DeadLetterRepository
+	// Postgres large objects by default, see NewFSSnapshotRepo
+	SnapshotRepo SnapshotRepository
 }
 
 func NewRepositories(pg *postgres.Postgres) *Repositories {
 
 	advertRepo := NewAdvertRepo(pg)
 	subscriberRepo := NewSubscriberRepo(pg)
+	webhookRepo := NewWebhookRepo(pg)
+	notificationRepo := NewNotificationRepo(pg)
+	apiKeyRepo := NewAPIKeyRepo(pg)
+	priceHistoryRepo := NewPriceHistoryRepo(pg)
+	deadLetterRepo := NewDeadLetterRepo(pg)
+	snapshotRepo := NewSnapshotRepo(pg)
 
 	return &Repositories{
 		AdvertRepo:     advertRepo,
 		SubscriberRepo: subscriberRepo,
+		WebhookRepo:    webhookRepo,
+
+		NotificationRepo: notificationRepo,
+		APIKeyRepo:       apiKeyRepo,
+		PriceHistoryRepo: priceHistoryRepo,
+		DeadLetterRepo:   deadLetterRepo,
+		SnapshotRepo:     snapshotRepo,
 	}
 }
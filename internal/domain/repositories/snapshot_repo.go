@@ -0,0 +1,193 @@
+package repositories
+
+import (
+	"context"
+	"io"
+	domain "parser/internal/domain/models"
+	"parser/internal/postgres"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+)
+
+type SnapshotRepository interface {
+	// Stores snapshot and assigns SnapshotID
+	Insert(ctx context.Context, snapshot *domain.Snapshot) error
+	// Snapshots without pages, newest first.
+	// Snapshots of every URL if url is empty, all of them if page is nil
+	List(ctx context.Context, url string, page *domain.Page) ([]*domain.Snapshot, error)
+	// Returns snapshot with page. Nil if there is none
+	Get(ctx context.Context, snapshotID int64) (*domain.Snapshot, error)
+	Delete(ctx context.Context, snapshotIDs []int64) error
+}
+
+// Pages are kept in large objects, so lists don't drag them along
+type snapshotRepo struct {
+	db *postgres.Postgres
+}
+
+func NewSnapshotRepo(db *postgres.Postgres) SnapshotRepository {
+	return &snapshotRepo{db: db}
+}
+
+func (s *snapshotRepo) Insert(ctx context.Context, snapshot *domain.Snapshot) error {
+	return s.db.InTx(ctx, func(tx pgx.Tx) error {
+		los := tx.LargeObjects()
+
+		oid, err := los.Create(ctx, 0)
+		if err != nil {
+			return err
+		}
+
+		lo, err := los.Open(ctx, oid, pgx.LargeObjectModeWrite)
+		if err != nil {
+			return err
+		}
+
+		if _, err := lo.Write(snapshot.Page()); err != nil {
+			return err
+		}
+
+		if err := lo.Close(); err != nil {
+			return err
+		}
+
+		sql, args, err := sq.Insert("snapshots").
+			Columns("url", "error", "size", "page_oid", "created_at").
+			Values(snapshot.URL(), snapshot.Error(), snapshot.Size(), oid, snapshot.CreatedAt()).
+			Suffix("RETURNING snapshot_id").
+			PlaceholderFormat(sq.Dollar).
+			ToSql()
+
+		if err != nil {
+			return err
+		}
+
+		return tx.QueryRow(ctx, sql, args...).Scan(&snapshot.SnapshotID)
+	})
+}
+
+func (s *snapshotRepo) List(ctx context.Context, url string, page *domain.Page) ([]*domain.Snapshot, error) {
+	query := selectSnapshots().OrderBy("created_at DESC", "snapshot_id DESC")
+
+	if url != "" {
+		query = query.Where(sq.Eq{"url": url})
+	}
+
+	if page != nil {
+		query = query.Limit(page.Limit).Offset(page.Offset)
+	}
+
+	sql, args, err := query.PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, release, err := s.db.Query(ctx, sql, args)
+	if err != nil {
+		return nil, err
+	}
+
+	defer release()
+
+	var dbsnapshots []*postgres.SnapshotDB
+	err = s.db.ScanAll(rows, &dbsnapshots)
+	if err != nil {
+		return nil, postgres.CheckEmptyRows(err)
+	}
+
+	snapshots := make([]*domain.Snapshot, 0, len(dbsnapshots))
+	for _, dbsnapshot := range dbsnapshots {
+		snapshots = append(snapshots, dbsnapshot.ToDomain(nil))
+	}
+
+	return snapshots, nil
+}
+
+func (s *snapshotRepo) Get(ctx context.Context, snapshotID int64) (*domain.Snapshot, error) {
+	sql, args, err := selectSnapshots().
+		Where(sq.Eq{"snapshot_id": snapshotID}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot *domain.Snapshot
+
+	err = s.db.InTx(ctx, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, sql, args...)
+		if err != nil {
+			return err
+		}
+
+		var dbsnapshot postgres.SnapshotDB
+		err = s.db.ScanOne(rows, &dbsnapshot)
+		if err != nil {
+			return postgres.CheckEmptyRows(err)
+		}
+
+		los := tx.LargeObjects()
+
+		lo, err := los.Open(ctx, dbsnapshot.PageOID, pgx.LargeObjectModeRead)
+		if err != nil {
+			return err
+		}
+
+		defer lo.Close()
+
+		page, err := io.ReadAll(lo)
+		if err != nil {
+			return err
+		}
+
+		snapshot = dbsnapshot.ToDomain(page)
+
+		return nil
+	})
+
+	return snapshot, err
+}
+
+func (s *snapshotRepo) Delete(ctx context.Context, snapshotIDs []int64) error {
+	if len(snapshotIDs) == 0 {
+		return nil
+	}
+
+	sql, args, err := sq.Delete("snapshots").
+		Where(sq.Eq{"snapshot_id": snapshotIDs}).
+		Suffix("RETURNING page_oid").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	return s.db.InTx(ctx, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, sql, args...)
+		if err != nil {
+			return err
+		}
+
+		var oids []uint32
+		err = s.db.ScanAll(rows, &oids)
+		if err != nil {
+			return postgres.CheckEmptyRows(err)
+		}
+
+		los := tx.LargeObjects()
+		for _, oid := range oids {
+			if err := los.Unlink(ctx, oid); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func selectSnapshots() sq.SelectBuilder {
+	return sq.Select("snapshot_id, url, error, size, page_oid, created_at").From("snapshots")
+}
@@ -10,17 +10,50 @@ import (
 )
 
 type SubscriberRepository interface {
-	// Inserts subscriber and underlying subscriptions
+	// Inserts subscriber and underlying subscription (if any)
 	InsertSubscriber(ctx context.Context, sub *domain.Subscriber) error
 	// Expects to have at least one subscription
 	InsertOnlySubscription(ctx context.Context, sub *domain.Subscriber) error
 
 	GetSubscription(ctx context.Context, subscriberTelegramID int64, advertURL string) (*domain.Subscription, error)
+	// Returns subscription with attached advert. See domain.Subscription.Advert
+	GetSubscriptionByAdvert(ctx context.Context, subscriberID, advertID string) (*domain.Subscription, error)
+	// Returns page of subscriptions with attached adverts from newest to oldest
+	GetSubscriptions(ctx context.Context, subscriberID string, page *domain.Page) ([]*domain.Subscription, error)
+	CountSubscriptions(ctx context.Context, subscriberID string) (int, error)
+	// Returns IDs of every advert subscriber is subscribed to, including muted ones
+	GetSubscribedAdvertIDs(ctx context.Context, subscriberID string) ([]string, error)
+	// Returns false if there's no such subscription
+	DeleteSubscription(ctx context.Context, subscriberID, advertID string) (bool, error)
+	// Counts all subscribers of advert, including inactive ones
+	CountAdvertSubscribers(ctx context.Context, advertID string) (int, error)
 	// Looks for adverts that users are subscribed to and returns
 	GetAllURLs(ctx context.Context) ([]string, error)
 
+	// Returns subscribers with unmuted subscriptions
 	GetAdvertSubscribers(ctx context.Context, advertID string) ([]*domain.Subscriber, error)
 	GetSubscriber(ctx context.Context, telegramID int64) (*domain.Subscriber, error)
+
+	// Telegram alerts of unreachable subscriber are skipped, other channels are still notified
+	SetTelegramReachable(ctx context.Context, telegramID int64, reachable bool) error
+	SetLocale(ctx context.Context, subscriberID, locale string) error
+	// Sets quiet hours, timezone and digest mode
+	SetSchedule(ctx context.Context, subscriberID string, schedule *domain.Schedule) error
+
+	// Sets default channels of subscriber
+	SetChannels(ctx context.Context, subscriberID string, channels []domain.Channel) error
+	// Pass nil channels to fallback to subscriber's ones.
+	// Returns false if there's no such subscription
+	SetSubscriptionChannels(ctx context.Context, subscriberID, advertID string, channels []domain.Channel) (bool, error)
+	// Muted subscriptions don't receive alerts.
+	// Returns false if there's no such subscription
+	SetSubscriptionMuted(ctx context.Context, subscriberID, advertID string, isMuted bool) (bool, error)
+
+	InsertEmailConfirmation(ctx context.Context, confirmation *domain.EmailConfirmation) error
+	GetEmailConfirmation(ctx context.Context, token string) (*domain.EmailConfirmation, error)
+	// Attaches confirmed email to subscriber and
+	// deletes all pending confirmations of subscriber
+	ConfirmEmail(ctx context.Context, confirmation *domain.EmailConfirmation) error
 }
 
 type subscriberRepo struct {
@@ -55,9 +88,34 @@ func (s *subscriberRepo) GetAllURLs(ctx context.Context) ([]string, error) {
 
 }
 
+func (s *subscriberRepo) GetSubscribedAdvertIDs(ctx context.Context, subscriberID string) ([]string, error) {
+	sql, args := sq.Select("advert_id::text").
+		From("subscriptions").
+		Where(sq.Eq{
+			"subscriber_id": subscriberID,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		MustSql()
+
+	rows, release, err := s.db.Query(ctx, sql, args)
+	if err != nil {
+		return nil, err
+	}
+
+	defer release()
+
+	var advertIDs []string
+	err = s.db.ScanAll(rows, &advertIDs)
+	if err != nil {
+		return nil, postgres.CheckEmptyRows(err)
+	}
+
+	return advertIDs, nil
+}
+
 func (s *subscriberRepo) GetSubscription(ctx context.Context, subscriberTelegramID int64, advertURL string) (*domain.Subscription, error) {
 
-	sql, args, err := sq.Select("sp.advert_id, sp.subscriber_id").
+	sql, args, err := sq.Select("sp.advert_id, sp.subscriber_id, sp.channels, sp.is_muted, sp.created_at").
 		From("subscriptions sp").
 		Join("adverts ad on ad.advert_id = sp.advert_id").
 		Join("subscribers sub on sp.subscriber_id = sub.subscriber_id").
@@ -74,14 +132,114 @@ func (s *subscriberRepo) GetSubscription(ctx context.Context, subscriberTelegram
 	}
 	defer release()
 
-	// TODO: replace with SubscriptionDB
-	var subscription domain.Subscription
+	var subscription postgres.SubscriptionDB
 	err = s.db.ScanOne(rows, &subscription)
 	if err != nil {
 		return nil, postgres.CheckEmptyRows(err)
 	}
 
-	return &subscription, nil
+	return subscription.ToDomain(), nil
+}
+
+func (s *subscriberRepo) GetSubscriptionByAdvert(ctx context.Context, subscriberID, advertID string) (*domain.Subscription, error) {
+
+	sql, args, err := selectSubscriptionsWithAdverts().
+		Where(sq.Eq{
+			"sp.subscriber_id": subscriberID,
+			"sp.advert_id":     advertID,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	subscriptions, err := s.querySubscriptionsWithAdverts(ctx, sql, args)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(subscriptions) == 0 {
+		return nil, nil
+	}
+
+	return subscriptions[0], nil
+}
+
+func (s *subscriberRepo) GetSubscriptions(ctx context.Context, subscriberID string, page *domain.Page) ([]*domain.Subscription, error) {
+
+	sql, args, err := selectSubscriptionsWithAdverts().
+		Where(sq.Eq{
+			"sp.subscriber_id": subscriberID,
+		}).
+		// advert_id keeps order stable for equal timestamps
+		OrderBy("sp.created_at DESC", "sp.advert_id").
+		Limit(page.Limit).
+		Offset(page.Offset).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.querySubscriptionsWithAdverts(ctx, sql, args)
+}
+
+func (s *subscriberRepo) CountSubscriptions(ctx context.Context, subscriberID string) (int, error) {
+	sql, args, err := sq.Select("count(*)").
+		From("subscriptions").
+		Where(sq.Eq{
+			"subscriber_id": subscriberID,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		return 0, err
+	}
+
+	return s.count(ctx, sql, args)
+}
+
+func (s *subscriberRepo) CountAdvertSubscribers(ctx context.Context, advertID string) (int, error) {
+	sql, args, err := sq.Select("count(*)").
+		From("subscriptions").
+		Where(sq.Eq{
+			"advert_id": advertID,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		return 0, err
+	}
+
+	return s.count(ctx, sql, args)
+}
+
+func (s *subscriberRepo) DeleteSubscription(ctx context.Context, subscriberID, advertID string) (bool, error) {
+	sql, args, err := sq.Delete("subscriptions").
+		Where(sq.Eq{
+			"subscriber_id": subscriberID,
+			"advert_id":     advertID,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		return false, err
+	}
+
+	tag, release, err := s.db.Exec(ctx, sql, args)
+	if err != nil {
+		return false, err
+	}
+
+	defer release()
+
+	return tag.RowsAffected() > 0, nil
 }
 
 func (s *subscriberRepo) GetSubscriber(ctx context.Context, telegramID int64) (*domain.Subscriber, error) {
@@ -114,11 +272,11 @@ func (s *subscriberRepo) GetSubscriber(ctx context.Context, telegramID int64) (*
 
 func (s *subscriberRepo) GetAdvertSubscribers(ctx context.Context, advertID string) ([]*domain.Subscriber, error) {
 
-	sql, args, err := sq.Select("sub.subscriber_id, sub.telegram_id").
+	sql, args, err := sq.Select("sub.subscriber_id, sub.telegram_id, sub.telegram_reachable, sub.email, sub.channels, sub.locale, sub.timezone, sub.quiet_from, sub.quiet_to, sub.digest_mode, sp.channels").
 		From("subscriptions sp").
 		Join("subscribers sub on sub.subscriber_id = sp.subscriber_id").
 		Join("adverts ads on sp.advert_id = ads.advert_id").
-		Where("ads.advert_id = $1", advertID).
+		Where("ads.advert_id = $1 and sp.is_muted = FALSE", advertID).
 		PlaceholderFormat(sq.Dollar).
 		ToSql()
 
@@ -133,33 +291,176 @@ func (s *subscriberRepo) GetAdvertSubscribers(ctx context.Context, advertID stri
 
 	defer release()
 
-	var dbsubscribers []*postgres.SubscriberDB
+	var (
+		dbsubscribers   []*postgres.SubscriberDB
+		dbsubscriptions []*postgres.SubscriptionDB
+	)
 	for rows.Next() {
 		var dbsub postgres.SubscriberDB
-		// rows: subscriber_id, telegram_id
-		err = rows.Scan(&dbsub.SubscriberID, &dbsub.TelegramID)
+		var dbsubscription postgres.SubscriptionDB
+		// rows: subscriber_id, telegram_id, telegram_reachable, email, channels, locale,
+		// timezone, quiet_from, quiet_to, digest_mode, subscription channels
+		err = rows.Scan(
+			&dbsub.SubscriberID, &dbsub.TelegramID, &dbsub.TelegramReachable, &dbsub.Email, &dbsub.Channels, &dbsub.Locale,
+			&dbsub.Timezone, &dbsub.QuietFrom, &dbsub.QuietTo, &dbsub.DigestMode, &dbsubscription.Channels,
+		)
 		if err != nil {
 			return nil, postgres.CheckEmptyRows(err)
 		}
 
+		dbsubscription.SubscriberID = dbsub.SubscriberID
+
 		dbsubscribers = append(dbsubscribers, &dbsub)
+		dbsubscriptions = append(dbsubscriptions, &dbsubscription)
 	}
 
 	subscribers := make([]*domain.Subscriber, 0)
-	for _, dbsub := range dbsubscribers {
-		subscribers = append(subscribers, dbsub.ToDomain())
+	for i, dbsub := range dbsubscribers {
+		subscriber := dbsub.ToDomain()
+
+		// Keep subscription to know its channels
+		subscription := dbsubscriptions[i].ToDomain()
+		subscription.AdvertID = advertID
+		subscriber.AddSubscription(subscription)
+
+		subscribers = append(subscribers, subscriber)
 	}
 
 	return subscribers, nil
 }
 
+func (s *subscriberRepo) SetTelegramReachable(ctx context.Context, telegramID int64, reachable bool) error {
+	sql, args := sq.Update("subscribers").
+		Set("telegram_reachable", reachable).
+		Where(sq.Eq{
+			"telegram_id": telegramID,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		MustSql()
+
+	_, release, err := s.db.Exec(ctx, sql, args)
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	return nil
+}
+
+func (s *subscriberRepo) SetLocale(ctx context.Context, subscriberID, locale string) error {
+	sql, args := sq.Update("subscribers").
+		Set("locale", locale).
+		Where(sq.Eq{
+			"subscriber_id": subscriberID,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		MustSql()
+
+	_, release, err := s.db.Exec(ctx, sql, args)
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	return nil
+}
+
+func (s *subscriberRepo) SetSchedule(ctx context.Context, subscriberID string, schedule *domain.Schedule) error {
+	// NULL quiet hours if subscriber has none
+	var quietFrom, quietTo *int
+	if quiet := schedule.QuietHours(); quiet != nil {
+		quietFrom, quietTo = &quiet.From, &quiet.To
+	}
+
+	sql, args := sq.Update("subscribers").
+		Set("timezone", schedule.Timezone()).
+		Set("quiet_from", quietFrom).
+		Set("quiet_to", quietTo).
+		Set("digest_mode", string(schedule.Digest())).
+		Where(sq.Eq{
+			"subscriber_id": subscriberID,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		MustSql()
+
+	_, release, err := s.db.Exec(ctx, sql, args)
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	return nil
+}
+
+func (s *subscriberRepo) SetChannels(ctx context.Context, subscriberID string, channels []domain.Channel) error {
+	sql, args := sq.Update("subscribers").
+		Set("channels", domain.ChannelsToStrings(channels)).
+		Where(sq.Eq{
+			"subscriber_id": subscriberID,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		MustSql()
+
+	_, release, err := s.db.Exec(ctx, sql, args)
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	return nil
+}
+
+func (s *subscriberRepo) SetSubscriptionChannels(ctx context.Context, subscriberID, advertID string, channels []domain.Channel) (bool, error) {
+	sql, args := sq.Update("subscriptions").
+		Set("channels", domain.ChannelsToStrings(channels)).
+		Where(sq.Eq{
+			"subscriber_id": subscriberID,
+			"advert_id":     advertID,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		MustSql()
+
+	tag, release, err := s.db.Exec(ctx, sql, args)
+	if err != nil {
+		return false, err
+	}
+
+	defer release()
+
+	return tag.RowsAffected() > 0, nil
+}
+
+func (s *subscriberRepo) SetSubscriptionMuted(ctx context.Context, subscriberID, advertID string, isMuted bool) (bool, error) {
+	sql, args := sq.Update("subscriptions").
+		Set("is_muted", isMuted).
+		Where(sq.Eq{
+			"subscriber_id": subscriberID,
+			"advert_id":     advertID,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		MustSql()
+
+	tag, release, err := s.db.Exec(ctx, sql, args)
+	if err != nil {
+		return false, err
+	}
+
+	defer release()
+
+	return tag.RowsAffected() > 0, nil
+}
+
 func (s *subscriberRepo) InsertOnlySubscription(ctx context.Context, sub *domain.Subscriber) error {
 
 	subscription := sub.Subscriptions()[0]
 
 	sql, args, err := sq.Insert("subscriptions").
-		Columns("advert_id", "subscriber_id").
-		Values(subscription.AdvertID, subscription.SubscriberID).
+		Columns("advert_id", "subscriber_id", "channels").
+		Values(subscription.AdvertID, subscription.SubscriberID, domain.ChannelsToStrings(subscription.Channels())).
 		PlaceholderFormat(sq.Dollar).
 		ToSql()
 
@@ -181,8 +482,8 @@ func (s *subscriberRepo) InsertSubscriber(ctx context.Context, sub *domain.Subsc
 
 	// Build sql for subscription insert
 	sqlInsertSubscriber, argsInsertSubscriber, err := sq.Insert("subscribers").
-		Columns("subscriber_id", "telegram_id").
-		Values(sub.SubscriberID, sub.TelegramID()).
+		Columns("subscriber_id", "telegram_id", "channels", "locale").
+		Values(sub.SubscriberID, sub.TelegramID(), domain.ChannelsToStrings(sub.Channels()), sub.Locale()).
 		PlaceholderFormat(sq.Dollar).
 		ToSql()
 
@@ -190,14 +491,30 @@ func (s *subscriberRepo) InsertSubscriber(ctx context.Context, sub *domain.Subsc
 		return err
 	}
 
+	// Subscriber could be created without subscriptions (e.g. on /start)
+	if !sub.HasSubscriptions() {
+		_, release, err := s.db.Exec(ctx, sqlInsertSubscriber, argsInsertSubscriber)
+		if err != nil {
+			return err
+		}
+
+		defer release()
+
+		return nil
+	}
+
 	// Build sql for subscription insert
 	subscription := sub.Subscriptions()[0]
 	sqlInsertSubscription, argsInsertSubscription, err := sq.Insert("subscriptions").
-		Columns("advert_id", "subscriber_id").
-		Values(subscription.AdvertID, subscription.SubscriberID).
+		Columns("advert_id", "subscriber_id", "channels").
+		Values(subscription.AdvertID, subscription.SubscriberID, domain.ChannelsToStrings(subscription.Channels())).
 		PlaceholderFormat(sq.Dollar).
 		ToSql()
 
+	if err != nil {
+		return err
+	}
+
 	conn, err := s.db.ConnAcquire(ctx)
 	if err != nil {
 		return err
@@ -240,3 +557,179 @@ func (s *subscriberRepo) InsertSubscriber(ctx context.Context, sub *domain.Subsc
 
 	return nil
 }
+
+func (s *subscriberRepo) InsertEmailConfirmation(ctx context.Context, confirmation *domain.EmailConfirmation) error {
+	sql, args, err := sq.Insert("email_confirmations").
+		Columns("token", "subscriber_id", "email", "expires_at").
+		Values(confirmation.Token, confirmation.SubscriberID, confirmation.Email(), confirmation.ExpiresAt()).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, release, err := s.db.Exec(ctx, sql, args)
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	return nil
+}
+
+func (s *subscriberRepo) GetEmailConfirmation(ctx context.Context, token string) (*domain.EmailConfirmation, error) {
+	sql, args, err := sq.Select("token, subscriber_id, email, expires_at").
+		From("email_confirmations").
+		Where("token = $1", token).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, release, err := s.db.Query(ctx, sql, args)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	var confirmation postgres.EmailConfirmationDB
+
+	err = s.db.ScanOne(rows, &confirmation)
+	if err != nil {
+		return nil, postgres.CheckEmptyRows(err)
+	}
+
+	return confirmation.ToDomain(), nil
+}
+
+func (s *subscriberRepo) ConfirmEmail(ctx context.Context, confirmation *domain.EmailConfirmation) error {
+
+	sqlUpdateSubscriber, argsUpdateSubscriber, err := sq.Update("subscribers").
+		Set("email", confirmation.Email()).
+		Where(sq.Eq{
+			"subscriber_id": confirmation.SubscriberID,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	sqlDeleteConfirmations, argsDeleteConfirmations, err := sq.Delete("email_confirmations").
+		Where(sq.Eq{
+			"subscriber_id": confirmation.SubscriberID,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	conn, err := s.db.ConnAcquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Release()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Executed within tx
+	{
+		_, err = tx.Exec(ctx, sqlUpdateSubscriber, argsUpdateSubscriber...)
+		if err != nil {
+			if txError := tx.Rollback(ctx); txError != nil {
+				return fmt.Errorf("%v: %v", txError, err)
+			}
+
+			return err
+		}
+
+		_, err = tx.Exec(ctx, sqlDeleteConfirmations, argsDeleteConfirmations...)
+		if err != nil {
+			if txError := tx.Rollback(ctx); txError != nil {
+				return fmt.Errorf("%v: %v", txError, err)
+			}
+
+			return err
+		}
+	}
+
+	if txError := tx.Commit(ctx); txError != nil {
+		return txError
+	}
+
+	return nil
+}
+
+func selectSubscriptionsWithAdverts() sq.SelectBuilder {
+	return sq.Select(
+		"sp.subscriber_id, sp.advert_id, sp.channels, sp.is_muted, sp.created_at",
+		"ad.url, ad.title, ad.current_price, ad.last_price, ad.is_parsed",
+	).
+		From("subscriptions sp").
+		Join("adverts ad on ad.advert_id = sp.advert_id")
+}
+
+func (s *subscriberRepo) querySubscriptionsWithAdverts(ctx context.Context, sql string, args []interface{}) ([]*domain.Subscription, error) {
+	rows, release, err := s.db.Query(ctx, sql, args)
+	if err != nil {
+		return nil, err
+	}
+
+	defer release()
+
+	subscriptions := make([]*domain.Subscription, 0)
+	for rows.Next() {
+		var dbsubscription postgres.SubscriptionDB
+		var dbadvert postgres.AdvertDB
+		// rows: subscriber_id, advert_id, channels, is_muted, created_at,
+		// url, title, current_price, last_price, is_parsed
+		err = rows.Scan(
+			&dbsubscription.SubscriberID, &dbsubscription.AdvertID, &dbsubscription.Channels, &dbsubscription.IsMuted, &dbsubscription.CreatedAt,
+			&dbadvert.URL, &dbadvert.Title, &dbadvert.CurrentPrice, &dbadvert.LastPrice, &dbadvert.IsParsed,
+		)
+		if err != nil {
+			return nil, postgres.CheckEmptyRows(err)
+		}
+
+		dbadvert.AdvertID = dbsubscription.AdvertID.String()
+
+		subscription := dbsubscription.ToDomain()
+		subscription.AttachAdvert(dbadvert.ToDomain())
+
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return subscriptions, nil
+}
+
+func (s *subscriberRepo) count(ctx context.Context, sql string, args []interface{}) (int, error) {
+	rows, release, err := s.db.Query(ctx, sql, args)
+	if err != nil {
+		return 0, err
+	}
+
+	defer release()
+
+	var count int
+	err = s.db.ScanOne(rows, &count)
+	if err != nil {
+		return 0, postgres.CheckEmptyRows(err)
+	}
+
+	return count, nil
+}
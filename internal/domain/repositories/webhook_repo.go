@@ -0,0 +1,179 @@
+package repositories
+
+import (
+	"context"
+	domain "parser/internal/domain/models"
+	"parser/internal/postgres"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+type WebhookRepository interface {
+	Insert(ctx context.Context, webhook *domain.Webhook) error
+	Delete(ctx context.Context, subscriberID, webhookID string) (bool, error)
+
+	GetSubscriberWebhooks(ctx context.Context, subscriberID string) ([]*domain.Webhook, error)
+	// Returns active webhooks of active subscribers that are subscribed to advert.
+	// Muted subscriptions are skipped
+	GetAdvertWebhooks(ctx context.Context, advertID string) ([]*domain.Webhook, error)
+
+	// Increments failures of webhook.
+	// Webhook is deactivated once failures reach maxFailures.
+	// Returns true if webhook is deactivated
+	RecordFailure(ctx context.Context, webhookID string, maxFailures int) (bool, error)
+	ResetFailures(ctx context.Context, webhookID string) error
+}
+
+type webhookRepo struct {
+	db *postgres.Postgres
+}
+
+func NewWebhookRepo(db *postgres.Postgres) WebhookRepository {
+	return &webhookRepo{db: db}
+}
+
+func (w *webhookRepo) Insert(ctx context.Context, webhook *domain.Webhook) error {
+	sql, args, err := sq.Insert("webhooks").
+		Columns("webhook_id", "subscriber_id", "url", "secret").
+		Values(webhook.WebhookID, webhook.SubscriberID, webhook.URL(), webhook.Secret()).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, release, err := w.db.Exec(ctx, sql, args)
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	return nil
+}
+
+func (w *webhookRepo) Delete(ctx context.Context, subscriberID, webhookID string) (bool, error) {
+	sql, args, err := sq.Delete("webhooks").
+		Where(sq.Eq{
+			"webhook_id":    webhookID,
+			"subscriber_id": subscriberID,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		return false, err
+	}
+
+	tag, release, err := w.db.Exec(ctx, sql, args)
+	if err != nil {
+		return false, err
+	}
+
+	defer release()
+
+	return tag.RowsAffected() > 0, nil
+}
+
+func (w *webhookRepo) GetSubscriberWebhooks(ctx context.Context, subscriberID string) ([]*domain.Webhook, error) {
+	sql, args, err := sq.Select("webhook_id, subscriber_id, url, secret, is_active, failures").
+		From("webhooks").
+		Where("subscriber_id = $1", subscriberID).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return w.queryWebhooks(ctx, sql, args)
+}
+
+func (w *webhookRepo) GetAdvertWebhooks(ctx context.Context, advertID string) ([]*domain.Webhook, error) {
+	sql, args, err := sq.Select("wh.webhook_id, wh.subscriber_id, wh.url, wh.secret, wh.is_active, wh.failures").
+		From("webhooks wh").
+		Join("subscribers sub on sub.subscriber_id = wh.subscriber_id").
+		Join("subscriptions sp on sp.subscriber_id = sub.subscriber_id").
+		Where("sp.advert_id = $1 and sp.is_muted = FALSE and wh.is_active = TRUE", advertID).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return w.queryWebhooks(ctx, sql, args)
+}
+
+func (w *webhookRepo) RecordFailure(ctx context.Context, webhookID string, maxFailures int) (bool, error) {
+	sql, args, err := sq.Update("webhooks").
+		Set("failures", sq.Expr("failures + 1")).
+		Set("is_active", sq.Expr("failures + 1 < ?", maxFailures)).
+		Where(sq.Eq{
+			"webhook_id": webhookID,
+		}).
+		Suffix("RETURNING is_active").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		return false, err
+	}
+
+	rows, release, err := w.db.Query(ctx, sql, args)
+	if err != nil {
+		return false, err
+	}
+
+	defer release()
+
+	var isActive bool
+	err = w.db.ScanOne(rows, &isActive)
+	if err != nil {
+		return false, postgres.CheckEmptyRows(err)
+	}
+
+	return !isActive, nil
+}
+
+func (w *webhookRepo) ResetFailures(ctx context.Context, webhookID string) error {
+	sql, args := sq.Update("webhooks").
+		Set("failures", 0).
+		Where(sq.Eq{
+			"webhook_id": webhookID,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		MustSql()
+
+	_, release, err := w.db.Exec(ctx, sql, args)
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	return nil
+}
+
+func (w *webhookRepo) queryWebhooks(ctx context.Context, sql string, args []interface{}) ([]*domain.Webhook, error) {
+	rows, release, err := w.db.Query(ctx, sql, args)
+	if err != nil {
+		return nil, err
+	}
+
+	defer release()
+
+	var dbwebhooks []*postgres.WebhookDB
+	err = w.db.ScanAll(rows, &dbwebhooks)
+	if err != nil {
+		return nil, postgres.CheckEmptyRows(err)
+	}
+
+	webhooks := make([]*domain.Webhook, 0, len(dbwebhooks))
+	for _, dbwebhook := range dbwebhooks {
+		webhooks = append(webhooks, dbwebhook.ToDomain())
+	}
+
+	return webhooks, nil
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	goerrors "errors"
+	"net/url"
+	domain "parser/internal/domain/models"
+	"parser/internal/domain/repositories"
+	"parser/internal/errors"
+	"parser/internal/http/dto"
+	"parser/internal/telegram"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AuthService interface {
+	// Key is sent by server-to-server clients
+	AuthenticateAPIKey(ctx context.Context, key string) (*domain.Principal, error)
+	// Data is fields of Telegram Login Widget
+	AuthenticateTelegramLogin(ctx context.Context, data url.Values) (*domain.Principal, error)
+	// initData is passed by Telegram to Web App
+	AuthenticateWebApp(ctx context.Context, initData string) (*domain.Principal, error)
+
+	// Returns plain key along with created one. Plain key is not stored
+	CreateAPIKey(ctx context.Context, dto *dto.APIKeyRequest) (*domain.APIKey, string, error)
+	ListAPIKeys(ctx context.Context) ([]*domain.APIKey, error)
+	RevokeAPIKey(ctx context.Context, apiKeyID string) error
+	// Registers key configured by operator, e.g. to bootstrap admin access
+	EnsureAPIKey(ctx context.Context, name, plain string, scopes []domain.Scope) error
+}
+
+type authService struct {
+	apiKeyRepo repositories.APIKeyRepository
+
+	// Signs data of Telegram Login Widget and Web App
+	botToken string
+	// Signed telegram data is accepted that long after sign in
+	telegramMaxAge time.Duration
+}
+
+func NewAuthService(apiKeyRepo repositories.APIKeyRepository, botToken string, telegramMaxAge time.Duration) AuthService {
+	return &authService{
+		apiKeyRepo:     apiKeyRepo,
+		botToken:       botToken,
+		telegramMaxAge: telegramMaxAge,
+	}
+}
+
+func (s *authService) AuthenticateAPIKey(ctx context.Context, key string) (*domain.Principal, error) {
+	apiKey, err := s.apiKeyRepo.GetByHash(ctx, domain.HashAPIKey(key))
+	if err != nil {
+		return nil, errors.WrapInternal(err, "authService.AuthenticateAPIKey.GetByHash")
+	}
+
+	if apiKey == nil {
+		return nil, errors.WrapDomain(domain.ErrInvalidCredentials)
+	}
+
+	now := time.Now()
+	if apiKey.ShouldTouch(now) {
+		err = s.apiKeyRepo.Touch(ctx, apiKey.APIKeyID, now)
+		if err != nil {
+			return nil, errors.WrapInternal(err, "authService.AuthenticateAPIKey.Touch")
+		}
+	}
+
+	return domain.NewAPIKeyPrincipal(apiKey), nil
+}
+
+func (s *authService) AuthenticateTelegramLogin(ctx context.Context, data url.Values) (*domain.Principal, error) {
+	user, err := telegram.VerifyLogin(s.botToken, data, s.telegramMaxAge, time.Now())
+	if err != nil {
+		return nil, telegramAuthError(err)
+	}
+
+	return domain.NewTelegramPrincipal(user.ID, strconv.FormatInt(user.ID, 10)), nil
+}
+
+func (s *authService) AuthenticateWebApp(ctx context.Context, initData string) (*domain.Principal, error) {
+	user, err := telegram.VerifyWebAppData(s.botToken, initData, s.telegramMaxAge, time.Now())
+	if err != nil {
+		return nil, telegramAuthError(err)
+	}
+
+	return domain.NewTelegramPrincipal(user.ID, strconv.FormatInt(user.ID, 10)), nil
+}
+
+func (s *authService) CreateAPIKey(ctx context.Context, dto *dto.APIKeyRequest) (*domain.APIKey, string, error) {
+	scopes, err := domain.ParseScopes(dto.Scopes)
+	if err != nil {
+		return nil, "", errors.WrapDomain(err)
+	}
+
+	apiKey, plain, err := domain.NewEmptyAPIKey(dto.Name, scopes)
+	if err != nil {
+		if goerrors.Is(err, domain.ErrEmptyAPIKeyName) {
+			return nil, "", errors.WrapDomain(err)
+		}
+
+		return nil, "", errors.WrapInternal(err, "authService.CreateAPIKey.NewEmptyAPIKey")
+	}
+
+	err = s.apiKeyRepo.Insert(ctx, apiKey)
+	if err != nil {
+		return nil, "", errors.WrapInternal(err, "authService.CreateAPIKey.Insert")
+	}
+
+	return apiKey, plain, nil
+}
+
+func (s *authService) ListAPIKeys(ctx context.Context) ([]*domain.APIKey, error) {
+	apiKeys, err := s.apiKeyRepo.List(ctx)
+	if err != nil {
+		return nil, errors.WrapInternal(err, "authService.ListAPIKeys.List")
+	}
+
+	return apiKeys, nil
+}
+
+func (s *authService) RevokeAPIKey(ctx context.Context, apiKeyID string) error {
+	if _, err := uuid.Parse(apiKeyID); err != nil {
+		return errors.WrapDomain(domain.ErrNoAPIKey)
+	}
+
+	ok, err := s.apiKeyRepo.Revoke(ctx, apiKeyID, time.Now())
+	if err != nil {
+		return errors.WrapInternal(err, "authService.RevokeAPIKey.Revoke")
+	}
+
+	if !ok {
+		return errors.WrapDomain(domain.ErrNoAPIKey)
+	}
+
+	return nil
+}
+
+func (s *authService) EnsureAPIKey(ctx context.Context, name, plain string, scopes []domain.Scope) error {
+	apiKey, err := domain.NewAPIKeyFromPlain(name, plain, scopes)
+	if err != nil {
+		return errors.WrapDomain(err)
+	}
+
+	err = s.apiKeyRepo.Insert(ctx, apiKey)
+	if err != nil {
+		return errors.WrapInternal(err, "authService.EnsureAPIKey.Insert")
+	}
+
+	return nil
+}
+
+// Details of bad signature are not shown to client
+func telegramAuthError(err error) error {
+	if goerrors.Is(err, telegram.ErrAuthDataExpired) {
+		return errors.WrapDomain(domain.ErrCredentialsExpired)
+	}
+
+	return errors.WrapDomain(domain.ErrInvalidCredentials)
+}
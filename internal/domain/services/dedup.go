@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	domain "parser/internal/domain/models"
+	"parser/internal/domain/repositories"
+	"parser/internal/errors"
+	"time"
+)
+
+// deduplicator suppresses alerts that repeat the last one
+// delivered to subscriber about advert through channel.
+// See domain.AlertFingerprint
+type deduplicator struct {
+	notificationRepo repositories.NotificationRepository
+}
+
+// Reports whether alert is a repeat. Suppressed alerts are counted in storage
+func (d *deduplicator) suppress(ctx context.Context, subscriberID, advertID string, channel domain.Channel, fingerprint string, now time.Time) (bool, error) {
+	last, err := d.notificationRepo.GetLastFingerprint(ctx, subscriberID, advertID, channel)
+	if err != nil {
+		return false, errors.WrapInternal(err, "deduplicator.suppress.GetLastFingerprint")
+	}
+
+	if last != fingerprint {
+		return false, nil
+	}
+
+	err = d.notificationRepo.RecordSuppression(ctx, subscriberID, advertID, channel, now)
+	if err != nil {
+		return false, errors.WrapInternal(err, "deduplicator.suppress.RecordSuppression")
+	}
+
+	return true, nil
+}
+
+// Should be called once alert is sent
+func (d *deduplicator) delivered(ctx context.Context, subscriberID, advertID string, channel domain.Channel, fingerprint string, now time.Time) error {
+	err := d.notificationRepo.RecordDelivery(ctx, subscriberID, advertID, channel, fingerprint, now)
+	if err != nil {
+		return errors.WrapInternal(err, "deduplicator.delivered.RecordDelivery")
+	}
+
+	return nil
+}
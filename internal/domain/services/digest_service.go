@@ -0,0 +1,321 @@
+package services
+
+import (
+	"context"
+	goerrors "errors"
+	domain "parser/internal/domain/models"
+	"parser/internal/domain/repositories"
+	"parser/internal/errors"
+	"parser/internal/logger"
+	"parser/internal/messages"
+	"parser/internal/notify"
+	"parser/internal/timer"
+	"time"
+)
+
+// DigestService delivers held telegram alerts.
+// Alerts held because of quiet hours or digest mode are sent as digest (see domain.Schedule).
+// Alerts held for coalescing window are merged per advert, e.g. "1000 → 850 → 800"
+type DigestService interface {
+	// Sends digests and coalesced alerts that are due
+	SendDigests(ctx context.Context) error
+
+	// Calls SendDigests every interval
+	Run(interval time.Duration)
+	Close()
+}
+
+type digestService struct {
+	subscriptionRepo repositories.SubscriberRepository
+	notificationRepo repositories.NotificationRepository
+	// Suppresses alerts that repeat delivered ones
+	dedup *deduplicator
+	// Routes notifications by domain.Channel. See notify.Multiplexer
+	notifier notify.Notifier
+	messages *messages.Renderer
+	timer    timer.Timer
+
+	// Coalesced alert is sent once the first change of advert is that old
+	coalesceWindow time.Duration
+
+	log logger.Logger
+}
+
+func NewDigestService(
+	subscriptionRepo repositories.SubscriberRepository,
+	notificationRepo repositories.NotificationRepository,
+	notifier notify.Notifier,
+	messages *messages.Renderer,
+	timer timer.Timer,
+	coalesceWindow time.Duration,
+	log logger.Logger) DigestService {
+	return &digestService{
+		subscriptionRepo: subscriptionRepo,
+		notificationRepo: notificationRepo,
+		dedup:            &deduplicator{notificationRepo: notificationRepo},
+		notifier:         notifier,
+		messages:         messages,
+		timer:            timer,
+		coalesceWindow:   coalesceWindow,
+		log:              log,
+	}
+}
+
+func (d *digestService) Run(interval time.Duration) {
+	d.timer.Every(interval, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		defer cancel()
+
+		if err := d.SendDigests(ctx); err != nil {
+			d.log.Error("sending digests failed", logger.ErrFields(err)...)
+		}
+	})
+}
+
+func (d *digestService) Close() {
+	d.timer.Stop()
+}
+
+func (d *digestService) SendDigests(ctx context.Context) error {
+	subscribers, err := d.notificationRepo.GetPendingSubscribers(ctx)
+	if err != nil {
+		return errors.WrapInternal(err, "digestService.SendDigests.GetPendingSubscribers")
+	}
+
+	now := time.Now()
+
+	// Failure of one subscriber doesn't prevent others from receiving alerts.
+	// The first error is returned after everyone is handled
+	var firstErr error
+	for _, subscriber := range subscribers {
+		err := d.flush(ctx, subscriber, now)
+		if err != nil && firstErr == nil {
+			firstErr = errors.Chain(err, "digestService.SendDigests")
+		}
+	}
+
+	return firstErr
+}
+
+func (d *digestService) flush(ctx context.Context, subscriber *domain.Subscriber, now time.Time) error {
+	pending, err := d.notificationRepo.GetPending(ctx, subscriber.SubscriberID)
+	if err != nil {
+		return errors.WrapInternal(err, "flush.GetPending")
+	}
+
+	schedule := subscriber.Schedule()
+
+	// Coalesced alerts join digest if subscriber has one coming
+	if schedule.ShouldHold(now) || hasKind(pending, domain.PendingDigest) {
+		if !schedule.IsDigestDue(now, subscriber.LastDigestAt()) {
+			return nil
+		}
+
+		return d.sendDigest(ctx, subscriber, pending, now)
+	}
+
+	return d.sendCoalesced(ctx, subscriber, pending, now)
+}
+
+func (d *digestService) sendDigest(ctx context.Context, subscriber *domain.Subscriber, pending []*domain.PendingNotification, now time.Time) error {
+	var (
+		sent    []*heldAdvert
+		adverts []*messages.DigestAdvert
+	)
+
+	for _, held := range groupPending(pending) {
+		// Prices could have returned to where they were. Nothing to tell then
+		if held.isUnchanged() {
+			continue
+		}
+
+		suppressed, err := d.dedup.suppress(ctx, subscriber.SubscriberID, held.advertID, domain.ChannelTelegram, held.fingerprint(), now)
+		if err != nil {
+			return errors.Chain(err, "sendDigest")
+		}
+
+		if suppressed {
+			continue
+		}
+
+		sent = append(sent, held)
+		adverts = append(adverts, held.advert)
+	}
+
+	if len(adverts) > 0 {
+		msg, err := d.messages.Render(messages.Locale(subscriber.Locale()), messages.Digest, &messages.DigestData{
+			Adverts: adverts,
+		})
+		if err != nil {
+			return errors.WrapInternal(err, "sendDigest.Render")
+		}
+
+		// Digest is not about single advert
+		err = deliver(ctx, d.notifier, nil, domain.ChannelTelegram, subscriber.TelegramID(), msg)
+		if goerrors.Is(err, notify.ErrRecipientUnreachable) {
+			return d.markUnreachable(ctx, subscriber, pending)
+		}
+
+		if err != nil {
+			// Alerts stay held until next attempt
+			return errors.WrapInternal(err, "sendDigest.Notify")
+		}
+
+		for _, held := range sent {
+			err = d.dedup.delivered(ctx, subscriber.SubscriberID, held.advertID, domain.ChannelTelegram, held.fingerprint(), now)
+			if err != nil {
+				return errors.Chain(err, "sendDigest")
+			}
+		}
+	}
+
+	err := d.notificationRepo.CompleteDigest(ctx, subscriber.SubscriberID, notificationIDs(pending), now)
+	if err != nil {
+		return errors.WrapInternal(err, "sendDigest.CompleteDigest")
+	}
+
+	return nil
+}
+
+// Sends one alert per advert once its first held change is older than coalescing window
+func (d *digestService) sendCoalesced(ctx context.Context, subscriber *domain.Subscriber, pending []*domain.PendingNotification, now time.Time) error {
+	for _, held := range groupPending(pending) {
+		// Following changes might come yet
+		if now.Sub(held.firstAt) < d.coalesceWindow {
+			continue
+		}
+
+		send := !held.isUnchanged()
+		if send {
+			suppressed, err := d.dedup.suppress(ctx, subscriber.SubscriberID, held.advertID, domain.ChannelTelegram, held.fingerprint(), now)
+			if err != nil {
+				return errors.Chain(err, "sendCoalesced")
+			}
+
+			send = !suppressed
+		}
+
+		if send {
+			msg, err := d.messages.Render(messages.Locale(subscriber.Locale()), messages.PriceChanged, &messages.PriceChangedData{
+				Title:    held.advert.Title,
+				URL:      held.advert.URL,
+				OldPrice: held.advert.OldPrice(),
+				NewPrice: held.advert.NewPrice(),
+				Prices:   held.advert.Prices,
+			})
+			if err != nil {
+				return errors.WrapInternal(err, "sendCoalesced.Render")
+			}
+
+			err = deliver(ctx, d.notifier, nil, domain.ChannelTelegram, subscriber.TelegramID(), msg)
+			if goerrors.Is(err, notify.ErrRecipientUnreachable) {
+				return d.markUnreachable(ctx, subscriber, pending)
+			}
+
+			if err != nil {
+				// Alerts stay held until next attempt
+				return errors.WrapInternal(err, "sendCoalesced.Notify")
+			}
+
+			err = d.dedup.delivered(ctx, subscriber.SubscriberID, held.advertID, domain.ChannelTelegram, held.fingerprint(), now)
+			if err != nil {
+				return errors.Chain(err, "sendCoalesced")
+			}
+		}
+
+		err := d.notificationRepo.DeletePending(ctx, held.notificationIDs)
+		if err != nil {
+			return errors.WrapInternal(err, "sendCoalesced.DeletePending")
+		}
+	}
+
+	return nil
+}
+
+// User has blocked the bot or deleted an account.
+// Stop sending them telegram alerts until they're back with /start.
+// Held alerts are dropped, they're stale by then
+func (d *digestService) markUnreachable(ctx context.Context, subscriber *domain.Subscriber, pending []*domain.PendingNotification) error {
+	err := d.subscriptionRepo.SetTelegramReachable(ctx, subscriber.TelegramID(), false)
+	if err != nil {
+		return errors.WrapInternal(err, "markUnreachable.SetTelegramReachable")
+	}
+
+	err = d.notificationRepo.DeletePending(ctx, notificationIDs(pending))
+	if err != nil {
+		return errors.WrapInternal(err, "markUnreachable.DeletePending")
+	}
+
+	return nil
+}
+
+// heldAdvert is price history of advert merged from held alerts
+type heldAdvert struct {
+	advertID        string
+	notificationIDs []string
+	// When the first change is held
+	firstAt time.Time
+	advert  *messages.DigestAdvert
+}
+
+func (ha *heldAdvert) isUnchanged() bool {
+	return ha.advert.OldPrice() == ha.advert.NewPrice()
+}
+
+func (ha *heldAdvert) fingerprint() string {
+	return domain.AlertFingerprint(ha.advert.OldPrice(), ha.advert.NewPrice())
+}
+
+// groupPending merges held alerts of every advert into price history.
+// Expects alerts from oldest to newest. Adverts are ordered by their first alert
+func groupPending(pending []*domain.PendingNotification) []*heldAdvert {
+	byAdvert := make(map[string]*heldAdvert)
+	ordered := make([]*heldAdvert, 0)
+
+	for _, notification := range pending {
+		held, ok := byAdvert[notification.AdvertID]
+		if !ok {
+			held = &heldAdvert{
+				advertID: notification.AdvertID,
+				firstAt:  notification.CreatedAt(),
+				advert: &messages.DigestAdvert{
+					Prices: []float64{notification.OldPrice()},
+				},
+			}
+
+			byAdvert[notification.AdvertID] = held
+			ordered = append(ordered, held)
+		}
+
+		held.notificationIDs = append(held.notificationIDs, notification.NotificationID)
+
+		// The newest title and URL win
+		held.advert.Title = notification.Title()
+		held.advert.URL = notification.URL()
+
+		if held.advert.NewPrice() != notification.NewPrice() {
+			held.advert.Prices = append(held.advert.Prices, notification.NewPrice())
+		}
+	}
+
+	return ordered
+}
+
+func hasKind(pending []*domain.PendingNotification, kind domain.PendingKind) bool {
+	for _, notification := range pending {
+		if notification.Kind() == kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+func notificationIDs(pending []*domain.PendingNotification) []string {
+	ids := make([]string, 0, len(pending))
+	for _, notification := range pending {
+		ids = append(ids, notification.NotificationID)
+	}
+
+	return ids
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	domain "parser/internal/domain/models"
+	"parser/internal/logger"
+	"parser/internal/notify"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendDigests(t *testing.T) {
+	now := time.Now()
+
+	newService := func(notificationRepo *fakeNotificationRepo, telegram *recordingNotifier) DigestService {
+		mux := notify.NewMultiplexer()
+		mux.Register(domain.ChannelTelegram, telegram)
+
+		return NewDigestService(new(fakeSubscriberRepo), notificationRepo, mux, newRenderer(t), nil, time.Minute, logger.Nop())
+	}
+
+	t.Run("sends due digests", func(t *testing.T) {
+		hourly, err := domain.NewSchedule("", nil, domain.DigestHourly)
+		require.NoError(t, err)
+
+		due := domain.NewSubscriber("sub-1", 1, "", domain.DefaultChannels(), "en")
+		due.SetSchedule(hourly)
+
+		// Has just received digest
+		notDue := domain.NewSubscriber("sub-2", 2, "", domain.DefaultChannels(), "en")
+		notDue.SetSchedule(hourly)
+		notDue.SetLastDigestAt(now)
+
+		notificationRepo := &fakeNotificationRepo{
+			subscribers: []*domain.Subscriber{due, notDue},
+			pending: []*domain.PendingNotification{
+				domain.NewPendingNotification("n-1", "sub-1", "ad-1", domain.PendingDigest, "iPhone 13", "https://www.avito.ru/1", 1000, 850, now),
+				domain.NewPendingNotification("n-2", "sub-1", "ad-2", domain.PendingDigest, "Bike", "https://www.avito.ru/2", 100, 110, now),
+				domain.NewPendingNotification("n-3", "sub-1", "ad-1", domain.PendingDigest, "iPhone 13", "https://www.avito.ru/1", 850, 800, now),
+				domain.NewPendingNotification("n-4", "sub-2", "ad-1", domain.PendingDigest, "iPhone 13", "https://www.avito.ru/1", 1000, 850, now),
+			},
+		}
+
+		telegram := new(recordingNotifier)
+		err = newService(notificationRepo, telegram).SendDigests(context.Background())
+		require.NoError(t, err)
+
+		require.Len(t, telegram.args, 1)
+		require.Equal(t, int64(1), telegram.args[0][0])
+		require.Equal(t, "Price changes digest:\n\niPhone 13\nRUB 1,000 → RUB 850 → RUB 800 (-20%)\nhttps://www.avito.ru/1\n\nBike\nRUB 100 → RUB 110 (+10%)\nhttps://www.avito.ru/2", telegram.args[0][1])
+
+		require.Equal(t, map[string][]string{"sub-1": {"n-1", "n-2", "n-3"}}, notificationRepo.completed)
+	})
+
+	t.Run("merges rapid changes once window is over", func(t *testing.T) {
+		subscriber := domain.NewSubscriber("sub-1", 1, "", domain.DefaultChannels(), "en")
+
+		notificationRepo := &fakeNotificationRepo{
+			subscribers: []*domain.Subscriber{subscriber},
+			pending: []*domain.PendingNotification{
+				domain.NewPendingNotification("n-1", "sub-1", "ad-1", domain.PendingCoalesce, "iPhone 13", "https://www.avito.ru/1", 1000, 850, now.Add(-2*time.Minute)),
+				domain.NewPendingNotification("n-2", "sub-1", "ad-1", domain.PendingCoalesce, "iPhone 13", "https://www.avito.ru/1", 850, 800, now.Add(-time.Minute/2)),
+				// Window is not over yet
+				domain.NewPendingNotification("n-3", "sub-1", "ad-2", domain.PendingCoalesce, "Bike", "https://www.avito.ru/2", 100, 110, now),
+			},
+		}
+
+		telegram := new(recordingNotifier)
+		err := newService(notificationRepo, telegram).SendDigests(context.Background())
+		require.NoError(t, err)
+
+		require.Len(t, telegram.args, 1)
+		require.Equal(t, "Price changed!\niPhone 13\nRUB 1,000 → RUB 850 → RUB 800 (-20%)\nhttps://www.avito.ru/1", telegram.args[0][1])
+		require.Equal(t, []string{"n-1", "n-2"}, notificationRepo.deleted)
+		require.Nil(t, notificationRepo.completed)
+	})
+
+	t.Run("suppresses already delivered changes", func(t *testing.T) {
+		subscriber := domain.NewSubscriber("sub-1", 1, "", domain.DefaultChannels(), "en")
+
+		notificationRepo := &fakeNotificationRepo{
+			subscribers: []*domain.Subscriber{subscriber},
+			pending: []*domain.PendingNotification{
+				domain.NewPendingNotification("n-1", "sub-1", "ad-1", domain.PendingCoalesce, "iPhone 13", "https://www.avito.ru/1", 1000, 850, now.Add(-2*time.Minute)),
+			},
+			delivered: map[string]string{
+				deliveryKey("sub-1", "ad-1", domain.ChannelTelegram): domain.AlertFingerprint(1000, 850),
+			},
+		}
+
+		telegram := new(recordingNotifier)
+		err := newService(notificationRepo, telegram).SendDigests(context.Background())
+		require.NoError(t, err)
+
+		require.Empty(t, telegram.args)
+		require.Equal(t, 1, notificationRepo.suppressed)
+		require.Equal(t, []string{"n-1"}, notificationRepo.deleted)
+	})
+}
+
+func TestGroupPending(t *testing.T) {
+	now := time.Now()
+
+	held := groupPending([]*domain.PendingNotification{
+		domain.NewPendingNotification("n-1", "sub", "ad-1", domain.PendingDigest, "iPhone", "url-1", 1000, 900, now),
+		domain.NewPendingNotification("n-2", "sub", "ad-2", domain.PendingDigest, "Bike", "url-2", 100, 90, now),
+		// Price has returned back
+		domain.NewPendingNotification("n-3", "sub", "ad-1", domain.PendingDigest, "iPhone", "url-1", 900, 1000, now),
+	})
+
+	require.Len(t, held, 2)
+
+	require.True(t, held[0].isUnchanged())
+	require.Equal(t, []string{"n-1", "n-3"}, held[0].notificationIDs)
+
+	require.False(t, held[1].isUnchanged())
+	require.Equal(t, "Bike", held[1].advert.Title)
+	require.Equal(t, []float64{100, 90}, held[1].advert.Prices)
+	require.Equal(t, "100->90", held[1].fingerprint())
+}
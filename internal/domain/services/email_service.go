@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	domain "parser/internal/domain/models"
+	"parser/internal/domain/repositories"
+	"parser/internal/email"
+	"parser/internal/errors"
+	"parser/internal/http/dto"
+	"time"
+)
+
+var (
+	ErrEmailDisabled = errors.Define(errors.UnavailableKind, "email_disabled", "email notifications are disabled")
+)
+
+type EmailService interface {
+	// Sends confirmation link to email from dto.
+	// Email is attached to subscriber after ConfirmEmail
+	RequestConfirmation(ctx context.Context, dto *dto.EmailRequest) error
+
+	ConfirmEmail(ctx context.Context, token string) error
+}
+
+type confirmEmailData struct {
+	Link      string
+	ExpiresAt time.Time
+}
+
+type emailService struct {
+	subscriptionRepo repositories.SubscriberRepository
+	mailer           email.Mailer
+
+	// Token is appended as ?token= query param
+	confirmURL      string
+	confirmationTTL time.Duration
+}
+
+func NewEmailService(
+	subscriptionRepo repositories.SubscriberRepository,
+	mailer email.Mailer,
+	confirmURL string,
+	confirmationTTL time.Duration) EmailService {
+	return &emailService{
+		subscriptionRepo: subscriptionRepo,
+		mailer:           mailer,
+		confirmURL:       confirmURL,
+		confirmationTTL:  confirmationTTL,
+	}
+}
+
+func (s *emailService) RequestConfirmation(ctx context.Context, dto *dto.EmailRequest) error {
+	if s.mailer == nil {
+		return errors.WrapDomain(ErrEmailDisabled)
+	}
+
+	address, err := email.ParseAddress(dto.Email)
+	if err != nil {
+		return errors.Wrap(err, errors.ValidationKind, "invalid_email")
+	}
+
+	subscriber, err := s.subscriptionRepo.GetSubscriber(ctx, dto.TelegramID)
+	if err != nil {
+		return errors.WrapInternal(err, "emailService.RequestConfirmation.GetSubscriber")
+	}
+
+	// Only existing subscribers could receive emails
+	if subscriber == nil {
+		return errors.WrapDomain(domain.ErrNoSubscriber)
+	}
+
+	confirmation, err := domain.NewEmptyEmailConfirmation(subscriber.SubscriberID, address, s.confirmationTTL)
+	if err != nil {
+		return errors.WrapInternal(err, "emailService.RequestConfirmation.NewEmptyEmailConfirmation")
+	}
+
+	err = s.subscriptionRepo.InsertEmailConfirmation(ctx, confirmation)
+	if err != nil {
+		return errors.WrapInternal(err, "emailService.RequestConfirmation.InsertEmailConfirmation")
+	}
+
+	text, html, err := email.Render(email.TemplateConfirmEmail, &confirmEmailData{
+		Link:      fmt.Sprintf("%s?token=%s", s.confirmURL, confirmation.Token),
+		ExpiresAt: confirmation.ExpiresAt(),
+	})
+	if err != nil {
+		return errors.WrapInternal(err, "emailService.RequestConfirmation.Render")
+	}
+
+	err = s.mailer.Send(&email.Message{
+		To:      address,
+		Subject: "Confirm your email",
+		Text:    text,
+		HTML:    html,
+	})
+	if err != nil {
+		return errors.WrapInternal(err, "emailService.RequestConfirmation.Send")
+	}
+
+	return nil
+}
+
+func (s *emailService) ConfirmEmail(ctx context.Context, token string) error {
+	confirmation, err := s.subscriptionRepo.GetEmailConfirmation(ctx, token)
+	if err != nil {
+		return errors.WrapInternal(err, "emailService.ConfirmEmail.GetEmailConfirmation")
+	}
+
+	if confirmation == nil {
+		return errors.WrapDomain(domain.ErrConfirmationNotFound)
+	}
+
+	if confirmation.IsExpired(time.Now()) {
+		return errors.WrapDomain(domain.ErrConfirmationExpired)
+	}
+
+	err = s.subscriptionRepo.ConfirmEmail(ctx, confirmation)
+	if err != nil {
+		return errors.WrapInternal(err, "emailService.ConfirmEmail.ConfirmEmail")
+	}
+
+	return nil
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	domain "parser/internal/domain/models"
+	"parser/internal/domain/repositories"
+	"parser/internal/errors"
+	"parser/internal/logger"
+	"parser/internal/parser"
+	"sync"
+	"time"
+)
+
+// Failure of update handler is classified by kind of error, e.g. "handler_internal"
+const failureClassHandler = "handler_"
+
+// FailureService is central handler of failed updates.
+// Failures are classified and counted per URL. Pages that failed to parse
+// are stored as domain.DeadLetter, operators are alerted when URL keeps failing
+type FailureService interface {
+	// Handles update that failed to parse (update.Err) or to be handled (err)
+	HandleFailure(ctx context.Context, update *parser.ParseResult, err error)
+	// Resets failures counted against URL
+	HandleSuccess(ctx context.Context, url string)
+	// Forgets failures of URL that is not parsed anymore
+	HandleRemoved(ctx context.Context, url string)
+	// Failures in a row of URL
+	Failures(url string) int
+}
+
+// Alerter notifies operators of the tracker, see notify.NewTelegramAlerter
+type Alerter interface {
+	Alert(ctx context.Context, text string) error
+}
+
+// FailureMetrics receives measurements of FailureService. See metrics.Prometheus
+type FailureMetrics interface {
+	// class is either parser.Outcome or "handler_" followed by kind of error
+	ObserveFailure(class string)
+	// URLs failing right now
+	SetFailingURLs(n int)
+}
+
+type nopFailureMetrics struct{}
+
+func (nopFailureMetrics) ObserveFailure(class string) {}
+func (nopFailureMetrics) SetFailingURLs(n int)        {}
+
+// Failures of URL in a row
+type failureStreak struct {
+	count int
+	// Class of the last failure
+	class string
+	// Operators are alerted once per streak
+	alerted bool
+}
+
+type failureService struct {
+	deadLetterRepo repositories.DeadLetterRepository
+	// Optional. Alerts are only logged if nil
+	alerter Alerter
+	// Operators are alerted after that many failures of URL in a row
+	alertAfter int
+
+	mu      sync.Mutex
+	streaks map[string]*failureStreak
+
+	metrics FailureMetrics
+	log     logger.Logger
+}
+
+func NewFailureService(
+	deadLetterRepo repositories.DeadLetterRepository,
+	alerter Alerter,
+	alertAfter int,
+	metrics FailureMetrics,
+	log logger.Logger) FailureService {
+	if metrics == nil {
+		metrics = nopFailureMetrics{}
+	}
+
+	return &failureService{
+		deadLetterRepo: deadLetterRepo,
+		alerter:        alerter,
+		alertAfter:     alertAfter,
+		streaks:        make(map[string]*failureStreak),
+		metrics:        metrics,
+		log:            log,
+	}
+}
+
+func (f *failureService) HandleFailure(ctx context.Context, update *parser.ParseResult, err error) {
+	parseErr := update.Err()
+	if parseErr != nil {
+		err = parseErr
+	}
+
+	class := classifyFailure(parseErr, err)
+	f.metrics.ObserveFailure(class)
+
+	streak, changed := f.count(update.URL(), class)
+	log := f.log.WithContext(ctx).With(logger.URL(update.URL()), logger.String("class", class), logger.Int("failures", streak.count))
+
+	if parseErr != nil {
+		log.Warn("parsing failed", logger.ErrFields(err)...)
+	} else {
+		log.Error("update handling failed", logger.ErrFields(err)...)
+	}
+
+	// Page is stored once it starts failing in a new way,
+	// the same page failing every cycle would flood the store otherwise
+	if changed && update.Raw() != nil && *update.Raw() != "" {
+		f.storeDeadLetter(ctx, log, update, class, err)
+	}
+
+	if streak.alerted {
+		f.alert(ctx, log, update.URL(), class, streak.count, err)
+	}
+}
+
+func (f *failureService) HandleSuccess(ctx context.Context, url string) {
+	if f.forget(url) {
+		f.log.WithContext(ctx).Info("url recovered", logger.URL(url))
+	}
+}
+
+func (f *failureService) HandleRemoved(ctx context.Context, url string) {
+	if f.forget(url) {
+		f.log.WithContext(ctx).Info("failing url removed", logger.URL(url))
+	}
+}
+
+// Drops streak of URL. Reports whether URL was failing
+func (f *failureService) forget(url string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.streaks[url]; !ok {
+		return false
+	}
+
+	delete(f.streaks, url)
+	f.metrics.SetFailingURLs(len(f.streaks))
+
+	return true
+}
+
+func (f *failureService) Failures(url string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if streak, ok := f.streaks[url]; ok {
+		return streak.count
+	}
+
+	return 0
+}
+
+// Returns copy of streak of URL after failure. Changed reports the first failure
+// of streak or change of class. Alerted is set only when threshold is reached
+func (f *failureService) count(url, class string) (streak failureStreak, changed bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	s, ok := f.streaks[url]
+	if !ok {
+		s = new(failureStreak)
+		f.streaks[url] = s
+		f.metrics.SetFailingURLs(len(f.streaks))
+	}
+
+	changed = s.class != class
+	s.class = class
+	s.count++
+
+	streak = *s
+	streak.alerted = false
+
+	if !s.alerted && s.count >= f.alertAfter {
+		s.alerted = true
+		streak.alerted = true
+	}
+
+	return streak, changed
+}
+
+func (f *failureService) storeDeadLetter(ctx context.Context, log logger.Logger, update *parser.ParseResult, class string, cause error) {
+	letter, err := domain.NewDeadLetter(update.URL(), class, cause.Error(), *update.Raw(), time.Now())
+	if err != nil {
+		log.Error("compressing dead letter failed", logger.Err(err))
+		return
+	}
+
+	id, err := f.deadLetterRepo.Insert(ctx, letter)
+	if err != nil {
+		err = errors.WrapInternal(err, "failureService.storeDeadLetter.Insert").With("url", update.URL())
+		log.Error("storing dead letter failed", logger.ErrFields(err)...)
+		return
+	}
+
+	log.Info("dead letter stored", logger.Int64("dead_letter_id", id), logger.Int("page_size", len(letter.Page())))
+}
+
+func (f *failureService) alert(ctx context.Context, log logger.Logger, url, class string, failures int, cause error) {
+	log.Error("url keeps failing", logger.Err(cause))
+
+	if f.alerter == nil {
+		return
+	}
+
+	text := fmt.Sprintf("URL keeps failing (%d times in a row, %s):\n%s\n\n%v", failures, class, url, cause)
+	if err := f.alerter.Alert(ctx, text); err != nil {
+		log.Error("alerting operators failed", logger.Err(err))
+	}
+}
+
+// Removed target drops failures of URL along, otherwise URL
+// that is not parsed anymore would be counted as failing forever
+type forgettingTargets struct {
+	parser.TargetManager
+
+	failures FailureService
+}
+
+func (t *forgettingTargets) RemoveTarget(url string) {
+	t.TargetManager.RemoveTarget(url)
+	t.failures.HandleRemoved(context.Background(), url)
+}
+
+// classifyFailure returns parser.Outcome of parse error,
+// otherwise kind of handler's error prefixed by "handler_"
+func classifyFailure(parseErr, err error) string {
+	if parseErr != nil {
+		return parser.Outcome(parseErr)
+	}
+
+	return failureClassHandler + errors.KindOf(err).K
+}
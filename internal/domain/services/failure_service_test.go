@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	goerrors "errors"
+	"strconv"
+	"testing"
+
+	domain "parser/internal/domain/models"
+	"parser/internal/errors"
+	"parser/internal/logger"
+	"parser/internal/parser"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeDeadLetterRepo struct {
+	letters []*domain.DeadLetter
+}
+
+func (f *fakeDeadLetterRepo) Insert(ctx context.Context, letter *domain.DeadLetter) (int64, error) {
+	f.letters = append(f.letters, letter)
+	return int64(len(f.letters)), nil
+}
+
+type recordingAlerter struct {
+	alerts []string
+}
+
+func (r *recordingAlerter) Alert(ctx context.Context, text string) error {
+	r.alerts = append(r.alerts, text)
+	return nil
+}
+
+type recordingFailureMetrics struct {
+	failing int
+}
+
+func (r *recordingFailureMetrics) ObserveFailure(class string) {}
+func (r *recordingFailureMetrics) SetFailingURLs(n int)        { r.failing = n }
+
+func failedResult(url string, err error, html string) *parser.ParseResult {
+	return parser.NewFailedParseResult(url, err, &html)
+}
+
+func TestFailureService(t *testing.T) {
+	const url = "https://www.avito.ru/1"
+
+	malformed := &strconv.NumError{Func: "ParseFloat", Num: "free", Err: strconv.ErrSyntax}
+
+	t.Run("stores page once it starts failing in a new way", func(t *testing.T) {
+		repo := new(fakeDeadLetterRepo)
+		s := NewFailureService(repo, nil, 10, nil, logger.Nop())
+
+		s.HandleFailure(context.Background(), failedResult(url, malformed, "<html>free</html>"), malformed)
+		s.HandleFailure(context.Background(), failedResult(url, malformed, "<html>free</html>"), malformed)
+		s.HandleFailure(context.Background(), failedResult(url, parser.ErrURLUnavailable, "<html>banned</html>"), parser.ErrURLUnavailable)
+
+		require.Equal(t, 3, s.Failures(url))
+		require.Len(t, repo.letters, 2)
+		require.Equal(t, parser.OutcomeMalformed, repo.letters[0].Class())
+		require.Equal(t, parser.OutcomeUnavailable, repo.letters[1].Class())
+		require.Equal(t, url, repo.letters[1].URL())
+
+		html, err := repo.letters[1].HTML()
+		require.NoError(t, err)
+		require.Equal(t, "<html>banned</html>", html)
+	})
+
+	t.Run("classifies errors of update handler by kind", func(t *testing.T) {
+		repo := new(fakeDeadLetterRepo)
+		s := NewFailureService(repo, nil, 10, nil, logger.Nop())
+
+		err := errors.WrapInternal(goerrors.New("connection refused"), "handleUpdate")
+		s.HandleFailure(context.Background(), parser.NewParseResult("iPhone", 1000, url), err)
+
+		require.Equal(t, 1, s.Failures(url))
+		require.Empty(t, repo.letters)
+		require.Equal(t, "handler_internal", classifyFailure(nil, err))
+	})
+
+	t.Run("alerts once per streak", func(t *testing.T) {
+		alerter := new(recordingAlerter)
+		s := NewFailureService(new(fakeDeadLetterRepo), alerter, 2, nil, logger.Nop())
+
+		for i := 0; i < 3; i++ {
+			s.HandleFailure(context.Background(), failedResult(url, malformed, "<html></html>"), malformed)
+		}
+
+		require.Len(t, alerter.alerts, 1)
+		require.Contains(t, alerter.alerts[0], url)
+		require.Contains(t, alerter.alerts[0], "malformed")
+
+		s.HandleSuccess(context.Background(), url)
+		require.Zero(t, s.Failures(url))
+
+		for i := 0; i < 2; i++ {
+			s.HandleFailure(context.Background(), failedResult(url, malformed, "<html></html>"), malformed)
+		}
+
+		require.Len(t, alerter.alerts, 2)
+	})
+
+	t.Run("forgets failures of removed target", func(t *testing.T) {
+		metrics := new(recordingFailureMetrics)
+		s := NewFailureService(new(fakeDeadLetterRepo), nil, 10, metrics, logger.Nop())
+		targets := new(fakeTargets)
+		forgetting := &forgettingTargets{TargetManager: targets, failures: s}
+
+		s.HandleFailure(context.Background(), failedResult(url, malformed, "<html></html>"), malformed)
+		require.Equal(t, 1, metrics.failing)
+
+		forgetting.RemoveTarget(url)
+
+		require.Equal(t, []string{url}, targets.removed)
+		require.Zero(t, s.Failures(url))
+		require.Zero(t, metrics.failing)
+	})
+}
@@ -1,19 +1,123 @@
 package services
 
 import (
+	domain "parser/internal/domain/models"
 	"parser/internal/domain/repositories"
+	"parser/internal/email"
+	"parser/internal/logger"
+	"parser/internal/messages"
 	"parser/internal/notify"
 	"parser/internal/parser"
+	"parser/internal/timer"
+	"time"
 )
 
+// Used to create Services instance
+type Options struct {
+	Repositories *repositories.Repositories
+	RingParser   *parser.RingParser
+
+	// Routes notifications to channels. See notify.Multiplexer
+	Notifier notify.Notifier
+	// Renders localized notification texts
+	Messages *messages.Renderer
+
+	// Optional. Email confirmation is disabled if nil
+	Mailer email.Mailer
+	// Link in confirmation email. e.g. https://example.com/email/confirm
+	EmailConfirmURL string
+	// How long confirmation link is valid
+	EmailConfirmationTTL time.Duration
+
+	// Webhook is deactivated after that many failed deliveries in a row
+	MaxWebhookFailures int
+
+	// Schedules delivery of held alerts. See DigestService
+	DigestTimer timer.Timer
+	// Telegram alerts are held that long to merge rapid changes of advert.
+	// Disabled if zero
+	CoalesceWindow time.Duration
+
+	// Verifies signatures of Telegram Login Widget and Web App
+	BotToken string
+	// Signed telegram data is accepted that long after sign in
+	TelegramAuthMaxAge time.Duration
+
+	// Price changes a stream could fall behind by before it is dropped.
+	// 64 if zero
+	StreamBuffer int
+
+	// Optional. Operators are alerted of failing URLs, alerts are only logged if nil
+	Alerter Alerter
+	// Operators are alerted after that many failures of URL in a row
+	AlertAfter int
+	// Optional
+	FailureMetrics FailureMetrics
+
+	// Which page snapshots are kept. Snapshots are disabled if nil
+	SnapshotRetention *domain.SnapshotRetention
+
+	// Optional. Services log under their own names
+	Logger logger.Logger
+}
+
 type Services struct {
 	SubscriptionService SubscriptionService
+	EmailService        EmailService
+	WebhookService      WebhookService
+	DigestService       DigestService
+	AuthService         AuthService
+	StreamService       StreamService
+	FailureService      FailureService
+	SnapshotService     SnapshotService
 }
 
-func NewServices(repos *repositories.Repositories, notifier notify.Notifier, ringParser *parser.RingParser) *Services {
+func NewServices(opts *Options) *Services {
+	repos := opts.Repositories
+
+	log := opts.Logger
+	if log == nil {
+		log = logger.Nop()
+	}
 
-	subscriptionService := NewSubscriptionService(repos.SubscriberRepo, repos.AdvertRepo, notifier, ringParser)
+	failureService := NewFailureService(repos.DeadLetterRepo, opts.Alerter, opts.AlertAfter, opts.FailureMetrics, log.Named("failures"))
+	streamService := NewStreamService(repos.SubscriberRepo, repos.PriceHistoryRepo, opts.StreamBuffer)
+	subscriptionService := NewSubscriptionService(
+		repos.SubscriberRepo,
+		repos.AdvertRepo,
+		repos.WebhookRepo,
+		repos.NotificationRepo,
+		opts.Notifier,
+		opts.Messages,
+		&forgettingTargets{TargetManager: opts.RingParser, failures: failureService},
+		opts.MaxWebhookFailures,
+		opts.CoalesceWindow,
+		streamService,
+		log.Named("subscriptions"),
+	)
+	emailService := NewEmailService(repos.SubscriberRepo, opts.Mailer, opts.EmailConfirmURL, opts.EmailConfirmationTTL)
+	webhookService := NewWebhookService(repos.SubscriberRepo, repos.WebhookRepo)
+	digestService := NewDigestService(
+		repos.SubscriberRepo,
+		repos.NotificationRepo,
+		opts.Notifier,
+		opts.Messages,
+		opts.DigestTimer,
+		opts.CoalesceWindow,
+		log.Named("digests"),
+	)
+	authService := NewAuthService(repos.APIKeyRepo, opts.BotToken, opts.TelegramAuthMaxAge)
+	snapshotService := NewSnapshotService(repos.SnapshotRepo, opts.SnapshotRetention)
 
-	return &Services{SubscriptionService: subscriptionService}
+	return &Services{
+		SubscriptionService: subscriptionService,
+		EmailService:        emailService,
+		WebhookService:      webhookService,
+		DigestService:       digestService,
+		AuthService:         authService,
+		StreamService:       streamService,
+		FailureService:      failureService,
+		SnapshotService:     snapshotService,
+	}
 
 }
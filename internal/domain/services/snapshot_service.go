@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	domain "parser/internal/domain/models"
+	"parser/internal/domain/repositories"
+	"parser/internal/errors"
+	"parser/internal/parser"
+	"time"
+)
+
+var (
+	ErrSnapshotsDisabled = errors.Define(errors.UnavailableKind, "snapshots_disabled", "page snapshots are disabled")
+)
+
+// SnapshotService archives html of parsed pages, so parser could be
+// debugged with exact markup that broke it. See domain.SnapshotRetention
+type SnapshotService interface {
+	// Stores page of parse result and deletes expired snapshots of its URL.
+	// Results without html are skipped, so are failures that repeat the last one
+	Capture(ctx context.Context, result *parser.ParseResult) error
+
+	// Snapshots without pages, newest first. Every URL if url is empty
+	List(ctx context.Context, url string, limit, offset int) ([]*domain.Snapshot, error)
+	// Returns snapshot with page
+	Get(ctx context.Context, snapshotID int64) (*domain.Snapshot, error)
+}
+
+type snapshotService struct {
+	snapshotRepo repositories.SnapshotRepository
+	// Snapshots are disabled if nil
+	retention *domain.SnapshotRetention
+}
+
+func NewSnapshotService(snapshotRepo repositories.SnapshotRepository, retention *domain.SnapshotRetention) SnapshotService {
+	return &snapshotService{
+		snapshotRepo: snapshotRepo,
+		retention:    retention,
+	}
+}
+
+func (s *snapshotService) Capture(ctx context.Context, result *parser.ParseResult) error {
+	if s.retention == nil || result.Raw() == nil || *result.Raw() == "" {
+		return nil
+	}
+
+	now := time.Now()
+
+	snapshot, err := domain.NewSnapshot(result.URL(), *result.Raw(), result.Err(), now)
+	if err != nil {
+		return errors.WrapInternal(err, "snapshotService.Capture.NewSnapshot")
+	}
+
+	// Newest first, bounded by retention
+	snapshots, err := s.snapshotRepo.List(ctx, result.URL(), nil)
+	if err != nil {
+		return errors.WrapInternal(err, "snapshotService.Capture.List").With("url", result.URL())
+	}
+
+	// URL failing every cycle, e.g. with captcha, is archived once per streak
+	// the same way dead letters are. See FailureService
+	if snapshot.Failed() && len(snapshots) > 0 && snapshots[0].Error() == snapshot.Error() {
+		return nil
+	}
+
+	err = s.snapshotRepo.Insert(ctx, snapshot)
+	if err != nil {
+		return errors.WrapInternal(err, "snapshotService.Capture.Insert").With("url", result.URL())
+	}
+
+	expired := s.retention.Expired(append(snapshots, snapshot), now)
+	if len(expired) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(expired))
+	for _, snapshot := range expired {
+		ids = append(ids, snapshot.SnapshotID)
+	}
+
+	err = s.snapshotRepo.Delete(ctx, ids)
+	if err != nil {
+		return errors.WrapInternal(err, "snapshotService.Capture.Delete").With("url", result.URL())
+	}
+
+	return nil
+}
+
+func (s *snapshotService) List(ctx context.Context, url string, limit, offset int) ([]*domain.Snapshot, error) {
+	if s.retention == nil {
+		return nil, errors.WrapDomain(ErrSnapshotsDisabled)
+	}
+
+	page, err := domain.NewPage(limit, offset)
+	if err != nil {
+		return nil, errors.WrapDomain(err)
+	}
+
+	snapshots, err := s.snapshotRepo.List(ctx, url, page)
+	if err != nil {
+		return nil, errors.WrapInternal(err, "snapshotService.List")
+	}
+
+	return snapshots, nil
+}
+
+func (s *snapshotService) Get(ctx context.Context, snapshotID int64) (*domain.Snapshot, error) {
+	if s.retention == nil {
+		return nil, errors.WrapDomain(ErrSnapshotsDisabled)
+	}
+
+	snapshot, err := s.snapshotRepo.Get(ctx, snapshotID)
+	if err != nil {
+		return nil, errors.WrapInternal(err, "snapshotService.Get").With("snapshot_id", snapshotID)
+	}
+
+	if snapshot == nil {
+		return nil, errors.WrapDomain(domain.ErrNoSnapshot)
+	}
+
+	return snapshot, nil
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	domain "parser/internal/domain/models"
+	"parser/internal/domain/repositories"
+	"parser/internal/parser"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotService(t *testing.T) {
+	const url = "https://www.avito.ru/1"
+
+	capture := func(t *testing.T, s SnapshotService, html string, err error) {
+		t.Helper()
+
+		result := parser.NewParseResultWithRaw("iPhone", 1000, url, &html)
+		if err != nil {
+			result = parser.NewFailedParseResult(url, err, &html)
+		}
+
+		require.NoError(t, s.Capture(context.Background(), result))
+	}
+
+	t.Run("keeps last pages and every failure", func(t *testing.T) {
+		s := NewSnapshotService(repositories.NewFSSnapshotRepo(t.TempDir()), &domain.SnapshotRetention{KeepPerURL: 2, FailedMaxAge: time.Hour})
+
+		capture(t, s, "<html>1</html>", nil)
+		capture(t, s, "<html>broken</html>", errors.New("no price"))
+		capture(t, s, "<html>2</html>", nil)
+		capture(t, s, "<html>3</html>", nil)
+
+		snapshots, err := s.List(context.Background(), url, 0, 0)
+		require.NoError(t, err)
+		require.Len(t, snapshots, 3)
+
+		var pages []string
+		for _, snapshot := range snapshots {
+			full, err := s.Get(context.Background(), snapshot.SnapshotID)
+			require.NoError(t, err)
+
+			html, err := full.HTML()
+			require.NoError(t, err)
+			pages = append(pages, html)
+		}
+
+		require.Equal(t, []string{"<html>3</html>", "<html>2</html>", "<html>broken</html>"}, pages)
+	})
+
+	t.Run("archives repeated failure once", func(t *testing.T) {
+		s := NewSnapshotService(repositories.NewFSSnapshotRepo(t.TempDir()), &domain.SnapshotRetention{KeepPerURL: 2, KeepFailedPerURL: 2, FailedMaxAge: time.Hour})
+
+		// Captcha every cycle
+		for i := 0; i < 5; i++ {
+			capture(t, s, "<html>captcha</html>", errors.New("no title"))
+		}
+
+		capture(t, s, "<html>broken</html>", errors.New("no price"))
+		capture(t, s, "<html>captcha</html>", errors.New("no title"))
+
+		snapshots, err := s.List(context.Background(), url, 0, 0)
+		require.NoError(t, err)
+		require.Len(t, snapshots, 2)
+		require.Equal(t, "no title", snapshots[0].Error())
+		require.Equal(t, "no price", snapshots[1].Error())
+	})
+
+	t.Run("skips results without html", func(t *testing.T) {
+		s := NewSnapshotService(repositories.NewFSSnapshotRepo(t.TempDir()), &domain.SnapshotRetention{KeepPerURL: 2})
+
+		require.NoError(t, s.Capture(context.Background(), parser.NewParseResult("iPhone", 1000, url)))
+
+		snapshots, err := s.List(context.Background(), "", 0, 0)
+		require.NoError(t, err)
+		require.Empty(t, snapshots)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		s := NewSnapshotService(nil, nil)
+
+		require.NoError(t, s.Capture(context.Background(), parser.NewParseResult("iPhone", 1000, url)))
+
+		_, err := s.List(context.Background(), "", 0, 0)
+		require.ErrorIs(t, err, ErrSnapshotsDisabled)
+
+		_, err = s.Get(context.Background(), 1)
+		require.ErrorIs(t, err, ErrSnapshotsDisabled)
+	})
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	domain "parser/internal/domain/models"
+	"parser/internal/domain/repositories"
+	"parser/internal/errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Used when Options.StreamBuffer is not set
+	defaultStreamBuffer = 64
+	// Client that has been away longer is told to refetch subscriptions instead,
+	// see PriceFeed.Reset
+	maxResumeEvents = 1000
+)
+
+var (
+	ErrNoStreamFilter  = errors.Define(errors.ValidationKind, "no_stream_filter", "telegram_id or advert_id is required")
+	ErrInvalidAdvertID = errors.Define(errors.ValidationKind, "invalid_advert_id", "advert_id should be a uuid")
+)
+
+type StreamService interface {
+	// Stores price change of advert and delivers it to connected streams
+	RecordPriceChange(ctx context.Context, ad *domain.Advert) error
+
+	// Opens feed of price changes of adverts subscriber is subscribed to.
+	// advertID narrows feed down to single advert, telegramID is optional then.
+	// Changes stored after lastEventID are replayed first, see PriceFeed.History
+	Subscribe(ctx context.Context, telegramID int64, advertID string, lastEventID int64) (*PriceFeed, error)
+}
+
+// PriceFeed is a stream of price changes of fixed set of adverts
+type PriceFeed struct {
+	// Changes missed since Last-Event-ID, oldest first.
+	// Might overlap with Live, so consumer skips IDs it has already sent
+	History []*domain.PriceChange
+	// More changes were missed than are replayed. History is empty then,
+	// consumer should refetch subscriptions rather than trust its state
+	Reset bool
+	// Closed when feed is closed or consumer falls behind.
+	// Consumer reconnects with Last-Event-ID in the latter case
+	Live <-chan *domain.PriceChange
+
+	close func()
+}
+
+// Stops delivery of live changes. Safe to call more than once
+func (f *PriceFeed) Close() {
+	if f.close != nil {
+		f.close()
+	}
+}
+
+type streamService struct {
+	subscriptionRepo repositories.SubscriberRepository
+	priceHistoryRepo repositories.PriceHistoryRepository
+	hub              *priceHub
+}
+
+func NewStreamService(
+	subscriptionRepo repositories.SubscriberRepository,
+	priceHistoryRepo repositories.PriceHistoryRepository,
+	buffer int) StreamService {
+	if buffer == 0 {
+		buffer = defaultStreamBuffer
+	}
+
+	return &streamService{
+		subscriptionRepo: subscriptionRepo,
+		priceHistoryRepo: priceHistoryRepo,
+		hub:              newPriceHub(buffer),
+	}
+}
+
+func (s *streamService) RecordPriceChange(ctx context.Context, ad *domain.Advert) error {
+	change := domain.PriceChangeFromAdvert(ad, time.Now())
+
+	changeID, err := s.priceHistoryRepo.Insert(ctx, change)
+	if err != nil {
+		return errors.WrapInternal(err, "streamService.RecordPriceChange.Insert")
+	}
+
+	change.ChangeID = changeID
+	s.hub.publish(change)
+
+	return nil
+}
+
+func (s *streamService) Subscribe(ctx context.Context, telegramID int64, advertID string, lastEventID int64) (*PriceFeed, error) {
+	advertIDs, err := s.streamAdverts(ctx, telegramID, advertID)
+	if err != nil {
+		return nil, errors.Chain(err, "streamService.Subscribe")
+	}
+
+	// Changes made while history is loaded are delivered live
+	live, cancel := s.hub.subscribe(advertIDs)
+	feed := &PriceFeed{Live: live, close: cancel}
+
+	if lastEventID <= 0 {
+		return feed, nil
+	}
+
+	// One more than replayed tells whether history is complete
+	history, err := s.priceHistoryRepo.GetSince(ctx, advertIDs, lastEventID, maxResumeEvents+1)
+	if err != nil {
+		feed.Close()
+		return nil, errors.WrapInternal(err, "streamService.Subscribe.GetSince")
+	}
+
+	if len(history) > maxResumeEvents {
+		feed.Reset = true
+		return feed, nil
+	}
+
+	feed.History = history
+
+	return feed, nil
+}
+
+// Resolves adverts stream is made of. The set is fixed for the whole stream
+func (s *streamService) streamAdverts(ctx context.Context, telegramID int64, advertID string) ([]string, error) {
+	if advertID != "" {
+		if _, err := uuid.Parse(advertID); err != nil {
+			return nil, errors.WrapDomain(ErrInvalidAdvertID)
+		}
+	}
+
+	if telegramID == 0 {
+		if advertID == "" {
+			return nil, errors.WrapDomain(ErrNoStreamFilter)
+		}
+
+		return []string{advertID}, nil
+	}
+
+	subscriber, err := s.subscriptionRepo.GetSubscriber(ctx, telegramID)
+	if err != nil {
+		return nil, errors.WrapInternal(err, "streamAdverts.GetSubscriber")
+	}
+
+	if subscriber == nil {
+		return nil, errors.WrapDomain(domain.ErrNoSubscriber)
+	}
+
+	advertIDs, err := s.subscriptionRepo.GetSubscribedAdvertIDs(ctx, subscriber.SubscriberID)
+	if err != nil {
+		return nil, errors.WrapInternal(err, "streamAdverts.GetSubscribedAdvertIDs")
+	}
+
+	if advertID == "" {
+		return advertIDs, nil
+	}
+
+	for _, id := range advertIDs {
+		if id == advertID {
+			return []string{advertID}, nil
+		}
+	}
+
+	return nil, errors.WrapDomain(domain.ErrNoSubscription)
+}
+
+// priceHub fans price changes out to connected streams
+type priceHub struct {
+	mu     sync.Mutex
+	buffer int
+	feeds  map[*hubFeed]struct{}
+}
+
+type hubFeed struct {
+	advertIDs map[string]struct{}
+	ch        chan *domain.PriceChange
+}
+
+func newPriceHub(buffer int) *priceHub {
+	return &priceHub{
+		buffer: buffer,
+		feeds:  make(map[*hubFeed]struct{}),
+	}
+}
+
+// Returned func unsubscribes and closes channel
+func (h *priceHub) subscribe(advertIDs []string) (<-chan *domain.PriceChange, func()) {
+	feed := &hubFeed{
+		advertIDs: make(map[string]struct{}, len(advertIDs)),
+		ch:        make(chan *domain.PriceChange, h.buffer),
+	}
+
+	for _, id := range advertIDs {
+		feed.advertIDs[id] = struct{}{}
+	}
+
+	h.mu.Lock()
+	h.feeds[feed] = struct{}{}
+	h.mu.Unlock()
+
+	return feed.ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		h.remove(feed)
+	}
+}
+
+// Never blocks. Feeds that fall behind are dropped,
+// so slow client doesn't hold up the parser
+func (h *priceHub) publish(change *domain.PriceChange) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for feed := range h.feeds {
+		if _, ok := feed.advertIDs[change.AdvertID]; !ok {
+			continue
+		}
+
+		select {
+		case feed.ch <- change:
+		default:
+			h.remove(feed)
+		}
+	}
+}
+
+// Must be called with mu held
+func (h *priceHub) remove(feed *hubFeed) {
+	if _, ok := h.feeds[feed]; !ok {
+		return
+	}
+
+	delete(h.feeds, feed)
+	close(feed.ch)
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	domain "parser/internal/domain/models"
+	"parser/internal/domain/repositories"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	advertOne = "6f1c9a4e-8b1e-4c8e-9a53-0c3f4c1a2b7d"
+	advertTwo = "0b7e2f3a-1c4d-4e5f-8a9b-2c3d4e5f6a7b"
+)
+
+func (f *fakeSubscriberRepo) GetSubscribedAdvertIDs(ctx context.Context, subscriberID string) ([]string, error) {
+	var advertIDs []string
+	for _, subscription := range f.subscriptions {
+		if subscription.SubscriberID == subscriberID {
+			advertIDs = append(advertIDs, subscription.AdvertID)
+		}
+	}
+
+	return advertIDs, nil
+}
+
+type fakePriceHistoryRepo struct {
+	repositories.PriceHistoryRepository
+
+	changes []*domain.PriceChange
+}
+
+func (f *fakePriceHistoryRepo) Insert(ctx context.Context, change *domain.PriceChange) (int64, error) {
+	f.changes = append(f.changes, change)
+	return int64(len(f.changes)), nil
+}
+
+func (f *fakePriceHistoryRepo) GetSince(ctx context.Context, advertIDs []string, afterID int64, limit uint64) ([]*domain.PriceChange, error) {
+	var changes []*domain.PriceChange
+	for _, change := range f.changes {
+		for _, advertID := range advertIDs {
+			if change.AdvertID == advertID && change.ChangeID > afterID {
+				changes = append(changes, change)
+			}
+		}
+	}
+
+	if uint64(len(changes)) > limit {
+		changes = changes[:limit]
+	}
+
+	return changes, nil
+}
+
+func receive(t *testing.T, live <-chan *domain.PriceChange) *domain.PriceChange {
+	t.Helper()
+
+	select {
+	case change := <-live:
+		return change
+	case <-time.After(time.Second):
+		t.Fatal("no change received")
+		return nil
+	}
+}
+
+func TestPriceHub(t *testing.T) {
+	t.Run("delivers changes of subscribed adverts only", func(t *testing.T) {
+		hub := newPriceHub(4)
+
+		live, cancel := hub.subscribe([]string{advertOne})
+		defer cancel()
+
+		hub.publish(domain.NewPriceChange(1, advertTwo, "", "", 100, 90, time.Now()))
+		hub.publish(domain.NewPriceChange(2, advertOne, "", "", 100, 80, time.Now()))
+
+		require.Equal(t, int64(2), receive(t, live).ChangeID)
+		require.Empty(t, live)
+	})
+
+	t.Run("drops subscriber that falls behind", func(t *testing.T) {
+		hub := newPriceHub(1)
+
+		slow, cancelSlow := hub.subscribe([]string{advertOne})
+		defer cancelSlow()
+
+		fast, cancelFast := hub.subscribe([]string{advertOne})
+		defer cancelFast()
+
+		hub.publish(domain.NewPriceChange(1, advertOne, "", "", 100, 90, time.Now()))
+		require.Equal(t, int64(1), receive(t, fast).ChangeID)
+
+		// Buffer of slow subscriber is full
+		hub.publish(domain.NewPriceChange(2, advertOne, "", "", 90, 80, time.Now()))
+		require.Equal(t, int64(2), receive(t, fast).ChangeID)
+
+		require.Equal(t, int64(1), receive(t, slow).ChangeID)
+		_, ok := <-slow
+		require.False(t, ok, "slow subscriber should be closed")
+
+		// Cancelling dropped subscriber is harmless
+		cancelSlow()
+	})
+}
+
+func TestStreamSubscribe(t *testing.T) {
+	subscriber := domain.NewSubscriber("sub-1", 1, "", nil, "en")
+
+	newService := func(history *fakePriceHistoryRepo) StreamService {
+		repo := &fakeSubscriberRepo{
+			subscribers:   []*domain.Subscriber{subscriber},
+			subscriptions: []*domain.Subscription{domain.NewSubscription("sub-1", advertOne)},
+		}
+
+		return NewStreamService(repo, history, 0)
+	}
+
+	t.Run("replays history and delivers live changes", func(t *testing.T) {
+		history := new(fakePriceHistoryRepo)
+		service := newService(history)
+		ctx := context.Background()
+
+		for _, id := range []string{advertOne, advertTwo, advertOne} {
+			err := service.RecordPriceChange(ctx, domain.NewAdvert(id, "https://www.avito.ru/1", "iPhone", 90, 100, true))
+			require.NoError(t, err)
+		}
+
+		feed, err := service.Subscribe(ctx, 1, "", 1)
+		require.NoError(t, err)
+		defer feed.Close()
+
+		require.Len(t, feed.History, 1)
+		require.Equal(t, int64(3), feed.History[0].ChangeID)
+
+		err = service.RecordPriceChange(ctx, domain.NewAdvert(advertOne, "https://www.avito.ru/1", "iPhone", 80, 90, true))
+		require.NoError(t, err)
+
+		change := receive(t, feed.Live)
+		require.Equal(t, int64(4), change.ChangeID)
+		require.Equal(t, float64(90), change.OldPrice())
+		require.Equal(t, float64(80), change.NewPrice())
+	})
+
+	t.Run("tells to resync when too many changes were missed", func(t *testing.T) {
+		history := new(fakePriceHistoryRepo)
+		service := newService(history)
+		ctx := context.Background()
+
+		for i := 0; i < maxResumeEvents+1; i++ {
+			err := service.RecordPriceChange(ctx, domain.NewAdvert(advertOne, "https://www.avito.ru/1", "iPhone", 90, 100, true))
+			require.NoError(t, err)
+		}
+
+		feed, err := service.Subscribe(ctx, 1, "", 1)
+		require.NoError(t, err)
+		defer feed.Close()
+
+		// Exactly maxResumeEvents missed
+		require.False(t, feed.Reset)
+		require.Len(t, feed.History, maxResumeEvents)
+
+		err = service.RecordPriceChange(ctx, domain.NewAdvert(advertOne, "https://www.avito.ru/1", "iPhone", 80, 90, true))
+		require.NoError(t, err)
+
+		feed, err = service.Subscribe(ctx, 1, "", 1)
+		require.NoError(t, err)
+		defer feed.Close()
+
+		require.True(t, feed.Reset)
+		require.Empty(t, feed.History)
+	})
+
+	t.Run("rejects adverts subscriber is not subscribed to", func(t *testing.T) {
+		_, err := newService(new(fakePriceHistoryRepo)).Subscribe(context.Background(), 1, advertTwo, 0)
+		require.ErrorContains(t, err, domain.ErrNoSubscription.Error())
+	})
+
+	t.Run("requires filter", func(t *testing.T) {
+		_, err := newService(new(fakePriceHistoryRepo)).Subscribe(context.Background(), 0, "", 0)
+		require.ErrorContains(t, err, ErrNoStreamFilter.Error())
+
+		_, err = newService(new(fakePriceHistoryRepo)).Subscribe(context.Background(), 0, "42", 0)
+		require.ErrorContains(t, err, ErrInvalidAdvertID.Error())
+	})
+}
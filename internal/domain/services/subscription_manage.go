@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	domain "parser/internal/domain/models"
+	"parser/internal/errors"
+	"parser/internal/http/dto"
+
+	"github.com/google/uuid"
+)
+
+func (s *subscriptionService) ListSubscriptions(ctx context.Context, telegramID int64, limit, offset int) ([]*domain.Subscription, int, error) {
+	page, err := domain.NewPage(limit, offset)
+	if err != nil {
+		return nil, 0, errors.WrapDomain(err)
+	}
+
+	subscriber, err := s.getSubscriber(ctx, telegramID)
+	if err != nil {
+		return nil, 0, errors.Chain(err, "subscriptionService.ListSubscriptions")
+	}
+
+	subscriptions, err := s.subscriptionRepo.GetSubscriptions(ctx, subscriber.SubscriberID, page)
+	if err != nil {
+		return nil, 0, errors.WrapInternal(err, "subscriptionService.ListSubscriptions.GetSubscriptions")
+	}
+
+	total, err := s.subscriptionRepo.CountSubscriptions(ctx, subscriber.SubscriberID)
+	if err != nil {
+		return nil, 0, errors.WrapInternal(err, "subscriptionService.ListSubscriptions.CountSubscriptions")
+	}
+
+	return subscriptions, total, nil
+}
+
+func (s *subscriptionService) GetSubscription(ctx context.Context, telegramID int64, advertID string) (*domain.Subscription, error) {
+	subscriber, err := s.getSubscriber(ctx, telegramID)
+	if err != nil {
+		return nil, errors.Chain(err, "subscriptionService.GetSubscription")
+	}
+
+	subscription, err := s.getSubscription(ctx, subscriber.SubscriberID, advertID)
+	if err != nil {
+		return nil, errors.Chain(err, "subscriptionService.GetSubscription")
+	}
+
+	return subscription, nil
+}
+
+func (s *subscriptionService) DeleteSubscription(ctx context.Context, telegramID int64, advertID string) error {
+	subscriber, err := s.getSubscriber(ctx, telegramID)
+	if err != nil {
+		return errors.Chain(err, "subscriptionService.DeleteSubscription")
+	}
+
+	// Advert's URL is needed to stop parsing it
+	subscription, err := s.getSubscription(ctx, subscriber.SubscriberID, advertID)
+	if err != nil {
+		return errors.Chain(err, "subscriptionService.DeleteSubscription")
+	}
+
+	// Advert mustn't get new subscription between count and removal of target
+	unlock := s.targetLocks.Lock(subscription.Advert().URL())
+	defer unlock()
+
+	ok, err := s.subscriptionRepo.DeleteSubscription(ctx, subscriber.SubscriberID, advertID)
+	if err != nil {
+		return errors.WrapInternal(err, "subscriptionService.DeleteSubscription.DeleteSubscription")
+	}
+
+	// Deleted concurrently
+	if !ok {
+		return errors.WrapDomain(domain.ErrNoSubscription)
+	}
+
+	left, err := s.subscriptionRepo.CountAdvertSubscribers(ctx, advertID)
+	if err != nil {
+		return errors.WrapInternal(err, "subscriptionService.DeleteSubscription.CountAdvertSubscribers")
+	}
+
+	// Nobody is interested in advert anymore
+	if left == 0 {
+		s.targets.RemoveTarget(subscription.Advert().URL())
+	}
+
+	return nil
+}
+
+func (s *subscriptionService) UpdateSubscription(ctx context.Context, telegramID int64, advertID string, dto *dto.UpdateSubscriptionRequest) (*domain.Subscription, error) {
+	// nil resets override
+	var channels []domain.Channel
+	if dto.Channels != nil && !dto.ResetChannels {
+		parsed, err := domain.ParseChannels(dto.Channels)
+		if err != nil {
+			return nil, errors.WrapDomain(err)
+		}
+
+		channels = parsed
+	}
+
+	subscriber, err := s.getSubscriber(ctx, telegramID)
+	if err != nil {
+		return nil, errors.Chain(err, "subscriptionService.UpdateSubscription")
+	}
+
+	subscription, err := s.getSubscription(ctx, subscriber.SubscriberID, advertID)
+	if err != nil {
+		return nil, errors.Chain(err, "subscriptionService.UpdateSubscription")
+	}
+
+	if dto.Channels != nil || dto.ResetChannels {
+		_, err = s.subscriptionRepo.SetSubscriptionChannels(ctx, subscriber.SubscriberID, advertID, channels)
+		if err != nil {
+			return nil, errors.WrapInternal(err, "subscriptionService.UpdateSubscription.SetSubscriptionChannels")
+		}
+
+		subscription.OverrideChannels(channels)
+	}
+
+	if dto.Muted != nil {
+		_, err = s.subscriptionRepo.SetSubscriptionMuted(ctx, subscriber.SubscriberID, advertID, *dto.Muted)
+		if err != nil {
+			return nil, errors.WrapInternal(err, "subscriptionService.UpdateSubscription.SetSubscriptionMuted")
+		}
+
+		subscription.SetMuted(*dto.Muted)
+	}
+
+	return subscription, nil
+}
+
+// Subscriptions could be managed only by existing subscribers
+func (s *subscriptionService) getSubscriber(ctx context.Context, telegramID int64) (*domain.Subscriber, error) {
+	subscriber, err := s.subscriptionRepo.GetSubscriber(ctx, telegramID)
+	if err != nil {
+		return nil, errors.WrapInternal(err, "getSubscriber.GetSubscriber")
+	}
+
+	if subscriber == nil {
+		return nil, errors.WrapDomain(domain.ErrNoSubscriber)
+	}
+
+	return subscriber, nil
+}
+
+// Returns subscription with attached advert
+func (s *subscriptionService) getSubscription(ctx context.Context, subscriberID, advertID string) (*domain.Subscription, error) {
+	// Malformed ID could not match any advert
+	if _, err := uuid.Parse(advertID); err != nil {
+		return nil, errors.WrapDomain(domain.ErrNoSubscription)
+	}
+
+	subscription, err := s.subscriptionRepo.GetSubscriptionByAdvert(ctx, subscriberID, advertID)
+	if err != nil {
+		return nil, errors.WrapInternal(err, "getSubscription.GetSubscriptionByAdvert")
+	}
+
+	if subscription == nil {
+		return nil, errors.WrapDomain(domain.ErrNoSubscription)
+	}
+
+	return subscription, nil
+}
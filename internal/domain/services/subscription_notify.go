@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	goerrors "errors"
+	domain "parser/internal/domain/models"
+	"parser/internal/errors"
+	"parser/internal/logger"
+	"parser/internal/messages"
+	"parser/internal/notify"
+	"time"
+)
+
+// notifySubscriber sends alert to every channel subscriber wants for the advert.
+// Failure of one channel doesn't prevent others from being notified
+func (s *subscriptionService) notifySubscriber(ctx context.Context, ad *domain.Advert, subscriber *domain.Subscriber, webhooks []*domain.Webhook) error {
+	var firstErr error
+
+	now := time.Now()
+
+	for _, channel := range subscriber.ChannelsFor(ad.AdvertID) {
+		var err error
+
+		switch channel {
+		case domain.ChannelTelegram:
+			// Alert is sent later within digest
+			if subscriber.Schedule().ShouldHold(now) {
+				err = s.holdNotification(ctx, ad, subscriber, domain.PendingDigest, now)
+				break
+			}
+
+			// Alert is merged with following changes of advert. See DigestService
+			if s.coalesceWindow > 0 {
+				err = s.holdNotification(ctx, ad, subscriber, domain.PendingCoalesce, now)
+				break
+			}
+
+			err = s.notifyTelegram(ctx, ad, subscriber, now)
+			if goerrors.Is(err, notify.ErrRecipientUnreachable) {
+				// User has blocked the bot or deleted an account.
+				// Stop sending them telegram alerts until they're back with /start.
+				// Email and webhooks are still delivered
+				s.log.WithContext(ctx).Info("subscriber is unreachable in telegram", logger.TelegramID(subscriber.TelegramID()))
+
+				err = s.subscriptionRepo.SetTelegramReachable(ctx, subscriber.TelegramID(), false)
+				if err != nil {
+					err = errors.WrapInternal(err, "notifySubscriber.SetTelegramReachable").With("telegram_id", subscriber.TelegramID())
+				}
+			}
+		case domain.ChannelEmail:
+			err = s.notifyEmail(ctx, ad, subscriber, now)
+		case domain.ChannelWebhook:
+			s.enqueueWebhooks(ctx, ad, webhooks)
+		}
+
+		if err != nil && firstErr == nil {
+			firstErr = errors.Chain(err, "notifySubscriber")
+		}
+	}
+
+	return firstErr
+}
+
+func (s *subscriptionService) notifyTelegram(ctx context.Context, ad *domain.Advert, subscriber *domain.Subscriber, now time.Time) error {
+	fingerprint := domain.AlertFingerprint(ad.LastPrice(), ad.CurrentPrice())
+
+	suppressed, err := s.dedup.suppress(ctx, subscriber.SubscriberID, ad.AdvertID, domain.ChannelTelegram, fingerprint, now)
+	if err != nil {
+		return errors.Chain(err, "notifyTelegram")
+	}
+
+	if suppressed {
+		return nil
+	}
+
+	msg, err := s.messages.Render(messages.Locale(subscriber.Locale()), messages.PriceChanged, &messages.PriceChangedData{
+		Title:    ad.Title(),
+		URL:      ad.URL(),
+		OldPrice: ad.LastPrice(),
+		NewPrice: ad.CurrentPrice(),
+	})
+	if err != nil {
+		return errors.WrapInternal(err, "notifyTelegram.Render")
+	}
+
+	err = deliver(ctx, s.notifier, ad, domain.ChannelTelegram, subscriber.TelegramID(), msg)
+	if goerrors.Is(err, notify.ErrRecipientUnreachable) {
+		// Caller handles it
+		return err
+	}
+
+	if err != nil {
+		// TODO: maybe some queue??
+		return errors.WrapInternal(err, "notifyTelegram.Notify").With("telegram_id", subscriber.TelegramID())
+	}
+
+	err = s.dedup.delivered(ctx, subscriber.SubscriberID, ad.AdvertID, domain.ChannelTelegram, fingerprint, now)
+	if err != nil {
+		return errors.Chain(err, "notifyTelegram")
+	}
+
+	return nil
+}
+
+// Quiet hours, digests and coalescing apply only to telegram, the channel that wakes people up.
+// Email and webhooks are delivered immediately
+func (s *subscriptionService) holdNotification(ctx context.Context, ad *domain.Advert, subscriber *domain.Subscriber, kind domain.PendingKind, now time.Time) error {
+	err := s.notificationRepo.InsertPending(ctx, domain.PendingFromAdvert(subscriber.SubscriberID, ad, kind, now))
+	if err != nil {
+		return errors.WrapInternal(err, "holdNotification.InsertPending")
+	}
+
+	return nil
+}
+
+func (s *subscriptionService) notifyEmail(ctx context.Context, ad *domain.Advert, subscriber *domain.Subscriber, now time.Time) error {
+	// Email is not confirmed yet
+	if !subscriber.HasEmail() {
+		return nil
+	}
+
+	fingerprint := domain.AlertFingerprint(ad.LastPrice(), ad.CurrentPrice())
+
+	suppressed, err := s.dedup.suppress(ctx, subscriber.SubscriberID, ad.AdvertID, domain.ChannelEmail, fingerprint, now)
+	if err != nil {
+		return errors.Chain(err, "notifyEmail")
+	}
+
+	if suppressed {
+		return nil
+	}
+
+	locale := messages.Locale(subscriber.Locale())
+	data := &messages.PriceChangedData{
+		Title:    ad.Title(),
+		URL:      ad.URL(),
+		OldPrice: ad.LastPrice(),
+		NewPrice: ad.CurrentPrice(),
+	}
+
+	subject, err := s.messages.Render(locale, messages.PriceChangedSubject, data)
+	if err != nil {
+		return errors.WrapInternal(err, "notifyEmail.Render")
+	}
+
+	msg, err := s.messages.Render(locale, messages.PriceChanged, data)
+	if err != nil {
+		return errors.WrapInternal(err, "notifyEmail.Render")
+	}
+
+	err = deliver(ctx, s.notifier, ad, domain.ChannelEmail, subscriber.Email(), subject, msg)
+	if goerrors.Is(err, notify.ErrChannelDisabled) {
+		return nil
+	}
+
+	if err != nil {
+		return errors.WrapInternal(err, "notifyEmail.Notify")
+	}
+
+	err = s.dedup.delivered(ctx, subscriber.SubscriberID, ad.AdvertID, domain.ChannelEmail, fingerprint, now)
+	if err != nil {
+		return errors.Chain(err, "notifyEmail")
+	}
+
+	return nil
+}
+
+// Webhooks are delivered by webhookQueue. Event is dropped if queue is full,
+// it's not counted as failure of receiver
+func (s *subscriptionService) enqueueWebhooks(ctx context.Context, ad *domain.Advert, webhooks []*domain.Webhook) {
+	if len(webhooks) == 0 {
+		return
+	}
+
+	if !s.webhookQueue.push(&webhookJob{ctx: detach(ctx), ad: ad, webhooks: webhooks}) {
+		s.log.WithContext(ctx).Warn("webhook queue is full, event dropped", logger.String("advert_id", ad.AdvertID))
+	}
+}
+
+// Called by webhookQueue workers
+func (s *subscriptionService) deliverWebhooks(job *webhookJob) {
+	if err := s.notifyWebhooks(job.ctx, job.ad, job.webhooks); err != nil {
+		s.log.WithContext(job.ctx).Error("delivering webhooks failed", logger.ErrFields(err)...)
+	}
+}
+
+// Failed delivery to one webhook doesn't prevent others from receiving an event.
+// Webhooks that keep failing are deactivated
+func (s *subscriptionService) notifyWebhooks(ctx context.Context, ad *domain.Advert, webhooks []*domain.Webhook) error {
+	for _, webhook := range webhooks {
+		deliveryCtx, cancel := context.WithTimeout(ctx, webhookDeliveryTimeout)
+		err := deliver(deliveryCtx, s.notifier, ad, domain.ChannelWebhook, webhook.URL(), webhook.Secret(), deliveryCtx)
+		cancel()
+
+		if goerrors.Is(err, notify.ErrChannelDisabled) {
+			return nil
+		}
+
+		if err == nil {
+			// Failures are counted in a row
+			if webhook.Failures() > 0 {
+				err = s.webhookRepo.ResetFailures(ctx, webhook.WebhookID)
+				if err != nil {
+					return errors.WrapInternal(err, "notifyWebhooks.ResetFailures").With("webhook_id", webhook.WebhookID)
+				}
+			}
+
+			continue
+		}
+
+		s.log.WithContext(ctx).Warn("webhook delivery failed", logger.String("webhook_id", webhook.WebhookID), logger.Err(err))
+
+		_, err = s.webhookRepo.RecordFailure(ctx, webhook.WebhookID, s.maxWebhookFailures)
+		if err != nil {
+			return errors.WrapInternal(err, "notifyWebhooks.RecordFailure").With("webhook_id", webhook.WebhookID)
+		}
+	}
+
+	return nil
+}
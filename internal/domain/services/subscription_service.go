@@ -2,63 +2,164 @@ package services
 
 import (
 	"context"
-	"fmt"
 	domain "parser/internal/domain/models"
 	"parser/internal/domain/repositories"
 	"parser/internal/errors"
 	"parser/internal/http/dto"
+	"parser/internal/logger"
+	"parser/internal/messages"
 	"parser/internal/notify"
 	"parser/internal/parser"
+	"parser/internal/tracing"
 	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-type UpdateHandler func(result *parser.ParseResult) error
+var tracer = otel.Tracer("parser/internal/domain/services")
+
+// UpdateHandler handles result of parsing. ctx carries trace of parsing
+type UpdateHandler func(ctx context.Context, result *parser.ParseResult) error
 
 type SubscriptionService interface {
 	NewSubscription(ctx context.Context, dto *dto.SubscribeRequest) error
 
+	// Returns page of subscriber's subscriptions with their adverts
+	// and total amount of subscriptions
+	ListSubscriptions(ctx context.Context, telegramID int64, limit, offset int) ([]*domain.Subscription, int, error)
+	// Returns subscription with current state of its advert
+	GetSubscription(ctx context.Context, telegramID int64, advertID string) (*domain.Subscription, error)
+	// Unsubscribes. Advert is no longer parsed once it loses its last subscriber
+	DeleteSubscription(ctx context.Context, telegramID int64, advertID string) error
+	// Updates alert settings of subscription. Returns updated subscription
+	UpdateSubscription(ctx context.Context, telegramID int64, advertID string, dto *dto.UpdateSubscriptionRequest) (*domain.Subscription, error)
+
 	NotifySubscribers(ctx context.Context, ad *domain.Advert) error
 
+	// Sets channels used by subscriptions that don't override them
+	SetChannels(ctx context.Context, dto *dto.ChannelsRequest) error
+	// Overrides channels of single subscription
+	SetSubscriptionChannels(ctx context.Context, dto *dto.SubscriptionChannelsRequest) error
+
+	// Sets language of notifications
+	SetLocale(ctx context.Context, dto *dto.LocaleRequest) error
+
+	// Sets quiet hours, timezone and digest mode.
+	// Held alerts are sent by DigestService
+	SetSchedule(ctx context.Context, dto *dto.ScheduleRequest) error
+
+	// Called when user sends /start to the bot.
+	// Creates subscriber or makes existing one receive notifications again.
+	// Locale is detected from languageCode
+	HandleStart(ctx context.Context, telegramID int64, languageCode string) error
+
 	GetUpdateHandler() UpdateHandler
 
 	GetURLFetcher() func(ctx context.Context) ([]string, error)
+
+	// Waits for queued webhook deliveries. Webhooks aren't delivered after that
+	Close()
 }
 
 type subscriptionService struct {
 	subscriptionRepo repositories.SubscriberRepository
 	advertRepo       repositories.AdvertRepository
-	notifier         notify.Notifier
-	targetAdder      parser.TargetAdder
+	webhookRepo      repositories.WebhookRepository
+	notificationRepo repositories.NotificationRepository
+	// Suppresses alerts that repeat delivered ones
+	dedup *deduplicator
+	// Routes notifications by domain.Channel. See notify.Multiplexer
+	notifier notify.Notifier
+	messages *messages.Renderer
+	targets  parser.TargetManager
+	// Keeps target of advert in sync with its subscriptions
+	targetLocks urlLocks
+
+	// Webhook is deactivated after that many failed deliveries in a row
+	maxWebhookFailures int
+	webhookQueue       *webhookQueue
+	// Telegram alerts are held that long to merge rapid changes of advert.
+	// Disabled if zero
+	coalesceWindow time.Duration
+
+	// Optional. Records price history and feeds live streams
+	stream StreamService
+
+	log logger.Logger
 }
 
 func NewSubscriptionService(
 	subscriptionRepo repositories.SubscriberRepository,
 	advertRepo repositories.AdvertRepository,
+	webhookRepo repositories.WebhookRepository,
+	notificationRepo repositories.NotificationRepository,
 	notifier notify.Notifier,
-	targetAdder parser.TargetAdder) SubscriptionService {
-	return &subscriptionService{
-		subscriptionRepo: subscriptionRepo,
-		advertRepo:       advertRepo,
-		notifier:         notifier,
-		targetAdder:      targetAdder,
+	messages *messages.Renderer,
+	targets parser.TargetManager,
+	maxWebhookFailures int,
+	coalesceWindow time.Duration,
+	stream StreamService,
+	log logger.Logger) SubscriptionService {
+	s := &subscriptionService{
+		subscriptionRepo:   subscriptionRepo,
+		advertRepo:         advertRepo,
+		webhookRepo:        webhookRepo,
+		notificationRepo:   notificationRepo,
+		dedup:              &deduplicator{notificationRepo: notificationRepo},
+		notifier:           notifier,
+		messages:           messages,
+		targets:            targets,
+		maxWebhookFailures: maxWebhookFailures,
+		coalesceWindow:     coalesceWindow,
+		stream:             stream,
+		log:                log,
 	}
+
+	s.webhookQueue = newWebhookQueue(s.deliverWebhooks)
+
+	return s
+}
+
+func (s *subscriptionService) Close() {
+	s.webhookQueue.close()
 }
 
 func (s *subscriptionService) NewSubscription(ctx context.Context, dto *dto.SubscribeRequest) error {
 
+	// Different URLs of the same advert share canonical one
+	advertURL, err := domain.ParseAdvertURL(dto.AdvertURL)
+	if err != nil {
+		return errors.WrapDomain(err)
+	}
+
+	// Concurrent deletion of the last subscription of advert
+	// shouldn't remove target added here
+	unlock := s.targetLocks.Lock(advertURL.String())
+	defer unlock()
+
 	// Before heavy buisiness logic perform quick check
-	candidateSubscription, err := s.subscriptionRepo.GetSubscription(ctx, dto.TelegramID, dto.AdvertURL)
+	candidateSubscription, err := s.subscriptionRepo.GetSubscription(ctx, dto.TelegramID, advertURL.String())
 	if err != nil {
 		return errors.WrapInternal(err, "subscriptionService.NewSubscription.GetSubscription")
 	}
 
 	// Subscription already exists
 	if candidateSubscription != nil {
-		return domain.ErrSubscriptionExist
+		return errors.WrapDomain(domain.ErrSubscriptionExist)
+	}
+
+	var channels []domain.Channel
+	if dto.Channels != nil {
+		channels, err = domain.ParseChannels(dto.Channels)
+		if err != nil {
+			return errors.WrapDomain(err)
+		}
 	}
 
 	// Try get existing advert
-	advert, err := s.advertRepo.GetByURL(ctx, dto.AdvertURL)
+	advert, err := s.advertRepo.GetByURL(ctx, advertURL.String())
 	if err != nil {
 		return errors.WrapInternal(err, "subscriptionService.NewSubscription.GetByURL")
 	}
@@ -66,7 +167,7 @@ func (s *subscriptionService) NewSubscription(ctx context.Context, dto *dto.Subs
 	// No such advert so create one
 	if advert == nil {
 
-		newAdvert, err := domain.NewEmptyAdvert(dto.AdvertURL)
+		newAdvert, err := domain.NewEmptyAdvert(advertURL.String())
 		if err != nil {
 			return errors.WrapDomain(err)
 		}
@@ -91,10 +192,12 @@ func (s *subscriptionService) NewSubscription(ctx context.Context, dto *dto.Subs
 	// No such subscriber so create one
 	if subscriber == nil {
 		subscriber = domain.SubscriberFromTelegramID(dto.TelegramID)
+		subscriber.SetLocale(string(messages.DefaultLocale))
 		isNewSubscriber = true
 	}
 
 	subscription := domain.NewSubscription(subscriber.SubscriberID, advert.AdvertID)
+	subscription.OverrideChannels(channels)
 	subscriber.AddSubscription(subscription)
 
 	if isNewSubscriber {
@@ -115,29 +218,195 @@ func (s *subscriptionService) NewSubscription(ctx context.Context, dto *dto.Subs
 
 	}
 
-	s.targetAdder.AddTarget(advert.URL())
+	s.targets.AddTarget(advert.URL())
 	return nil
 }
 
-func (s *subscriptionService) NotifySubscribers(ctx context.Context, ad *domain.Advert) error {
+func (s *subscriptionService) NotifySubscribers(ctx context.Context, ad *domain.Advert) (err error) {
+	ctx, span := tracer.Start(ctx, "SubscriptionService.NotifySubscribers", trace.WithAttributes(attribute.String("advert_id", ad.AdvertID)))
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
 	subscribers, err := s.subscriptionRepo.GetAdvertSubscribers(ctx, ad.AdvertID)
 	if err != nil {
-		// TODO: wrap to internal
-		return errors.WrapInternal(err, "subscriptionService.NotifySubscribers.GetAdvertSubscribers")
+		return errors.WrapInternal(err, "subscriptionService.NotifySubscribers.GetAdvertSubscribers").With("advert_id", ad.AdvertID)
+	}
+
+	// Fetch webhooks of all subscribers at once
+	webhooks, err := s.webhookRepo.GetAdvertWebhooks(ctx, ad.AdvertID)
+	if err != nil {
+		return errors.WrapInternal(err, "subscriptionService.NotifySubscribers.GetAdvertWebhooks").With("advert_id", ad.AdvertID)
 	}
 
+	span.SetAttributes(attribute.Int("subscribers", len(subscribers)))
+
+	subscriberWebhooks := make(map[string][]*domain.Webhook)
+	for _, webhook := range webhooks {
+		subscriberWebhooks[webhook.SubscriberID] = append(subscriberWebhooks[webhook.SubscriberID], webhook)
+	}
+
+	// Failure of one subscriber doesn't prevent others from being notified.
+	// The first error is returned after everyone is notified
+	var firstErr error
 	for _, subscriber := range subscribers {
-		// hardcoded for now
-		msg := fmt.Sprintf("Hey!\n%s is updated!\nNew price: %.2f\nPrev price: %.2f\n", ad.Title(), ad.CurrentPrice(), ad.LastPrice())
-
-		// Notify actually
-		// Imagine we've straightforwardly chosen telegram notifications
-		// Otherwise we'd need to get user's wanted notification provider
-		// and match arguments to specific notifier... see Notifier args...
-		err := s.notifier.Notify(ad, subscriber.TelegramID(), msg)
+		err := s.notifySubscriber(ctx, ad, subscriber, subscriberWebhooks[subscriber.SubscriberID])
+		if err != nil && firstErr == nil {
+			firstErr = errors.Chain(err, "subscriptionService.NotifySubscribers")
+		}
+	}
+
+	return firstErr
+}
+
+func (s *subscriptionService) SetChannels(ctx context.Context, dto *dto.ChannelsRequest) error {
+	channels, err := domain.ParseChannels(dto.Channels)
+	if err != nil {
+		return errors.WrapDomain(err)
+	}
+
+	subscriber, err := s.subscriptionRepo.GetSubscriber(ctx, dto.TelegramID)
+	if err != nil {
+		return errors.WrapInternal(err, "subscriptionService.SetChannels.GetSubscriber")
+	}
+
+	if subscriber == nil {
+		return errors.WrapDomain(domain.ErrNoSubscriber)
+	}
+
+	err = s.subscriptionRepo.SetChannels(ctx, subscriber.SubscriberID, channels)
+	if err != nil {
+		return errors.WrapInternal(err, "subscriptionService.SetChannels.SetChannels")
+	}
+
+	return nil
+}
+
+func (s *subscriptionService) SetSubscriptionChannels(ctx context.Context, dto *dto.SubscriptionChannelsRequest) error {
+	// nil resets override
+	var channels []domain.Channel
+	if dto.Channels != nil {
+		parsed, err := domain.ParseChannels(dto.Channels)
 		if err != nil {
-			// TODO: maybe some queue??
-			return errors.WrapInternal(err, "subscriptionService.NotifySubscribers.Notify")
+			return errors.WrapDomain(err)
+		}
+
+		channels = parsed
+	}
+
+	advertURL, err := domain.ParseAdvertURL(dto.AdvertURL)
+	if err != nil {
+		return errors.WrapDomain(err)
+	}
+
+	subscription, err := s.subscriptionRepo.GetSubscription(ctx, dto.TelegramID, advertURL.String())
+	if err != nil {
+		return errors.WrapInternal(err, "subscriptionService.SetSubscriptionChannels.GetSubscription")
+	}
+
+	if subscription == nil {
+		return errors.WrapDomain(domain.ErrNoSubscription)
+	}
+
+	_, err = s.subscriptionRepo.SetSubscriptionChannels(ctx, subscription.SubscriberID, subscription.AdvertID, channels)
+	if err != nil {
+		return errors.WrapInternal(err, "subscriptionService.SetSubscriptionChannels.SetSubscriptionChannels")
+	}
+
+	return nil
+}
+
+func (s *subscriptionService) SetLocale(ctx context.Context, dto *dto.LocaleRequest) error {
+	locale := messages.Locale(dto.Locale)
+	if !messages.IsSupported(locale) {
+		return errors.Wrap(messages.ErrUnsupportedLocale, errors.ValidationKind, "unsupported_locale")
+	}
+
+	subscriber, err := s.subscriptionRepo.GetSubscriber(ctx, dto.TelegramID)
+	if err != nil {
+		return errors.WrapInternal(err, "subscriptionService.SetLocale.GetSubscriber")
+	}
+
+	if subscriber == nil {
+		return errors.WrapDomain(domain.ErrNoSubscriber)
+	}
+
+	err = s.subscriptionRepo.SetLocale(ctx, subscriber.SubscriberID, string(locale))
+	if err != nil {
+		return errors.WrapInternal(err, "subscriptionService.SetLocale.SetLocale")
+	}
+
+	return nil
+}
+
+func (s *subscriptionService) SetSchedule(ctx context.Context, dto *dto.ScheduleRequest) error {
+	var quiet *domain.QuietHours
+	if dto.QuietHours != nil {
+		parsed, err := domain.ParseQuietHours(dto.QuietHours.From, dto.QuietHours.To)
+		if err != nil {
+			return errors.WrapDomain(err)
+		}
+
+		quiet = parsed
+	}
+
+	schedule, err := domain.NewSchedule(dto.Timezone, quiet, domain.DigestMode(dto.Digest))
+	if err != nil {
+		return errors.WrapDomain(err)
+	}
+
+	subscriber, err := s.subscriptionRepo.GetSubscriber(ctx, dto.TelegramID)
+	if err != nil {
+		return errors.WrapInternal(err, "subscriptionService.SetSchedule.GetSubscriber")
+	}
+
+	if subscriber == nil {
+		return errors.WrapDomain(domain.ErrNoSubscriber)
+	}
+
+	err = s.subscriptionRepo.SetSchedule(ctx, subscriber.SubscriberID, schedule)
+	if err != nil {
+		return errors.WrapInternal(err, "subscriptionService.SetSchedule.SetSchedule")
+	}
+
+	return nil
+}
+
+func (s *subscriptionService) HandleStart(ctx context.Context, telegramID int64, languageCode string) error {
+	subscriber, err := s.subscriptionRepo.GetSubscriber(ctx, telegramID)
+	if err != nil {
+		return errors.WrapInternal(err, "subscriptionService.HandleStart.GetSubscriber")
+	}
+
+	locale := string(messages.ParseLocale(languageCode))
+
+	// Remember user before they subscribe to know their locale
+	if subscriber == nil {
+		subscriber = domain.SubscriberFromTelegramID(telegramID)
+		subscriber.SetLocale(locale)
+
+		err = s.subscriptionRepo.InsertSubscriber(ctx, subscriber)
+		if err != nil {
+			return errors.WrapInternal(err, "subscriptionService.HandleStart.InsertSubscriber")
+		}
+
+		return nil
+	}
+
+	// User has unblocked the bot
+	if !subscriber.TelegramReachable() {
+		err = s.subscriptionRepo.SetTelegramReachable(ctx, telegramID, true)
+		if err != nil {
+			return errors.WrapInternal(err, "subscriptionService.HandleStart.SetTelegramReachable")
+		}
+	}
+
+	// Telegram client's language might have changed
+	if languageCode != "" && subscriber.Locale() != locale {
+		err = s.subscriptionRepo.SetLocale(ctx, subscriber.SubscriberID, locale)
+		if err != nil {
+			return errors.WrapInternal(err, "subscriptionService.HandleStart.SetLocale")
 		}
 	}
 
@@ -153,15 +422,25 @@ func (s *subscriptionService) GetURLFetcher() func(ctx context.Context) ([]strin
 	return s.getAllURLs
 }
 
-func (s *subscriptionService) handleUpdate(update *parser.ParseResult) error {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+func (s *subscriptionService) handleUpdate(ctx context.Context, update *parser.ParseResult) (err error) {
+	ctx, span := tracer.Start(ctx, "SubscriptionService.handleUpdate", trace.WithAttributes(attribute.String("url", update.URL())))
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
 	defer cancel()
 
 	advert, err := s.advertRepo.GetByURL(ctx, update.URL())
 	if err != nil {
-		return errors.WrapInternal(err, "subscriptionService.handleUpdate.GetByURL")
+		return errors.WrapInternal(err, "subscriptionService.handleUpdate.GetByURL").With("url", update.URL())
 	}
 
+	// Entries logged while notifying refer to the advert
+	ctx = logger.WithFields(ctx, logger.AdvertID(advert.AdvertID), logger.URL(update.URL()))
+	span.SetAttributes(attribute.String("advert_id", advert.AdvertID))
+
 	// Indicates if title of advert has updated (from empty title to normal)
 	var titleChanged bool
 
@@ -176,7 +455,8 @@ func (s *subscriptionService) handleUpdate(update *parser.ParseResult) error {
 		advert.UpdatePrice(update.Price())
 	}
 
-	fmt.Printf("update status:\n\tprice-[%t]\t\ntitle-[%t]\n", priceChanged, titleChanged)
+	s.log.WithContext(ctx).Debug("advert checked", logger.Bool("price_changed", priceChanged), logger.Bool("title_changed", titleChanged))
+	span.SetAttributes(attribute.Bool("price_changed", priceChanged), attribute.Bool("title_changed", titleChanged))
 
 	// If nothing has changed - ignore
 	if !priceChanged && !titleChanged {
@@ -195,7 +475,7 @@ func (s *subscriptionService) handleUpdate(update *parser.ParseResult) error {
 
 		err = s.advertRepo.Update(ctx, advert)
 		if err != nil {
-			return errors.WrapInternal(err, "subscriptionService.handleUpdate.Update")
+			return errors.WrapInternal(err, "subscriptionService.handleUpdate.Update").With("advert_id", advert.AdvertID)
 		}
 
 		return nil
@@ -203,14 +483,26 @@ func (s *subscriptionService) handleUpdate(update *parser.ParseResult) error {
 
 	err = s.advertRepo.Update(ctx, advert)
 	if err != nil {
-		return errors.WrapInternal(err, "subscriptionService.handleUpdate.Update")
+		return errors.WrapInternal(err, "subscriptionService.handleUpdate.Update").With("advert_id", advert.AdvertID)
 	}
 
-	err = s.NotifySubscribers(context.Background(), advert)
+	// Subscribers are notified even if history is not recorded
+	var recordErr error
+	if priceChanged && s.stream != nil {
+		recordErr = s.stream.RecordPriceChange(ctx, advert)
+	}
+
+	// Notifying takes longer than handling deadline allows.
+	// Trace and log fields are kept
+	err = s.NotifySubscribers(detach(ctx), advert)
 	if err != nil {
 		// NotifySubscribers is method that returns an ApplicationError
-		// so call errors.ChainInternal it for full errortrace
-		return errors.ChainInternal(err, "handleUpdate.NotifySubscribers")
+		// so call errors.Chain on it for full errortrace
+		return errors.Chain(err, "handleUpdate.NotifySubscribers")
+	}
+
+	if recordErr != nil {
+		return errors.Chain(recordErr, "handleUpdate.RecordPriceChange")
 	}
 
 	return nil
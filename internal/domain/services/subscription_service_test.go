@@ -1,7 +1,566 @@
 package services
 
-import "testing"
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	domain "parser/internal/domain/models"
+	"parser/internal/domain/repositories"
+	"parser/internal/http/dto"
+	"parser/internal/logger"
+	"parser/internal/messages"
+	"parser/internal/notify"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Embedded interfaces panic on methods tests don't expect to be called
+
+type fakeSubscriberRepo struct {
+	repositories.SubscriberRepository
+
+	subscribers []*domain.Subscriber
+	unreachable []int64
+
+	subscriptions []*domain.Subscription
+	muted         map[string]bool
+}
+
+func (f *fakeSubscriberRepo) GetSubscription(ctx context.Context, subscriberTelegramID int64, advertURL string) (*domain.Subscription, error) {
+	for _, subscription := range f.subscriptions {
+		advert := subscription.Advert()
+		if advert != nil && advert.URL() == advertURL {
+			return subscription, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (f *fakeSubscriberRepo) GetSubscriber(ctx context.Context, telegramID int64) (*domain.Subscriber, error) {
+	for _, subscriber := range f.subscribers {
+		if subscriber.TelegramID() == telegramID {
+			return subscriber, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (f *fakeSubscriberRepo) GetSubscriptionByAdvert(ctx context.Context, subscriberID, advertID string) (*domain.Subscription, error) {
+	for _, subscription := range f.subscriptions {
+		if subscription.SubscriberID == subscriberID && subscription.AdvertID == advertID {
+			return subscription, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (f *fakeSubscriberRepo) DeleteSubscription(ctx context.Context, subscriberID, advertID string) (bool, error) {
+	for i, subscription := range f.subscriptions {
+		if subscription.SubscriberID == subscriberID && subscription.AdvertID == advertID {
+			f.subscriptions = append(f.subscriptions[:i], f.subscriptions[i+1:]...)
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (f *fakeSubscriberRepo) CountAdvertSubscribers(ctx context.Context, advertID string) (int, error) {
+	var count int
+	for _, subscription := range f.subscriptions {
+		if subscription.AdvertID == advertID {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (f *fakeSubscriberRepo) SetSubscriptionMuted(ctx context.Context, subscriberID, advertID string, isMuted bool) (bool, error) {
+	if f.muted == nil {
+		f.muted = make(map[string]bool)
+	}
+
+	f.muted[subscriberID+"/"+advertID] = isMuted
+	return true, nil
+}
+
+func (f *fakeSubscriberRepo) SetSubscriptionChannels(ctx context.Context, subscriberID, advertID string, channels []domain.Channel) (bool, error) {
+	return true, nil
+}
+
+type fakeTargets struct {
+	removed []string
+}
+
+func (f *fakeTargets) AddTarget(url string) {}
+
+func (f *fakeTargets) RemoveTarget(url string) {
+	f.removed = append(f.removed, url)
+}
+
+func (f *fakeSubscriberRepo) GetAdvertSubscribers(ctx context.Context, advertID string) ([]*domain.Subscriber, error) {
+	return f.subscribers, nil
+}
+
+func (f *fakeSubscriberRepo) SetTelegramReachable(ctx context.Context, telegramID int64, reachable bool) error {
+	if !reachable {
+		f.unreachable = append(f.unreachable, telegramID)
+	}
+
+	return nil
+}
+
+type fakeWebhookRepo struct {
+	repositories.WebhookRepository
+
+	webhooks []*domain.Webhook
+}
+
+func (f *fakeWebhookRepo) GetAdvertWebhooks(ctx context.Context, advertID string) ([]*domain.Webhook, error) {
+	return f.webhooks, nil
+}
+
+type fakeNotificationRepo struct {
+	repositories.NotificationRepository
+
+	subscribers []*domain.Subscriber
+	pending     []*domain.PendingNotification
+	// subscriberID -> IDs of sent notifications
+	completed map[string][]string
+	deleted   []string
+
+	// subscriberID/advertID/channel -> fingerprint
+	delivered  map[string]string
+	suppressed int
+}
+
+func deliveryKey(subscriberID, advertID string, channel domain.Channel) string {
+	return subscriberID + "/" + advertID + "/" + string(channel)
+}
+
+func (f *fakeNotificationRepo) InsertPending(ctx context.Context, notification *domain.PendingNotification) error {
+	f.pending = append(f.pending, notification)
+	return nil
+}
+
+func (f *fakeNotificationRepo) GetPendingSubscribers(ctx context.Context) ([]*domain.Subscriber, error) {
+	return f.subscribers, nil
+}
+
+func (f *fakeNotificationRepo) GetPending(ctx context.Context, subscriberID string) ([]*domain.PendingNotification, error) {
+	var pending []*domain.PendingNotification
+	for _, notification := range f.pending {
+		if notification.SubscriberID == subscriberID {
+			pending = append(pending, notification)
+		}
+	}
+
+	return pending, nil
+}
+
+func (f *fakeNotificationRepo) CompleteDigest(ctx context.Context, subscriberID string, notificationIDs []string, sentAt time.Time) error {
+	if f.completed == nil {
+		f.completed = make(map[string][]string)
+	}
+
+	f.completed[subscriberID] = notificationIDs
+	return nil
+}
+
+func (f *fakeNotificationRepo) DeletePending(ctx context.Context, notificationIDs []string) error {
+	f.deleted = append(f.deleted, notificationIDs...)
+	return nil
+}
+
+func (f *fakeNotificationRepo) GetLastFingerprint(ctx context.Context, subscriberID, advertID string, channel domain.Channel) (string, error) {
+	return f.delivered[deliveryKey(subscriberID, advertID, channel)], nil
+}
+
+func (f *fakeNotificationRepo) RecordDelivery(ctx context.Context, subscriberID, advertID string, channel domain.Channel, fingerprint string, deliveredAt time.Time) error {
+	if f.delivered == nil {
+		f.delivered = make(map[string]string)
+	}
+
+	f.delivered[deliveryKey(subscriberID, advertID, channel)] = fingerprint
+	return nil
+}
+
+func (f *fakeNotificationRepo) RecordSuppression(ctx context.Context, subscriberID, advertID string, channel domain.Channel, suppressedAt time.Time) error {
+	f.suppressed++
+	return nil
+}
+
+// Safe for webhook queue workers. Context of delivery is not recorded
+type recordingNotifier struct {
+	mu   sync.Mutex
+	err  error
+	args [][]interface{}
+}
+
+func (rn *recordingNotifier) Notify(ad *domain.Advert, args ...interface{}) error {
+	if len(args) > 0 {
+		if _, ok := args[len(args)-1].(context.Context); ok {
+			args = args[:len(args)-1]
+		}
+	}
+
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+
+	rn.args = append(rn.args, args)
+	return rn.err
+}
 
 func TestHandleUpdate(t *testing.T) {
 
 }
+
+func TestNotifySubscribers(t *testing.T) {
+	ad := domain.NewAdvert("advert", "url", "title", 800, 1000, true)
+
+	t.Run("routes to subscriber and subscription channels", func(t *testing.T) {
+		// Uses subscriber's default channels
+		withDefaults := domain.NewSubscriber("sub-1", 1, "one@example.com", domain.DefaultChannels(), "en")
+		withDefaults.AddSubscription(domain.NewSubscription("sub-1", "advert"))
+
+		// Overrides channels for the advert
+		withOverride := domain.NewSubscriber("sub-2", 2, "two@example.com", domain.DefaultChannels(), "ru")
+		subscription := domain.NewSubscription("sub-2", "advert")
+		subscription.OverrideChannels([]domain.Channel{domain.ChannelEmail})
+		withOverride.AddSubscription(subscription)
+
+		telegram, email, webhook := new(recordingNotifier), new(recordingNotifier), new(recordingNotifier)
+
+		mux := notify.NewMultiplexer()
+		mux.Register(domain.ChannelTelegram, telegram)
+		mux.Register(domain.ChannelEmail, email)
+		mux.Register(domain.ChannelWebhook, webhook)
+
+		service := NewSubscriptionService(
+			&fakeSubscriberRepo{subscribers: []*domain.Subscriber{withDefaults, withOverride}},
+			nil,
+			&fakeWebhookRepo{webhooks: []*domain.Webhook{
+				domain.NewWebhook("wh-1", "sub-1", "http://one", "secret-1", true, 0),
+				domain.NewWebhook("wh-2", "sub-2", "http://two", "secret-2", true, 0),
+			}},
+			new(fakeNotificationRepo),
+			mux,
+			newRenderer(t),
+			nil,
+			10,
+			0,
+			nil,
+			logger.Nop(),
+		)
+
+		err := service.NotifySubscribers(context.Background(), ad)
+		require.NoError(t, err)
+
+		require.Len(t, telegram.args, 1)
+		require.Equal(t, int64(1), telegram.args[0][0])
+		// Rendered in subscriber's locale
+		require.Contains(t, telegram.args[0][1], "Price changed!")
+
+		require.Len(t, email.args, 2)
+		require.Equal(t, []interface{}{"one@example.com", "Price changed: title"}, email.args[0][:2])
+		require.Contains(t, email.args[0][2], "Price changed!")
+		require.Equal(t, []interface{}{"two@example.com", "Цена изменилась: title"}, email.args[1][:2])
+		require.Contains(t, email.args[1][2], "Цена изменилась!")
+		// Webhooks are delivered by queue
+		service.Close()
+		require.Equal(t, [][]interface{}{{"http://one", "secret-1"}}, webhook.args)
+	})
+
+	t.Run("skips disabled channels and deactivates unreachable", func(t *testing.T) {
+		unreachable := domain.NewSubscriber("sub-1", 1, "one@example.com", domain.DefaultChannels(), "en")
+		reachable := domain.NewSubscriber("sub-2", 2, "", []domain.Channel{domain.ChannelEmail, domain.ChannelTelegram}, "ru")
+
+		subscriberRepo := &fakeSubscriberRepo{subscribers: []*domain.Subscriber{unreachable, reachable}}
+
+		// Email is not registered
+		telegram := &recordingNotifier{}
+		mux := notify.NewMultiplexer()
+		mux.Register(domain.ChannelTelegram, &unreachableNotifier{telegramID: 1, next: telegram})
+
+		service := NewSubscriptionService(subscriberRepo, nil, new(fakeWebhookRepo), new(fakeNotificationRepo), mux, newRenderer(t), nil, 10, 0, nil, logger.Nop())
+
+		err := service.NotifySubscribers(context.Background(), ad)
+		require.NoError(t, err)
+
+		require.Equal(t, []int64{1}, subscriberRepo.unreachable)
+		require.Len(t, telegram.args, 1)
+		require.Equal(t, int64(2), telegram.args[0][0])
+	})
+
+	t.Run("keeps notifying unreachable in telegram by other channels", func(t *testing.T) {
+		// Bot has just been blocked
+		blocking := domain.NewSubscriber("sub-1", 1, "one@example.com", domain.DefaultChannels(), "en")
+		// Blocked the bot earlier
+		blocked := domain.NewSubscriber("sub-2", 2, "two@example.com", domain.DefaultChannels(), "en")
+		blocked.SetTelegramReachable(false)
+
+		subscriberRepo := &fakeSubscriberRepo{subscribers: []*domain.Subscriber{blocking, blocked}}
+
+		telegram, email, webhook := new(recordingNotifier), new(recordingNotifier), new(recordingNotifier)
+		mux := notify.NewMultiplexer()
+		mux.Register(domain.ChannelTelegram, &unreachableNotifier{telegramID: 1, next: telegram})
+		mux.Register(domain.ChannelEmail, email)
+		mux.Register(domain.ChannelWebhook, webhook)
+
+		webhooks := &fakeWebhookRepo{webhooks: []*domain.Webhook{
+			domain.NewWebhook("wh-1", "sub-1", "http://one", "secret-1", true, 0),
+		}}
+
+		service := NewSubscriptionService(subscriberRepo, nil, webhooks, new(fakeNotificationRepo), mux, newRenderer(t), nil, 10, 0, nil, logger.Nop())
+
+		err := service.NotifySubscribers(context.Background(), ad)
+		require.NoError(t, err)
+
+		require.Equal(t, []int64{1}, subscriberRepo.unreachable)
+		require.Empty(t, telegram.args)
+		require.Len(t, email.args, 2)
+		require.Equal(t, "one@example.com", email.args[0][0])
+		require.Equal(t, "two@example.com", email.args[1][0])
+		// Webhooks are delivered by queue
+		service.Close()
+		require.Equal(t, [][]interface{}{{"http://one", "secret-1"}}, webhook.args)
+	})
+
+	t.Run("holds telegram alerts until digest", func(t *testing.T) {
+		schedule, err := domain.NewSchedule("", nil, domain.DigestHourly)
+		require.NoError(t, err)
+
+		subscriber := domain.NewSubscriber("sub-1", 1, "one@example.com", []domain.Channel{domain.ChannelTelegram, domain.ChannelEmail}, "en")
+		subscriber.SetSchedule(schedule)
+
+		telegram, email := new(recordingNotifier), new(recordingNotifier)
+		mux := notify.NewMultiplexer()
+		mux.Register(domain.ChannelTelegram, telegram)
+		mux.Register(domain.ChannelEmail, email)
+
+		notificationRepo := new(fakeNotificationRepo)
+		service := NewSubscriptionService(
+			&fakeSubscriberRepo{subscribers: []*domain.Subscriber{subscriber}},
+			nil,
+			new(fakeWebhookRepo),
+			notificationRepo,
+			mux,
+			newRenderer(t),
+			nil,
+			10,
+			0,
+			nil,
+			logger.Nop(),
+		)
+
+		err = service.NotifySubscribers(context.Background(), ad)
+		require.NoError(t, err)
+
+		require.Empty(t, telegram.args)
+		// Email is not held
+		require.Len(t, email.args, 1)
+
+		require.Len(t, notificationRepo.pending, 1)
+		require.Equal(t, "sub-1", notificationRepo.pending[0].SubscriberID)
+		require.Equal(t, float64(1000), notificationRepo.pending[0].OldPrice())
+		require.Equal(t, float64(800), notificationRepo.pending[0].NewPrice())
+		require.Equal(t, domain.PendingDigest, notificationRepo.pending[0].Kind())
+	})
+
+	t.Run("holds telegram alerts for coalescing window", func(t *testing.T) {
+		subscriber := domain.NewSubscriber("sub-1", 1, "", []domain.Channel{domain.ChannelTelegram}, "en")
+
+		telegram := new(recordingNotifier)
+		mux := notify.NewMultiplexer()
+		mux.Register(domain.ChannelTelegram, telegram)
+
+		notificationRepo := new(fakeNotificationRepo)
+		service := NewSubscriptionService(
+			&fakeSubscriberRepo{subscribers: []*domain.Subscriber{subscriber}},
+			nil,
+			new(fakeWebhookRepo),
+			notificationRepo,
+			mux,
+			newRenderer(t),
+			nil,
+			10,
+			time.Minute,
+			nil,
+			logger.Nop(),
+		)
+
+		err := service.NotifySubscribers(context.Background(), ad)
+		require.NoError(t, err)
+
+		require.Empty(t, telegram.args)
+		require.Len(t, notificationRepo.pending, 1)
+		require.Equal(t, domain.PendingCoalesce, notificationRepo.pending[0].Kind())
+	})
+
+	t.Run("suppresses repeated alerts", func(t *testing.T) {
+		subscriber := domain.NewSubscriber("sub-1", 1, "one@example.com", []domain.Channel{domain.ChannelTelegram, domain.ChannelEmail}, "en")
+
+		telegram, email := new(recordingNotifier), new(recordingNotifier)
+		mux := notify.NewMultiplexer()
+		mux.Register(domain.ChannelTelegram, telegram)
+		mux.Register(domain.ChannelEmail, email)
+
+		notificationRepo := new(fakeNotificationRepo)
+		service := NewSubscriptionService(
+			&fakeSubscriberRepo{subscribers: []*domain.Subscriber{subscriber}},
+			nil,
+			new(fakeWebhookRepo),
+			notificationRepo,
+			mux,
+			newRenderer(t),
+			nil,
+			10,
+			0,
+			nil,
+			logger.Nop(),
+		)
+
+		// The same change is handled twice
+		for i := 0; i < 2; i++ {
+			err := service.NotifySubscribers(context.Background(), ad)
+			require.NoError(t, err)
+		}
+
+		require.Len(t, telegram.args, 1)
+		require.Len(t, email.args, 1)
+		require.Equal(t, 2, notificationRepo.suppressed)
+		require.Equal(t, "1000->800", notificationRepo.delivered[deliveryKey("sub-1", "advert", domain.ChannelTelegram)])
+	})
+}
+
+func TestManageSubscriptions(t *testing.T) {
+	const advertID = "6f1c9a4e-8b1e-4c8e-9a53-0c3f4c1a2b7d"
+
+	newRepo := func() *fakeSubscriberRepo {
+		subscription := func(subscriberID string) *domain.Subscription {
+			subscription := domain.NewSubscription(subscriberID, advertID)
+			subscription.AttachAdvert(domain.NewAdvert(advertID, "https://www.avito.ru/1", "iPhone", 800, 1000, true))
+			return subscription
+		}
+
+		return &fakeSubscriberRepo{
+			subscribers: []*domain.Subscriber{
+				domain.NewSubscriber("sub-1", 1, "", domain.DefaultChannels(), "en"),
+				domain.NewSubscriber("sub-2", 2, "", domain.DefaultChannels(), "en"),
+			},
+			subscriptions: []*domain.Subscription{subscription("sub-1"), subscription("sub-2")},
+		}
+	}
+
+	newService := func(repo *fakeSubscriberRepo, targets *fakeTargets) SubscriptionService {
+		return NewSubscriptionService(repo, nil, new(fakeWebhookRepo), new(fakeNotificationRepo), notify.NewMultiplexer(), newRenderer(t), targets, 10, 0, nil, logger.Nop())
+	}
+
+	t.Run("removes target once advert loses last subscriber", func(t *testing.T) {
+		repo, targets := newRepo(), new(fakeTargets)
+		service := newService(repo, targets)
+
+		err := service.DeleteSubscription(context.Background(), 1, advertID)
+		require.NoError(t, err)
+		require.Empty(t, targets.removed)
+
+		err = service.DeleteSubscription(context.Background(), 2, advertID)
+		require.NoError(t, err)
+		require.Equal(t, []string{"https://www.avito.ru/1"}, targets.removed)
+
+		// Already deleted
+		err = service.DeleteSubscription(context.Background(), 2, advertID)
+		require.ErrorContains(t, err, domain.ErrNoSubscription.Error())
+	})
+
+	t.Run("rejects unknown subscriptions", func(t *testing.T) {
+		service := newService(newRepo(), new(fakeTargets))
+
+		_, err := service.GetSubscription(context.Background(), 1, "not-uuid")
+		require.ErrorContains(t, err, domain.ErrNoSubscription.Error())
+
+		_, err = service.GetSubscription(context.Background(), 3, advertID)
+		require.ErrorContains(t, err, domain.ErrNoSubscriber.Error())
+
+		_, _, err = service.ListSubscriptions(context.Background(), 1, domain.MaxPageLimit+1, 0)
+		require.ErrorContains(t, err, domain.ErrInvalidPage.Error())
+	})
+
+	t.Run("updates alert settings", func(t *testing.T) {
+		repo := newRepo()
+		service := newService(repo, new(fakeTargets))
+
+		muted := true
+		subscription, err := service.UpdateSubscription(context.Background(), 1, advertID, &dto.UpdateSubscriptionRequest{
+			Channels: []string{"email"},
+			Muted:    &muted,
+		})
+		require.NoError(t, err)
+
+		require.True(t, subscription.IsMuted())
+		require.Equal(t, []domain.Channel{domain.ChannelEmail}, subscription.Channels())
+		require.Equal(t, map[string]bool{"sub-1/" + advertID: true}, repo.muted)
+
+		subscription, err = service.UpdateSubscription(context.Background(), 1, advertID, &dto.UpdateSubscriptionRequest{
+			ResetChannels: true,
+		})
+		require.NoError(t, err)
+		require.False(t, subscription.HasChannels())
+	})
+}
+
+func TestNewSubscription(t *testing.T) {
+	subscription := domain.NewSubscription("sub-1", "advert-1")
+	subscription.AttachAdvert(domain.NewAdvert("advert-1", "https://www.avito.ru/moskva/telefony/iphone_123", "iPhone", 800, 1000, true))
+
+	repo := &fakeSubscriberRepo{subscriptions: []*domain.Subscription{subscription}}
+	service := NewSubscriptionService(repo, nil, new(fakeWebhookRepo), new(fakeNotificationRepo), notify.NewMultiplexer(), newRenderer(t), new(fakeTargets), 10, 0, nil, logger.Nop())
+
+	t.Run("rejects invalid urls", func(t *testing.T) {
+		for url, want := range map[string]error{
+			"ftp://avito.ru/moskva/telefony/iphone_123":    domain.ErrInvalidURL,
+			"https://example.com/moskva/telefony/iphone_1": domain.ErrUnsupportedHost,
+			"https://avito.ru/moskva/telefony":             domain.ErrNoItemID,
+		} {
+			err := service.NewSubscription(context.Background(), &dto.SubscribeRequest{TelegramID: 1, AdvertURL: url})
+			require.ErrorContains(t, err, want.Error(), url)
+		}
+	})
+
+	t.Run("looks up subscriptions by canonical url", func(t *testing.T) {
+		for _, url := range []string{
+			"avito.ru/moskva/telefony/iphone_123",
+			"http://m.avito.ru/moskva/telefony/iphone_123/?utm_source=tg",
+		} {
+			err := service.NewSubscription(context.Background(), &dto.SubscribeRequest{TelegramID: 1, AdvertURL: url})
+			require.ErrorContains(t, err, domain.ErrSubscriptionExist.Error(), url)
+		}
+	})
+}
+
+func newRenderer(t *testing.T) *messages.Renderer {
+	renderer, err := messages.NewRenderer(&messages.Options{DefaultLocale: messages.LocaleRU})
+	require.NoError(t, err)
+
+	return renderer
+}
+
+// Returns notify.ErrRecipientUnreachable for telegramID
+type unreachableNotifier struct {
+	telegramID int64
+	next       notify.Notifier
+}
+
+func (un *unreachableNotifier) Notify(ad *domain.Advert, args ...interface{}) error {
+	if args[0] == un.telegramID {
+		return notify.ErrRecipientUnreachable
+	}
+
+	return un.next.Notify(ad, args...)
+}
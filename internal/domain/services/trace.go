@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	goerrors "errors"
+	domain "parser/internal/domain/models"
+	"parser/internal/notify"
+	"parser/internal/tracing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// deliver sends notification within span of its own.
+// Notifiers don't take context, so span can't be started by them
+func deliver(ctx context.Context, notifier notify.Notifier, ad *domain.Advert, channel domain.Channel, args ...interface{}) error {
+	_, span := tracer.Start(ctx, "Notifier.Notify",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("channel", string(channel))),
+	)
+	defer span.End()
+
+	err := notifier.Notify(ad, append([]interface{}{channel}, args...)...)
+	// Disabled channel is not a failure
+	if !goerrors.Is(err, notify.ErrChannelDisabled) {
+		tracing.RecordError(span, err)
+	}
+
+	return err
+}
+
+// detach returns context with values of ctx, e.g. trace and log fields,
+// that is not cancelled along with ctx
+func detach(ctx context.Context) context.Context {
+	return detachedContext{parent: ctx}
+}
+
+type detachedContext struct {
+	parent context.Context
+}
+
+func (d detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (d detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (d detachedContext) Err() error {
+	return nil
+}
+
+func (d detachedContext) Value(key interface{}) interface{} {
+	return d.parent.Value(key)
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"sync"
+)
+
+// urlLocks serializes changes of the same advert, e.g. its last subscription
+// being deleted while new one is added. Otherwise the advert could be removed
+// from parsing after it got new subscriber
+type urlLocks struct {
+	mu    sync.Mutex
+	locks map[string]*urlLock
+}
+
+type urlLock struct {
+	sync.Mutex
+	// Holders and waiters, lock is dropped once nobody needs it
+	refs int
+}
+
+// Lock blocks until url is free and returns func releasing it
+func (l *urlLocks) Lock(url string) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*urlLock)
+	}
+
+	lock, ok := l.locks[url]
+	if !ok {
+		lock = new(urlLock)
+		l.locks[url] = lock
+	}
+
+	lock.refs++
+	l.mu.Unlock()
+
+	lock.Lock()
+
+	return func() {
+		lock.Unlock()
+
+		l.mu.Lock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(l.locks, url)
+		}
+		l.mu.Unlock()
+	}
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUrlLocks(t *testing.T) {
+	var locks urlLocks
+
+	unlock := locks.Lock("https://www.avito.ru/1")
+
+	// Other urls are not blocked
+	locks.Lock("https://www.avito.ru/2")()
+
+	locked := make(chan struct{})
+	go func() {
+		defer locks.Lock("https://www.avito.ru/1")()
+		close(locked)
+	}()
+
+	select {
+	case <-locked:
+		t.Fatal("url was locked twice")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	unlock()
+	<-locked
+
+	// Released locks are dropped
+	require.Eventually(t, func() bool {
+		locks.mu.Lock()
+		defer locks.mu.Unlock()
+
+		return len(locks.locks) == 0
+	}, time.Second, time.Millisecond)
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	domain "parser/internal/domain/models"
+	"sync"
+	"time"
+)
+
+const (
+	// Events waiting for delivery. Newer ones are dropped once queue is full
+	webhookQueueSize = 256
+	webhookWorkers   = 4
+	// Bounds every attempt of delivering one event to one webhook, including backoff
+	webhookDeliveryTimeout = 30 * time.Second
+)
+
+// Price change to deliver to webhooks of one subscriber
+type webhookJob struct {
+	// Carries trace and log fields, not cancelled with update
+	ctx      context.Context
+	ad       *domain.Advert
+	webhooks []*domain.Webhook
+}
+
+// webhookQueue delivers webhooks off the update path,
+// so slow or dead receivers don't hold up other adverts
+type webhookQueue struct {
+	jobs    chan *webhookJob
+	deliver func(job *webhookJob)
+
+	// Protects jobs from being sent to after close
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+func newWebhookQueue(deliver func(job *webhookJob)) *webhookQueue {
+	q := &webhookQueue{
+		jobs:    make(chan *webhookJob, webhookQueueSize),
+		deliver: deliver,
+	}
+
+	q.wg.Add(webhookWorkers)
+	for i := 0; i < webhookWorkers; i++ {
+		go func() {
+			defer q.wg.Done()
+
+			for job := range q.jobs {
+				q.deliver(job)
+			}
+		}()
+	}
+
+	return q
+}
+
+// push returns false if job is dropped, e.g. queue is full or closed
+func (q *webhookQueue) push(job *webhookJob) bool {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return false
+	}
+
+	select {
+	case q.jobs <- job:
+		return true
+	default:
+		return false
+	}
+}
+
+// close stops accepting jobs and waits for queued ones to be delivered
+func (q *webhookQueue) close() {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.jobs)
+	}
+	q.mu.Unlock()
+
+	q.wg.Wait()
+}
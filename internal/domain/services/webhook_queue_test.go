@@ -0,0 +1,39 @@
+package services
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookQueue(t *testing.T) {
+	release := make(chan struct{})
+	var delivered int32
+
+	q := newWebhookQueue(func(job *webhookJob) {
+		<-release
+		atomic.AddInt32(&delivered, 1)
+	})
+
+	job := &webhookJob{ctx: context.Background()}
+
+	// Workers are busy, the rest waits in queue until it's full
+	var accepted int
+	for i := 0; i < webhookWorkers+webhookQueueSize+10; i++ {
+		if q.push(job) {
+			accepted++
+		}
+	}
+
+	require.GreaterOrEqual(t, accepted, webhookQueueSize)
+	require.Less(t, accepted, webhookWorkers+webhookQueueSize+10)
+
+	close(release)
+	q.close()
+
+	// Queued jobs are delivered before close returns
+	require.Equal(t, int32(accepted), atomic.LoadInt32(&delivered))
+	require.False(t, q.push(job))
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	domain "parser/internal/domain/models"
+	"parser/internal/domain/repositories"
+	"parser/internal/errors"
+	"parser/internal/http/dto"
+)
+
+type WebhookService interface {
+	Register(ctx context.Context, dto *dto.WebhookRequest) (*domain.Webhook, error)
+	List(ctx context.Context, telegramID int64) ([]*domain.Webhook, error)
+	Delete(ctx context.Context, telegramID int64, webhookID string) error
+}
+
+type webhookService struct {
+	subscriptionRepo repositories.SubscriberRepository
+	webhookRepo      repositories.WebhookRepository
+}
+
+func NewWebhookService(
+	subscriptionRepo repositories.SubscriberRepository,
+	webhookRepo repositories.WebhookRepository) WebhookService {
+	return &webhookService{
+		subscriptionRepo: subscriptionRepo,
+		webhookRepo:      webhookRepo,
+	}
+}
+
+func (s *webhookService) Register(ctx context.Context, dto *dto.WebhookRequest) (*domain.Webhook, error) {
+	subscriber, err := s.getSubscriber(ctx, dto.TelegramID)
+	if err != nil {
+		return nil, errors.Chain(err, "webhookService.Register")
+	}
+
+	webhook, err := domain.NewEmptyWebhook(subscriber.SubscriberID, dto.URL)
+	if err != nil {
+		return nil, errors.WrapDomain(err)
+	}
+
+	err = s.webhookRepo.Insert(ctx, webhook)
+	if err != nil {
+		return nil, errors.WrapInternal(err, "webhookService.Register.Insert")
+	}
+
+	return webhook, nil
+}
+
+func (s *webhookService) List(ctx context.Context, telegramID int64) ([]*domain.Webhook, error) {
+	subscriber, err := s.getSubscriber(ctx, telegramID)
+	if err != nil {
+		return nil, errors.Chain(err, "webhookService.List")
+	}
+
+	webhooks, err := s.webhookRepo.GetSubscriberWebhooks(ctx, subscriber.SubscriberID)
+	if err != nil {
+		return nil, errors.WrapInternal(err, "webhookService.List.GetSubscriberWebhooks")
+	}
+
+	return webhooks, nil
+}
+
+func (s *webhookService) Delete(ctx context.Context, telegramID int64, webhookID string) error {
+	subscriber, err := s.getSubscriber(ctx, telegramID)
+	if err != nil {
+		return errors.Chain(err, "webhookService.Delete")
+	}
+
+	ok, err := s.webhookRepo.Delete(ctx, subscriber.SubscriberID, webhookID)
+	if err != nil {
+		return errors.WrapInternal(err, "webhookService.Delete.Delete")
+	}
+
+	if !ok {
+		return errors.WrapDomain(domain.ErrNoWebhook)
+	}
+
+	return nil
+}
+
+// Webhooks could be managed only by existing subscribers
+func (s *webhookService) getSubscriber(ctx context.Context, telegramID int64) (*domain.Subscriber, error) {
+	subscriber, err := s.subscriptionRepo.GetSubscriber(ctx, telegramID)
+	if err != nil {
+		return nil, errors.WrapInternal(err, "getSubscriber.GetSubscriber")
+	}
+
+	if subscriber == nil {
+		return nil, errors.WrapDomain(domain.ErrNoSubscriber)
+	}
+
+	return subscriber, nil
+}
@@ -0,0 +1,35 @@
+package email
+
+import (
+	"errors"
+	"net/mail"
+)
+
+var (
+	ErrNoRecipient    = errors.New("recipient must not be empty")
+	ErrInvalidAddress = errors.New("invalid email address")
+)
+
+// Message is sent as multipart/alternative.
+// Clients that can't render HTML show Text
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+type Mailer interface {
+	Send(msg *Message) error
+}
+
+// ParseAddress returns bare address from RFC 5322 address.
+// e.g. "Tracker <tracker@example.com>" -> tracker@example.com
+func ParseAddress(address string) (string, error) {
+	addr, err := mail.ParseAddress(address)
+	if err != nil {
+		return "", ErrInvalidAddress
+	}
+
+	return addr.Address, nil
+}
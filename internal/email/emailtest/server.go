@@ -0,0 +1,166 @@
+// Package emailtest provides fake SMTP server for tests.
+package emailtest
+
+import (
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+)
+
+// Message is received by Server
+type Message struct {
+	From string
+	To   []string
+	// Raw message including headers
+	Data []byte
+}
+
+// Server is a fake SMTP server listening on loopback.
+// It accepts every message and keeps it in memory
+type Server struct {
+	Addr string
+
+	ln net.Listener
+	wg *sync.WaitGroup
+
+	mu       *sync.Mutex
+	messages []*Message
+}
+
+func NewServer() (*Server, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		Addr:     ln.Addr().String(),
+		ln:       ln,
+		wg:       new(sync.WaitGroup),
+		mu:       new(sync.Mutex),
+		messages: make([]*Message, 0),
+	}
+
+	s.wg.Add(1)
+	go s.serve()
+
+	return s, nil
+}
+
+// Messages returns copy of received messages
+func (s *Server) Messages() []*Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	messages := make([]*Message, len(s.messages))
+	copy(messages, s.messages)
+
+	return messages
+}
+
+func (s *Server) Close() error {
+	err := s.ln.Close()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+		}()
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	tc := textproto.NewConn(conn)
+	defer tc.Close()
+
+	if err := tc.PrintfLine("220 localhost fake ESMTP"); err != nil {
+		return
+	}
+
+	var msg *Message
+	for {
+		line, err := tc.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb, arg := line, ""
+		if i := strings.IndexByte(line, ' '); i != -1 {
+			verb, arg = line[:i], line[i+1:]
+		}
+
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			err = tc.PrintfLine("250 localhost")
+		case "MAIL":
+			msg = &Message{From: trimPath(arg, "FROM:")}
+			err = tc.PrintfLine("250 OK")
+		case "RCPT":
+			if msg == nil {
+				err = tc.PrintfLine("503 need MAIL first")
+				break
+			}
+			msg.To = append(msg.To, trimPath(arg, "TO:"))
+			err = tc.PrintfLine("250 OK")
+		case "DATA":
+			if msg == nil || len(msg.To) == 0 {
+				err = tc.PrintfLine("503 need RCPT first")
+				break
+			}
+			if err = tc.PrintfLine("354 end data with <CR><LF>.<CR><LF>"); err != nil {
+				return
+			}
+			msg.Data, err = tc.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			msg = nil
+			err = tc.PrintfLine("250 OK")
+		case "RSET":
+			msg = nil
+			err = tc.PrintfLine("250 OK")
+		case "NOOP":
+			err = tc.PrintfLine("250 OK")
+		case "QUIT":
+			_ = tc.PrintfLine("221 bye")
+			return
+		default:
+			err = tc.PrintfLine("502 command not implemented")
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// trimPath turns "FROM:<a@b.c>" into "a@b.c"
+func trimPath(arg, prefix string) string {
+	arg = strings.TrimSpace(arg)
+	if len(arg) >= len(prefix) && strings.EqualFold(arg[:len(prefix)], prefix) {
+		arg = arg[len(prefix):]
+	}
+
+	// Drop ESMTP params like BODY=8BITMIME
+	if i := strings.IndexByte(arg, ' '); i != -1 {
+		arg = arg[:i]
+	}
+
+	return strings.Trim(arg, "<>")
+}
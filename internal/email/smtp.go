@@ -0,0 +1,184 @@
+package email
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"time"
+)
+
+const (
+	defaultDialTimeout = time.Second * 10
+)
+
+type SMTPOptions struct {
+	// e.g. smtp.example.com:587
+	Addr string
+	// Address in From header, e.g. "Avito Tracker <tracker@example.com>"
+	From string
+
+	// Leave empty if server doesn't require authentication
+	Username string
+	Password string
+
+	DialTimeout time.Duration
+}
+
+type smtpMailer struct {
+	addr        string
+	host        string
+	from        string
+	auth        smtp.Auth
+	dialTimeout time.Duration
+}
+
+func NewSMTPMailer(opts *SMTPOptions) (Mailer, error) {
+	host, _, err := net.SplitHostPort(opts.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid smtp address: %w", err)
+	}
+
+	var auth smtp.Auth
+	if opts.Username != "" {
+		auth = smtp.PlainAuth("", opts.Username, opts.Password, host)
+	}
+
+	dialTimeout := opts.DialTimeout
+	if dialTimeout == 0 {
+		dialTimeout = defaultDialTimeout
+	}
+
+	return &smtpMailer{
+		addr:        opts.Addr,
+		host:        host,
+		from:        opts.From,
+		auth:        auth,
+		dialTimeout: dialTimeout,
+	}, nil
+}
+
+func (m *smtpMailer) Send(msg *Message) error {
+	if msg.To == "" {
+		return ErrNoRecipient
+	}
+
+	body, err := m.build(msg)
+	if err != nil {
+		return fmt.Errorf("unable to build message: %w", err)
+	}
+
+	// smtp.SendMail has no timeout so dial by hand
+	conn, err := net.DialTimeout("tcp", m.addr, m.dialTimeout)
+	if err != nil {
+		return fmt.Errorf("unable to dial smtp: %w", err)
+	}
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("unable to greet smtp: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.host, MinVersion: tls.VersionTLS12}); err != nil {
+			return fmt.Errorf("starttls: %w", err)
+		}
+	}
+
+	if m.auth != nil {
+		if err := client.Auth(m.auth); err != nil {
+			return fmt.Errorf("auth: %w", err)
+		}
+	}
+
+	from, err := ParseAddress(m.from)
+	if err != nil {
+		return fmt.Errorf("invalid from address: %w", err)
+	}
+
+	if err := client.Mail(from); err != nil {
+		return fmt.Errorf("mail from: %w", err)
+	}
+
+	if err := client.Rcpt(msg.To); err != nil {
+		return fmt.Errorf("rcpt to: %w", err)
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("data: %w", err)
+	}
+
+	if _, err := w.Write(body); err != nil {
+		return fmt.Errorf("data write: %w", err)
+	}
+
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("data close: %w", err)
+	}
+
+	return client.Quit()
+}
+
+func (m *smtpMailer) build(msg *Message) ([]byte, error) {
+	var buff bytes.Buffer
+
+	mw := multipart.NewWriter(&buff)
+
+	// Top-level headers
+	header := make(textproto.MIMEHeader)
+	header.Set("From", m.from)
+	header.Set("To", msg.To)
+	header.Set("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header.Set("Date", time.Now().Format(time.RFC1123Z))
+	header.Set("MIME-Version", "1.0")
+	header.Set("Content-Type", "multipart/alternative; boundary="+mw.Boundary())
+
+	for k, vs := range header {
+		for _, v := range vs {
+			fmt.Fprintf(&buff, "%s: %s\r\n", k, v)
+		}
+	}
+	buff.WriteString("\r\n")
+
+	// Order matters: clients prefer the last alternative they can display
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", msg.Text},
+		{"text/html; charset=UTF-8", msg.HTML},
+	}
+
+	for _, part := range parts {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		qw := quotedprintable.NewWriter(pw)
+		if _, err := qw.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+
+		if err := qw.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buff.Bytes(), nil
+}
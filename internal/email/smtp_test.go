@@ -0,0 +1,85 @@
+package email
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"testing"
+
+	"parser/internal/email/emailtest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSMTPMailer(t *testing.T) {
+	srv, err := emailtest.NewServer()
+	require.NoError(t, err)
+	defer srv.Close()
+
+	mailer, err := NewSMTPMailer(&SMTPOptions{
+		Addr: srv.Addr,
+		From: "Avito Tracker <tracker@example.com>",
+	})
+	require.NoError(t, err)
+
+	err = mailer.Send(&Message{
+		To:      "user@example.com",
+		Subject: "Цена изменилась",
+		Text:    "plain text",
+		HTML:    "<p>html</p>",
+	})
+	require.NoError(t, err)
+
+	messages := srv.Messages()
+	require.Len(t, messages, 1)
+	require.Equal(t, "tracker@example.com", messages[0].From)
+	require.Equal(t, []string{"user@example.com"}, messages[0].To)
+
+	msg, err := mail.ReadMessage(bytes.NewReader(messages[0].Data))
+	require.NoError(t, err)
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	require.Equal(t, "Цена изменилась", subject)
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/alternative", mediaType)
+
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+
+	expected := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", "plain text"},
+		{"text/html; charset=UTF-8", "<p>html</p>"},
+	}
+
+	for _, e := range expected {
+		part, err := mr.NextPart()
+		require.NoError(t, err)
+		require.Equal(t, e.contentType, part.Header.Get("Content-Type"))
+
+		// multipart.Reader decodes quoted-printable transparently
+		content, err := io.ReadAll(part)
+		require.NoError(t, err)
+		require.Equal(t, e.content, string(content))
+	}
+
+	_, err = mr.NextPart()
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestSMTPMailerNoRecipient(t *testing.T) {
+	mailer, err := NewSMTPMailer(&SMTPOptions{
+		Addr: "127.0.0.1:25",
+		From: "tracker@example.com",
+	})
+	require.NoError(t, err)
+
+	err = mailer.Send(&Message{Subject: "no recipient"})
+	require.ErrorIs(t, err, ErrNoRecipient)
+}
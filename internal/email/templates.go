@@ -0,0 +1,52 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+const (
+	// Localized alert rendered by caller, see AlertData
+	TemplateAlert        = "alert"
+	TemplateConfirmEmail = "confirm_email"
+)
+
+//go:embed templates
+var templatesFS embed.FS
+
+var (
+	textTemplates = texttemplate.Must(texttemplate.ParseFS(templatesFS, "templates/*.txt"))
+	htmlTemplates = htmltemplate.Must(htmltemplate.ParseFS(templatesFS, "templates/*.html"))
+)
+
+// AlertData is passed to TemplateAlert
+type AlertData struct {
+	Text string
+}
+
+// Lines of text are paragraphs of HTML version
+func (ad *AlertData) Lines() []string {
+	return strings.Split(ad.Text, "\n")
+}
+
+// Render executes both plain-text and HTML versions of template with data.
+// name is template name without extension, e.g. TemplateAlert
+func Render(name string, data interface{}) (text string, html string, err error) {
+	var textBuff, htmlBuff bytes.Buffer
+
+	err = textTemplates.ExecuteTemplate(&textBuff, name+".txt", data)
+	if err != nil {
+		return "", "", fmt.Errorf("render %s.txt: %w", name, err)
+	}
+
+	err = htmlTemplates.ExecuteTemplate(&htmlBuff, name+".html", data)
+	if err != nil {
+		return "", "", fmt.Errorf("render %s.html: %w", name, err)
+	}
+
+	return textBuff.String(), htmlBuff.String(), nil
+}
@@ -1,5 +1,21 @@
+// Package errors classifies errors of application.
+//
+// Domain sentinels are declared with Define, so errors wrapping them know
+// their kind and code. Services wrap failures with WrapInternal and add
+// operation name with Chain as error goes up, e.g. "handleUpdate.advertRepo.Update".
+// Wrapped error stays reachable with errors.Is and errors.As of std library
 package errors
 
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// Amount of caller frames captured by constructors
+const maxFrames = 32
+
 // ErrKind is enum
 // To prevent from ErrKind("some random error")...
 type ErrKind struct {
@@ -7,58 +23,230 @@ type ErrKind struct {
 }
 
 var (
+	// Failure of the application itself, e.g. database is down
 	InternalKind = ErrKind{"internal"}
-
-	// Not sure about this yet...
+	// Request breaks business rule
 	DomainKind = ErrKind{"domain"}
+	// Input is malformed
+	ValidationKind = ErrKind{"validation"}
+	// Requested entity doesn't exist
+	NotFoundKind = ErrKind{"not_found"}
+	// Entity already exists or is in conflicting state
+	ConflictKind = ErrKind{"conflict"}
+	// Feature is disabled or dependency is temporarily unreachable
+	UnavailableKind = ErrKind{"unavailable"}
 )
 
+// Match any error of the kind with errors.Is,
+// e.g. errors.Is(err, apperrors.ErrNotFound)
+var (
+	ErrInternal    error = &ApplicationError{kind: InternalKind}
+	ErrDomain      error = &ApplicationError{kind: DomainKind}
+	ErrValidation  error = &ApplicationError{kind: ValidationKind}
+	ErrNotFound    error = &ApplicationError{kind: NotFoundKind}
+	ErrConflict    error = &ApplicationError{kind: ConflictKind}
+	ErrUnavailable error = &ApplicationError{kind: UnavailableKind}
+)
+
+// Field is a key-value pair describing circumstances of error, e.g. advert_id
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// ApplicationError is immutable, methods that add details return a copy
 type ApplicationError struct {
-	stacktrace []string
-	err        error
-	kind       ErrKind
+	err  error
+	kind ErrKind
+	// Machine-readable reason, e.g. "subscription_exists"
+	code string
+	// Operations error went through, outermost first
+	ops    []string
+	fields []Field
+	// Program counters of constructor's callers
+	pcs []uintptr
 }
 
 func (ae *ApplicationError) Error() string {
+	// Kind sentinel
+	if ae.err == nil {
+		return ae.kind.K
+	}
+
 	return ae.err.Error()
 }
 
+func (ae *ApplicationError) Unwrap() error {
+	return ae.err
+}
+
+// Is matches kind sentinels, e.g. ErrNotFound.
+// Wrapped errors are matched by errors.Is itself through Unwrap
+func (ae *ApplicationError) Is(target error) bool {
+	t, ok := target.(*ApplicationError)
+	if !ok {
+		return false
+	}
+
+	return t.err == nil && t.kind == ae.kind
+}
+
+func (ae *ApplicationError) Kind() ErrKind {
+	return ae.kind
+}
+
+// Code falls back to name of kind, e.g. "internal"
+func (ae *ApplicationError) Code() string {
+	if ae.code == "" {
+		return ae.kind.K
+	}
+
+	return ae.code
+}
+
+// Cause returns wrapped error
+func (ae *ApplicationError) Cause() error {
+	return ae.err
+}
+
+// Operations error went through, outermost first
+func (ae *ApplicationError) Operations() []string {
+	return ae.ops
+}
+
+// PrintStacktrace joins operations, e.g. "handleUpdate.advertRepo.Update"
 func (ae *ApplicationError) PrintStacktrace() string {
-	var buff string
-	for _, trace := range ae.stacktrace {
-		buff += trace
+	return strings.Join(ae.ops, ".")
+}
+
+func (ae *ApplicationError) Fields() []Field {
+	return ae.fields
+}
+
+// With returns copy of error with field added
+func (ae *ApplicationError) With(key string, value interface{}) *ApplicationError {
+	c := *ae
+	c.fields = append(ae.fields[:len(ae.fields):len(ae.fields)], Field{Key: key, Value: value})
+
+	return &c
+}
+
+// Frames returns callers of constructor that created error, innermost first
+func (ae *ApplicationError) Frames() []runtime.Frame {
+	if len(ae.pcs) == 0 {
+		return nil
+	}
+
+	var frames []runtime.Frame
+
+	it := runtime.CallersFrames(ae.pcs)
+	for {
+		frame, more := it.Next()
+		frames = append(frames, frame)
+
+		if !more {
+			return frames
+		}
+	}
+}
+
+// StackTrace formats Frames like panic does
+func (ae *ApplicationError) StackTrace() string {
+	var b strings.Builder
+	for _, frame := range ae.Frames() {
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
 	}
 
-	return buff
+	return b.String()
+}
+
+// definition is sentinel error declared with Define
+type definition struct {
+	kind    ErrKind
+	code    string
+	message string
 }
 
-func (ae *ApplicationError) addTrace(trace string) {
-	ae.stacktrace = append([]string{trace, "."}, ae.stacktrace...)
+func (d *definition) Error() string {
+	return d.message
 }
 
-func WrapInternal(err error, context string) error {
-	return &ApplicationError{
-		kind:       InternalKind,
-		err:        err,
-		stacktrace: []string{context},
+// Define declares sentinel error, e.g. domain.ErrNoSubscriber.
+// Errors wrapping it with WrapDomain take over its kind and code
+func Define(kind ErrKind, code, message string) error {
+	return &definition{kind: kind, code: code, message: message}
+}
+
+// WrapInternal wraps failure of operation, e.g. repository call.
+// Error that is already classified keeps its kind, operation is chained then
+func WrapInternal(err error, op string) *ApplicationError {
+	if ae, ok := err.(*ApplicationError); ok {
+		return ae.chain(op)
 	}
+
+	return newError(err, InternalKind, "", op)
 }
 
-func WrapDomain(err error) error {
-	return &ApplicationError{
-		kind:       DomainKind,
-		err:        err,
-		stacktrace: nil,
+// WrapDomain wraps error caused by client, e.g. invalid input.
+// Kind and code are taken from error declared with Define, otherwise DomainKind is used
+func WrapDomain(err error) *ApplicationError {
+	var d *definition
+	if errors.As(err, &d) {
+		return newError(err, d.kind, d.code, "")
 	}
+
+	return newError(err, DomainKind, "", "")
 }
 
-func ChainInternal(err error, context string) error {
+// Wrap classifies error of other package, e.g. messages.ErrUnsupportedLocale
+func Wrap(err error, kind ErrKind, code string) *ApplicationError {
+	return newError(err, kind, code, "")
+}
+
+// Chain prepends operation to ApplicationError.
+// Other errors are returned as is
+func Chain(err error, op string) error {
 	ae, ok := err.(*ApplicationError)
 	if !ok {
 		return err
 	}
 
-	ae.addTrace(context)
+	return ae.chain(op)
+}
+
+// KindOf returns kind of ApplicationError in chain of err.
+// Unclassified errors are internal
+func KindOf(err error) ErrKind {
+	var ae *ApplicationError
+	if errors.As(err, &ae) {
+		return ae.kind
+	}
+
+	return InternalKind
+}
+
+func (ae *ApplicationError) chain(op string) *ApplicationError {
+	c := *ae
+	c.ops = append([]string{op}, ae.ops...)
+
+	return &c
+}
+
+func newError(err error, kind ErrKind, code, op string) *ApplicationError {
+	ae := &ApplicationError{
+		err:  err,
+		kind: kind,
+		code: code,
+	}
+
+	if op != "" {
+		ae.ops = []string{op}
+	}
+
+	// Skips runtime.Callers, newError and exported constructor
+	pcs := make([]uintptr, maxFrames)
+	n := runtime.Callers(3, pcs)
+	ae.pcs = pcs[:n]
 
 	return ae
 }
@@ -0,0 +1,76 @@
+package errors
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var errTaken = Define(ConflictKind, "name_taken", "name is taken")
+
+func TestApplicationError(t *testing.T) {
+	t.Run("keeps wrapped error reachable", func(t *testing.T) {
+		cause := errors.New("connection refused")
+		err := Chain(WrapInternal(cause, "advertRepo.Update"), "handleUpdate")
+
+		require.ErrorIs(t, err, cause)
+		require.ErrorIs(t, err, ErrInternal)
+		require.NotErrorIs(t, err, ErrDomain)
+		require.Equal(t, "connection refused", err.Error())
+
+		var ae *ApplicationError
+		require.ErrorAs(t, err, &ae)
+		require.Equal(t, InternalKind, ae.Kind())
+		require.Equal(t, "internal", ae.Code())
+		require.Equal(t, "handleUpdate.advertRepo.Update", ae.PrintStacktrace())
+	})
+
+	t.Run("takes kind and code of definition", func(t *testing.T) {
+		err := Chain(WrapDomain(errTaken), "service")
+
+		require.ErrorIs(t, err, errTaken)
+		require.ErrorIs(t, err, ErrConflict)
+		require.Equal(t, ConflictKind, KindOf(err))
+		require.Equal(t, "name is taken", err.Error())
+
+		var ae *ApplicationError
+		require.ErrorAs(t, err, &ae)
+		require.Equal(t, "name_taken", ae.Code())
+	})
+
+	t.Run("keeps kind when wrapped as internal", func(t *testing.T) {
+		err := WrapInternal(WrapDomain(errTaken), "repo")
+
+		require.Equal(t, ConflictKind, KindOf(err))
+		require.Equal(t, []string{"repo"}, err.Operations())
+	})
+
+	t.Run("treats unclassified errors as internal", func(t *testing.T) {
+		require.Equal(t, InternalKind, KindOf(errors.New("unknown")))
+		require.Equal(t, DomainKind, KindOf(WrapDomain(errors.New("plain"))))
+	})
+
+	t.Run("copies on change", func(t *testing.T) {
+		base := WrapInternal(errors.New("failed"), "repo")
+		first := base.With("advert_id", "1")
+		second := base.With("advert_id", "2")
+		chained := Chain(first, "service")
+
+		require.Empty(t, base.Fields())
+		require.Equal(t, []Field{{"advert_id", "1"}}, first.Fields())
+		require.Equal(t, []Field{{"advert_id", "2"}}, second.Fields())
+		require.Equal(t, "repo", first.PrintStacktrace())
+		require.Equal(t, "service.repo", chained.(*ApplicationError).PrintStacktrace())
+	})
+
+	t.Run("captures frames of caller", func(t *testing.T) {
+		err := Wrap(errors.New("failed"), ValidationKind, "invalid")
+
+		frames := err.Frames()
+		require.NotEmpty(t, frames)
+		require.True(t, strings.HasSuffix(frames[0].Function, "TestApplicationError.func6"), frames[0].Function)
+		require.Contains(t, err.StackTrace(), "errors_test.go")
+	})
+}
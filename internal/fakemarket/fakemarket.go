@@ -0,0 +1,144 @@
+// Package fakemarket serves recorded marketplace pages locally,
+// so parsers could be tested without network access
+package fakemarket
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Pages are served at /items/<name>, name is file name without .html
+const itemsPrefix = "/items/"
+
+// Route overrides how page is served
+type Route struct {
+	// 200 if zero
+	Status int
+	// Delay before response is written
+	Latency time.Duration
+}
+
+type Server struct {
+	srv *httptest.Server
+
+	// Name to html, read once
+	pages map[string][]byte
+
+	mu      sync.Mutex
+	latency time.Duration
+	routes  map[string]Route
+	hits    map[string]int
+}
+
+// New serves .html files of dir. Unknown pages are answered with 404
+func New(dir string) (*Server, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.html"))
+	if err != nil {
+		return nil, err
+	}
+
+	pages := make(map[string][]byte, len(paths))
+	for _, path := range paths {
+		html, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		pages[strings.TrimSuffix(filepath.Base(path), ".html")] = html
+	}
+
+	s := &Server{
+		pages:  pages,
+		routes: make(map[string]Route),
+		hits:   make(map[string]int),
+	}
+
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serve))
+
+	return s, nil
+}
+
+// URL of page, it doesn't have to exist
+func (s *Server) URL(page string) string {
+	return s.srv.URL + itemsPrefix + page
+}
+
+// Pages are names of served pages
+func (s *Server) Pages() []string {
+	names := make([]string, 0, len(s.pages))
+	for name := range s.pages {
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// SetLatency delays every page without own route latency
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.latency = d
+}
+
+// SetRoute changes status and latency of page, e.g. to serve removed advert with 404
+func (s *Server) SetRoute(page string, route Route) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.routes[page] = route
+}
+
+// Hits counts requests of page
+func (s *Server) Hits(page string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.hits[page]
+}
+
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, itemsPrefix)
+
+	s.mu.Lock()
+	route, ok := s.routes[name]
+	if !ok || route.Latency == 0 {
+		route.Latency = s.latency
+	}
+
+	s.hits[name]++
+	s.mu.Unlock()
+
+	if route.Latency > 0 {
+		select {
+		case <-time.After(route.Latency):
+		// Client gave up
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	html, ok := s.pages[name]
+	if !ok || !strings.HasPrefix(r.URL.Path, itemsPrefix) {
+		http.NotFound(w, r)
+		return
+	}
+
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(html)
+}
@@ -0,0 +1,91 @@
+package fakemarket
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func get(t *testing.T, ctx context.Context, url string) (int, string, error) {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	return resp.StatusCode, string(body), nil
+}
+
+func TestServer(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "active.html"), []byte("<html>active</html>"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a page"), 0o644))
+
+	market, err := New(dir)
+	require.NoError(t, err)
+	defer market.Close()
+
+	ctx := context.Background()
+
+	require.Equal(t, []string{"active"}, market.Pages())
+
+	t.Run("serves pages", func(t *testing.T) {
+		status, body, err := get(t, ctx, market.URL("active"))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "<html>active</html>", body)
+
+		status, _, err = get(t, ctx, market.URL("notes"))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("serves page with configured status", func(t *testing.T) {
+		market.SetRoute("active", Route{Status: http.StatusGone})
+		defer market.SetRoute("active", Route{})
+
+		status, body, err := get(t, ctx, market.URL("active"))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusGone, status)
+		require.Equal(t, "<html>active</html>", body)
+	})
+
+	t.Run("delays responses", func(t *testing.T) {
+		market.SetLatency(time.Second)
+		defer market.SetLatency(0)
+
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+
+		_, _, err := get(t, ctx, market.URL("active"))
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+
+		// Route latency takes precedence
+		market.SetRoute("active", Route{Latency: time.Millisecond})
+		defer market.SetRoute("active", Route{})
+
+		status, _, err := get(t, context.Background(), market.URL("active"))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, status)
+	})
+
+	t.Run("counts hits", func(t *testing.T) {
+		require.GreaterOrEqual(t, market.Hits("active"), 4)
+		require.Equal(t, 1, market.Hits("notes"))
+	})
+}
@@ -0,0 +1,153 @@
+// Package health runs checks of application components for /healthz and /readyz
+package health
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Used when Check.Timeout is not set
+const defaultTimeout = 2 * time.Second
+
+var ErrTimeout = errors.New("health check timed out")
+
+type Status string
+
+const (
+	StatusOK   Status = "ok"
+	StatusFail Status = "fail"
+)
+
+// CheckFunc returns nil if component is healthy
+type CheckFunc func(ctx context.Context) error
+
+type Check struct {
+	// Component name, e.g. "postgres"
+	Name string
+	// Check is failed if it takes longer. 2s if zero
+	Timeout time.Duration
+	// Failure of liveness check means process should be restarted,
+	// e.g. stuck loop. Liveness checks are run for readiness as well
+	Liveness bool
+
+	Run CheckFunc
+}
+
+type Report struct {
+	Status     Status
+	Components []*ComponentReport
+}
+
+type ComponentReport struct {
+	Name     string
+	Status   Status
+	Err      error
+	Duration time.Duration
+}
+
+// Registry holds checks registered by components. Safe for concurrent use
+type Registry struct {
+	mu     sync.RWMutex
+	checks []*Check
+}
+
+func NewRegistry() *Registry {
+	return new(Registry)
+}
+
+func (r *Registry) Register(check *Check) {
+	if check.Timeout == 0 {
+		check.Timeout = defaultTimeout
+	}
+
+	r.mu.Lock()
+	r.checks = append(r.checks, check)
+	r.mu.Unlock()
+}
+
+// Liveness runs liveness checks only
+func (r *Registry) Liveness(ctx context.Context) *Report {
+	return r.run(ctx, true)
+}
+
+// Readiness runs every check
+func (r *Registry) Readiness(ctx context.Context) *Report {
+	return r.run(ctx, false)
+}
+
+// Checks are run concurrently, so report takes as long as the slowest one
+func (r *Registry) run(ctx context.Context, livenessOnly bool) *Report {
+	r.mu.RLock()
+	var checks []*Check
+	for _, check := range r.checks {
+		if !livenessOnly || check.Liveness {
+			checks = append(checks, check)
+		}
+	}
+	r.mu.RUnlock()
+
+	report := &Report{
+		Status:     StatusOK,
+		Components: make([]*ComponentReport, len(checks)),
+	}
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+
+		go func(i int, check *Check) {
+			defer wg.Done()
+			report.Components[i] = runCheck(ctx, check)
+		}(i, check)
+	}
+
+	wg.Wait()
+
+	for _, component := range report.Components {
+		if component.Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+
+	sort.Slice(report.Components, func(i, j int) bool {
+		return report.Components[i].Name < report.Components[j].Name
+	})
+
+	return report
+}
+
+func runCheck(ctx context.Context, check *Check) *ComponentReport {
+	ctx, cancel := context.WithTimeout(ctx, check.Timeout)
+	defer cancel()
+
+	start := time.Now()
+
+	// Check that ignores context must not hold up the report
+	done := make(chan error, 1)
+	go func() {
+		done <- check.Run(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ErrTimeout
+	}
+
+	component := &ComponentReport{
+		Name:     check.Name,
+		Status:   StatusOK,
+		Duration: time.Since(start),
+	}
+
+	if err != nil {
+		component.Status = StatusFail
+		component.Err = err
+	}
+
+	return component
+}
@@ -0,0 +1,59 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	errDown := errors.New("down")
+
+	registry := NewRegistry()
+	registry.Register(&Check{Name: "postgres", Run: func(ctx context.Context) error { return errDown }})
+	registry.Register(&Check{Name: "proxy", Liveness: true, Run: func(ctx context.Context) error { return nil }})
+	registry.Register(&Check{
+		Name:     "chrome",
+		Liveness: true,
+		Timeout:  10 * time.Millisecond,
+		// Ignores context
+		Run: func(ctx context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		},
+	})
+
+	t.Run("liveness runs liveness checks only", func(t *testing.T) {
+		start := time.Now()
+		report := registry.Liveness(context.Background())
+
+		require.Less(t, time.Since(start), time.Second, "hung check should not hold up report")
+		require.Equal(t, StatusFail, report.Status)
+		require.Len(t, report.Components, 2)
+
+		chrome, proxy := report.Components[0], report.Components[1]
+		require.Equal(t, "chrome", chrome.Name)
+		require.Equal(t, StatusFail, chrome.Status)
+		require.ErrorIs(t, chrome.Err, ErrTimeout)
+
+		require.Equal(t, "proxy", proxy.Name)
+		require.Equal(t, StatusOK, proxy.Status)
+		require.NoError(t, proxy.Err)
+	})
+
+	t.Run("readiness runs every check", func(t *testing.T) {
+		report := registry.Readiness(context.Background())
+
+		require.Equal(t, StatusFail, report.Status)
+		require.Len(t, report.Components, 3)
+		require.Equal(t, "postgres", report.Components[1].Name)
+		require.ErrorIs(t, report.Components[1].Err, errDown)
+	})
+
+	t.Run("empty registry is healthy", func(t *testing.T) {
+		require.Equal(t, StatusOK, NewRegistry().Readiness(context.Background()).Status)
+	})
+}
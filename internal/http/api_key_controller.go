@@ -0,0 +1,73 @@
+package http
+
+import (
+	"net/http"
+	domain "parser/internal/domain/models"
+	"parser/internal/http/dto"
+)
+
+func (s *HTTPServer) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+
+	var inp dto.APIKeyRequest
+	err := decodeBody(r, &inp)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	apiKey, plain, err := s.services.AuthService.CreateAPIKey(r.Context(), &inp)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Key is shown only once
+	out := apiKeyResponse(apiKey)
+	out.Key = plain
+
+	writeJSON(w, http.StatusCreated, out)
+}
+
+func (s *HTTPServer) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+
+	apiKeys, err := s.services.AuthService.ListAPIKeys(r.Context())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	out := make([]*dto.APIKeyResponse, 0, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		out = append(out, apiKeyResponse(apiKey))
+	}
+
+	writeJSON(w, http.StatusOK, out)
+}
+
+// Path: /api-keys/{api_key_id}
+func (s *HTTPServer) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+
+	err := s.services.AuthService.RevokeAPIKey(r.Context(), PathParam(r, "api_key_id"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func apiKeyResponse(apiKey *domain.APIKey) *dto.APIKeyResponse {
+	out := &dto.APIKeyResponse{
+		APIKeyID:  apiKey.APIKeyID,
+		Name:      apiKey.Name(),
+		Hint:      apiKey.Hint(),
+		Scopes:    domain.ScopesToStrings(apiKey.Scopes()),
+		CreatedAt: apiKey.CreatedAt(),
+	}
+
+	if lastUsedAt := apiKey.LastUsedAt(); !lastUsedAt.IsZero() {
+		out.LastUsedAt = &lastUsedAt
+	}
+
+	return out
+}
@@ -0,0 +1,157 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	domain "parser/internal/domain/models"
+	"parser/internal/domain/services"
+	"strconv"
+	"strings"
+)
+
+// Schemes of Authorization header
+const (
+	// Bearer <api key>
+	schemeAPIKey = "Bearer"
+	// tma <initData of Telegram Web App>
+	schemeWebApp = "tma"
+	// TelegramLogin <fields of Telegram Login Widget as query string>
+	schemeTelegramLogin = "TelegramLogin"
+)
+
+var ErrUnauthenticated = errors.New("authentication required")
+
+type principalKey struct{}
+
+// PrincipalFromContext returns client authenticated by Authenticate middleware.
+// Nil if request has no credentials
+func PrincipalFromContext(ctx context.Context) *domain.Principal {
+	principal, _ := ctx.Value(principalKey{}).(*domain.Principal)
+	return principal
+}
+
+// Authenticate verifies credentials of Authorization header and puts principal into context.
+// Requests without credentials are passed as is, see requireScope
+func Authenticate(auth services.AuthService) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			if header == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			principal, err := authenticate(r.Context(), auth, header)
+			if err != nil {
+				writeError(w, r, err)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), principalKey{}, principal)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func authenticate(ctx context.Context, auth services.AuthService, header string) (*domain.Principal, error) {
+	scheme, credentials, _ := strings.Cut(header, " ")
+	credentials = strings.TrimSpace(credentials)
+
+	switch {
+	case credentials == "":
+		return nil, ErrUnauthenticated
+	case strings.EqualFold(scheme, schemeAPIKey):
+		return auth.AuthenticateAPIKey(ctx, credentials)
+	case strings.EqualFold(scheme, schemeWebApp):
+		return auth.AuthenticateWebApp(ctx, credentials)
+	case strings.EqualFold(scheme, schemeTelegramLogin):
+		data, err := url.ParseQuery(credentials)
+		if err != nil {
+			return nil, ErrUnauthenticated
+		}
+
+		return auth.AuthenticateTelegramLogin(ctx, data)
+	}
+
+	return nil, ErrUnauthenticated
+}
+
+// Responds with 401 to anonymous clients and 403 to clients without scope
+func requireScope(scope domain.Scope, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := PrincipalFromContext(r.Context())
+		if principal == nil {
+			writeError(w, r, ErrUnauthenticated)
+			return
+		}
+
+		if !principal.HasScope(scope) {
+			writeError(w, r, domain.ErrForbidden)
+			return
+		}
+
+		h(w, r)
+	}
+}
+
+// Resolves subscriber request is made on behalf of.
+// Telegram users could omit telegramID, see domain.Principal.ActAs
+func actAs(r *http.Request, telegramID int64) (int64, error) {
+	principal := PrincipalFromContext(r.Context())
+	if principal == nil {
+		return 0, ErrUnauthenticated
+	}
+
+	telegramID, err := principal.ActAs(telegramID)
+	if err != nil {
+		return 0, err
+	}
+
+	if telegramID == 0 {
+		return 0, ErrInvalidTelegramID
+	}
+
+	return telegramID, nil
+}
+
+// Reads telegram_id from query, it is optional for telegram users
+func queryTelegramID(r *http.Request) (int64, error) {
+	telegramID, err := parseTelegramID(r)
+	if err != nil {
+		return 0, err
+	}
+
+	return actAs(r, telegramID)
+}
+
+// Like queryTelegramID, but API keys could omit telegram_id as well.
+// Zero is returned then
+func optionalTelegramID(r *http.Request) (int64, error) {
+	telegramID, err := parseTelegramID(r)
+	if err != nil {
+		return 0, err
+	}
+
+	principal := PrincipalFromContext(r.Context())
+	if principal == nil {
+		return 0, ErrUnauthenticated
+	}
+
+	return principal.ActAs(telegramID)
+}
+
+func parseTelegramID(r *http.Request) (int64, error) {
+	value := r.URL.Query().Get("telegram_id")
+	if value == "" {
+		return 0, nil
+	}
+
+	telegramID, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidTelegramID
+	}
+
+	return telegramID, nil
+}
@@ -0,0 +1,101 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	domain "parser/internal/domain/models"
+	"parser/internal/domain/services"
+	"parser/internal/errors"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Embedded interface panics on methods tests don't expect to be called
+type fakeAuthService struct {
+	services.AuthService
+
+	apiKeys map[string]*domain.APIKey
+}
+
+func (f *fakeAuthService) AuthenticateAPIKey(ctx context.Context, key string) (*domain.Principal, error) {
+	apiKey, ok := f.apiKeys[key]
+	if !ok {
+		return nil, errors.WrapDomain(domain.ErrInvalidCredentials)
+	}
+
+	return domain.NewAPIKeyPrincipal(apiKey), nil
+}
+
+// Trusts id field, signatures are covered by telegram package
+func (f *fakeAuthService) AuthenticateTelegramLogin(ctx context.Context, data url.Values) (*domain.Principal, error) {
+	telegramID, err := strconv.ParseInt(data.Get("id"), 10, 64)
+	if err != nil {
+		return nil, errors.WrapDomain(domain.ErrInvalidCredentials)
+	}
+
+	return domain.NewTelegramPrincipal(telegramID, data.Get("id")), nil
+}
+
+func TestAuthenticate(t *testing.T) {
+	readKey, err := domain.NewAPIKeyFromPlain("reader", "avt_read", []domain.Scope{domain.ScopeSubscriptionsRead})
+	require.NoError(t, err)
+
+	auth := &fakeAuthService{apiKeys: map[string]*domain.APIKey{"avt_read": readKey}}
+
+	var actedAs int64
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		telegramID, err := queryTelegramID(r)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		actedAs = telegramID
+		w.WriteHeader(http.StatusOK)
+	}
+
+	router := NewMuxRouter()
+	router.Use(Authenticate(auth))
+	router.Get("/subscriptions", requireScope(domain.ScopeSubscriptionsRead, handler))
+	router.Delete("/subscriptions", requireScope(domain.ScopeSubscriptionsWrite, handler))
+
+	tests := []struct {
+		name          string
+		method        string
+		query         string
+		authorization string
+		status        int
+		actedAs       int64
+	}{
+		{"anonymous", http.MethodGet, "?telegram_id=1", "", http.StatusUnauthorized, 0},
+		{"unknown scheme", http.MethodGet, "?telegram_id=1", "Basic dXNlcjpwYXNz", http.StatusUnauthorized, 0},
+		{"invalid api key", http.MethodGet, "?telegram_id=1", "Bearer avt_nope", http.StatusUnauthorized, 0},
+		{"api key acts as anyone", http.MethodGet, "?telegram_id=1", "Bearer avt_read", http.StatusOK, 1},
+		{"api key requires telegram_id", http.MethodGet, "", "Bearer avt_read", http.StatusBadRequest, 0},
+		{"api key lacks scope", http.MethodDelete, "?telegram_id=1", "Bearer avt_read", http.StatusForbidden, 0},
+		{"telegram user acts as self", http.MethodDelete, "", "TelegramLogin id=42&hash=ab", http.StatusOK, 42},
+		{"telegram user acts as another", http.MethodGet, "?telegram_id=1", "TelegramLogin id=42&hash=ab", http.StatusForbidden, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actedAs = 0
+
+			req := httptest.NewRequest(tt.method, "/subscriptions"+tt.query, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			rec := httptest.NewRecorder()
+			router.Handler().ServeHTTP(rec, req)
+
+			require.Equal(t, tt.status, rec.Code)
+			require.Equal(t, tt.actedAs, actedAs)
+		})
+	}
+}
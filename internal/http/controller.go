@@ -12,18 +12,255 @@ func (s *HTTPServer) Subscribe(w http.ResponseWriter, r *http.Request) {
 	// TelegramID
 	// AdvertURL
 	var inp dto.SubscribeRequest
-	err := json.NewDecoder(r.Body).Decode(&inp)
+	err := decodeBody(r, &inp)
 	if err != nil {
-		w.Write([]byte(err.Error()))
+		writeError(w, r, err)
+		return
+	}
+
+	inp.TelegramID, err = actAs(r, inp.TelegramID)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	err = s.services.SubscriptionService.NewSubscription(r.Context(), &inp)
 	if err != nil {
-		// TODO: later add app error handling
-		w.Write([]byte(err.Error()))
+		writeError(w, r, err)
+		return
+	}
+
+	writeMessage(w, r, http.StatusCreated, "yahoo! New subscription is up")
+}
+
+func (s *HTTPServer) SetChannels(w http.ResponseWriter, r *http.Request) {
+
+	var inp dto.ChannelsRequest
+	err := decodeBody(r, &inp)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	inp.TelegramID, err = actAs(r, inp.TelegramID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	err = s.services.SubscriptionService.SetChannels(r.Context(), &inp)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeMessage(w, r, http.StatusOK, "channels are updated")
+}
+
+func (s *HTTPServer) SetSubscriptionChannels(w http.ResponseWriter, r *http.Request) {
+
+	var inp dto.SubscriptionChannelsRequest
+	err := decodeBody(r, &inp)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	inp.TelegramID, err = actAs(r, inp.TelegramID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	err = s.services.SubscriptionService.SetSubscriptionChannels(r.Context(), &inp)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeMessage(w, r, http.StatusOK, "subscription channels are updated")
+}
+
+func (s *HTTPServer) SetLocale(w http.ResponseWriter, r *http.Request) {
+
+	var inp dto.LocaleRequest
+	err := decodeBody(r, &inp)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	inp.TelegramID, err = actAs(r, inp.TelegramID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	err = s.services.SubscriptionService.SetLocale(r.Context(), &inp)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeMessage(w, r, http.StatusOK, "locale is updated")
+}
+
+func (s *HTTPServer) SetSchedule(w http.ResponseWriter, r *http.Request) {
+
+	var inp dto.ScheduleRequest
+	err := decodeBody(r, &inp)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	inp.TelegramID, err = actAs(r, inp.TelegramID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	err = s.services.SubscriptionService.SetSchedule(r.Context(), &inp)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeMessage(w, r, http.StatusOK, "schedule is updated")
+}
+
+func (s *HTTPServer) RequestEmail(w http.ResponseWriter, r *http.Request) {
+
+	var inp dto.EmailRequest
+	err := decodeBody(r, &inp)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	inp.TelegramID, err = actAs(r, inp.TelegramID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	err = s.services.EmailService.RequestConfirmation(r.Context(), &inp)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeMessage(w, r, http.StatusOK, "confirmation link is sent to "+inp.Email)
+}
+
+// Confirmation link from email leads here
+func (s *HTTPServer) ConfirmEmail(w http.ResponseWriter, r *http.Request) {
+
+	token := r.URL.Query().Get("token")
+
+	err := s.services.EmailService.ConfirmEmail(r.Context(), token)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	w.Write([]byte("yahoo! New subscription is up"))
+	writeMessage(w, r, http.StatusOK, "email is confirmed. Price alerts will be sent to it")
+}
+
+func (s *HTTPServer) RegisterWebhook(w http.ResponseWriter, r *http.Request) {
+
+	var inp dto.WebhookRequest
+	err := decodeBody(r, &inp)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	inp.TelegramID, err = actAs(r, inp.TelegramID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	webhook, err := s.services.WebhookService.Register(r.Context(), &inp)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Secret is shown only once
+	writeJSON(w, http.StatusCreated, &dto.WebhookResponse{
+		WebhookID: webhook.WebhookID,
+		URL:       webhook.URL(),
+		Secret:    webhook.Secret(),
+		IsActive:  webhook.IsActive(),
+		Failures:  webhook.Failures(),
+	})
+}
+
+// Query: ?telegram_id=
+func (s *HTTPServer) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+
+	telegramID, err := queryTelegramID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	webhooks, err := s.services.WebhookService.List(r.Context(), telegramID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	out := make([]*dto.WebhookResponse, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		out = append(out, &dto.WebhookResponse{
+			WebhookID: webhook.WebhookID,
+			URL:       webhook.URL(),
+			IsActive:  webhook.IsActive(),
+			Failures:  webhook.Failures(),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, out)
+}
+
+// Query: ?telegram_id=&webhook_id=
+func (s *HTTPServer) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+
+	telegramID, err := queryTelegramID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	err = s.services.WebhookService.Delete(r.Context(), telegramID, r.URL.Query().Get("webhook_id"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Body over BodyLimit is ErrBodyTooLarge, any other failure is ErrInvalidBody
+func decodeBody(r *http.Request, v interface{}) error {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err == nil {
+		return nil
+	}
+
+	// Error of http.MaxBytesReader has no type until go1.19
+	if err.Error() == "http: request body too large" {
+		return ErrBodyTooLarge
+	}
+
+	return ErrInvalidBody
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
 }
@@ -3,4 +3,70 @@ package dto
 type SubscribeRequest struct {
 	TelegramID int64  `json:"telegram_id"`
 	AdvertURL  string `json:"advert_url"`
+	// Optional. Overrides subscriber's channels for this advert
+	Channels []string `json:"channels,omitempty"`
+}
+
+type EmailRequest struct {
+	TelegramID int64  `json:"telegram_id"`
+	Email      string `json:"email"`
+}
+
+type WebhookRequest struct {
+	TelegramID int64  `json:"telegram_id"`
+	URL        string `json:"url"`
+}
+
+// Sets subscriber's default notification channels
+type ChannelsRequest struct {
+	TelegramID int64    `json:"telegram_id"`
+	Channels   []string `json:"channels"`
+}
+
+// Overrides notification channels of single subscription.
+// null channels resets override to subscriber's channels
+type SubscriptionChannelsRequest struct {
+	TelegramID int64    `json:"telegram_id"`
+	AdvertURL  string   `json:"advert_url"`
+	Channels   []string `json:"channels"`
+}
+
+type LocaleRequest struct {
+	TelegramID int64 `json:"telegram_id"`
+	// e.g. "ru", "en"
+	Locale string `json:"locale"`
+}
+
+// Sets when subscriber receives alerts
+type ScheduleRequest struct {
+	TelegramID int64 `json:"telegram_id"`
+	// IANA name, e.g. "Europe/Moscow". Defaults to Europe/Moscow
+	Timezone string `json:"timezone"`
+	// Optional. null disables quiet hours
+	QuietHours *QuietHours `json:"quiet_hours"`
+	// off | hourly | daily. Defaults to off
+	Digest string `json:"digest"`
+}
+
+// Local time of day, e.g. "23:00" - "08:00"
+type QuietHours struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Fields that are not set are left unchanged
+type UpdateSubscriptionRequest struct {
+	// Overrides subscriber's channels for the advert
+	Channels []string `json:"channels,omitempty"`
+	// Falls back to subscriber's channels. Takes precedence over Channels
+	ResetChannels bool `json:"reset_channels,omitempty"`
+	// Muted subscription doesn't receive alerts
+	Muted *bool `json:"muted,omitempty"`
+}
+
+type APIKeyRequest struct {
+	// Tells who uses the key, e.g. "billing-service"
+	Name string `json:"name"`
+	// subscriptions:read | subscriptions:write | webhooks | admin
+	Scopes []string `json:"scopes"`
 }
@@ -0,0 +1,102 @@
+package dto
+
+import "time"
+
+// Envelope of every error and of responses that carry only a message
+type StatusResponse struct {
+	// Machine-readable, e.g. "not_found"
+	Code string `json:"code"`
+	// Code of error, e.g. "subscription_exists". Only client errors have it
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message"`
+	// Same as X-Request-ID header. Helps to find request in logs
+	RequestID string `json:"request_id"`
+}
+
+type WebhookResponse struct {
+	WebhookID string `json:"webhook_id"`
+	URL       string `json:"url"`
+	// Shown only once when webhook is registered
+	Secret   string `json:"secret,omitempty"`
+	IsActive bool   `json:"is_active"`
+	Failures int    `json:"failures"`
+}
+
+type SubscriptionResponse struct {
+	AdvertID string `json:"advert_id"`
+	// Overrides subscriber's channels. null if subscriber's channels are used
+	Channels  []string  `json:"channels"`
+	Muted     bool      `json:"muted"`
+	CreatedAt time.Time `json:"created_at"`
+
+	Advert *AdvertResponse `json:"advert"`
+}
+
+// Current state of advert
+type AdvertResponse struct {
+	URL          string  `json:"url"`
+	Title        string  `json:"title"`
+	CurrentPrice float64 `json:"current_price"`
+	LastPrice    float64 `json:"last_price"`
+	// False until advert is parsed for the first time
+	IsParsed bool `json:"is_parsed"`
+}
+
+type SubscriptionListResponse struct {
+	Items  []*SubscriptionResponse `json:"items"`
+	Total  int                     `json:"total"`
+	Limit  int                     `json:"limit"`
+	Offset int                     `json:"offset"`
+}
+
+type SnapshotResponse struct {
+	SnapshotID int64  `json:"snapshot_id"`
+	URL        string `json:"url"`
+	// Parser couldn't extract advert from page
+	Failed bool `json:"failed"`
+	// Error of parser, empty if page was parsed fine
+	Error string `json:"error"`
+	// Length of html in bytes
+	Size      int       `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type APIKeyResponse struct {
+	APIKeyID string `json:"api_key_id"`
+	Name     string `json:"name"`
+	// Only shown once on creation
+	Key string `json:"key,omitempty"`
+	// First characters of key
+	Hint      string    `json:"hint"`
+	Scopes    []string  `json:"scopes"`
+	CreatedAt time.Time `json:"created_at"`
+	// null if key has never been used
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+// Data of "price" event of /prices/stream
+type PriceEvent struct {
+	// Position in stream, sent as id of event
+	ChangeID  int64     `json:"change_id"`
+	AdvertID  string    `json:"advert_id"`
+	Title     string    `json:"title"`
+	URL       string    `json:"url"`
+	OldPrice  float64   `json:"old_price"`
+	NewPrice  float64   `json:"new_price"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// Response of /healthz and /readyz
+type HealthResponse struct {
+	// "ok" if every component is healthy, "fail" otherwise
+	Status     string             `json:"status"`
+	Components []*ComponentHealth `json:"components"`
+}
+
+type ComponentHealth struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	// Empty if component is healthy
+	Error      string  `json:"error,omitempty"`
+	DurationMS float64 `json:"duration_ms"`
+}
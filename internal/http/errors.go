@@ -0,0 +1,178 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	domain "parser/internal/domain/models"
+	apperrors "parser/internal/errors"
+	"parser/internal/http/dto"
+	"parser/internal/logger"
+	"parser/internal/tracing"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Codes of dto.StatusResponse
+const (
+	CodeOK               = "ok"
+	CodeBadRequest       = "bad_request"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeConflict         = "conflict"
+	CodeGone             = "gone"
+	CodeTooLarge         = "payload_too_large"
+	CodeUnavailable      = "unavailable"
+	CodeInternal         = "internal_error"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+var (
+	ErrInvalidBody       = errors.New("request body should be valid JSON")
+	ErrInvalidTelegramID = errors.New("telegram_id should be an integer")
+	ErrInvalidPagination = errors.New("limit and offset should be integers")
+	ErrInvalidEventID    = errors.New("last event id should be an integer")
+	ErrInvalidSnapshotID = errors.New("snapshot id should be an integer")
+	ErrNotFound          = errors.New("resource not found")
+	ErrMethodNotAllowed  = errors.New("method not allowed")
+
+	// Shown instead of internal errors
+	errInternalMessage = "internal server error"
+)
+
+type errorStatus struct {
+	err    error
+	status int
+	code   string
+}
+
+// Domain errors with status their kind doesn't imply
+var domainStatuses = []errorStatus{
+	{domain.ErrInvalidCredentials, http.StatusUnauthorized, CodeUnauthorized},
+	{domain.ErrCredentialsExpired, http.StatusUnauthorized, CodeUnauthorized},
+	{domain.ErrForbidden, http.StatusForbidden, CodeForbidden},
+	{domain.ErrConfirmationExpired, http.StatusGone, CodeGone},
+}
+
+// Statuses of classified errors, DomainKind and ValidationKind become 400 Bad Request
+var kindStatuses = map[apperrors.ErrKind]errorStatus{
+	apperrors.NotFoundKind:    {nil, http.StatusNotFound, CodeNotFound},
+	apperrors.ConflictKind:    {nil, http.StatusConflict, CodeConflict},
+	apperrors.UnavailableKind: {nil, http.StatusServiceUnavailable, CodeUnavailable},
+}
+
+// Errors of http layer itself
+var requestStatuses = []errorStatus{
+	{ErrInvalidBody, http.StatusBadRequest, CodeBadRequest},
+	{ErrInvalidTelegramID, http.StatusBadRequest, CodeBadRequest},
+	{ErrInvalidPagination, http.StatusBadRequest, CodeBadRequest},
+	{ErrInvalidEventID, http.StatusBadRequest, CodeBadRequest},
+	{ErrInvalidSnapshotID, http.StatusBadRequest, CodeBadRequest},
+	{ErrUnauthenticated, http.StatusUnauthorized, CodeUnauthorized},
+	// Raised by http layer itself as well, see requireScope
+	{domain.ErrForbidden, http.StatusForbidden, CodeForbidden},
+	{ErrNotFound, http.StatusNotFound, CodeNotFound},
+	{ErrMethodNotAllowed, http.StatusMethodNotAllowed, CodeMethodNotAllowed},
+	{ErrBodyTooLarge, http.StatusRequestEntityTooLarge, CodeTooLarge},
+}
+
+// errorToStatus maps error to http status and code.
+// Status of errors.ApplicationError follows its kind, errors.InternalKind and unknown errors become 500
+func errorToStatus(err error) (int, string) {
+	var ae *apperrors.ApplicationError
+	if !errors.As(err, &ae) {
+		for _, s := range requestStatuses {
+			if errors.Is(err, s.err) {
+				return s.status, s.code
+			}
+		}
+
+		return http.StatusInternalServerError, CodeInternal
+	}
+
+	if ae.Kind() == apperrors.InternalKind {
+		return http.StatusInternalServerError, CodeInternal
+	}
+
+	for _, s := range domainStatuses {
+		if errors.Is(ae, s.err) {
+			return s.status, s.code
+		}
+	}
+
+	if s, ok := kindStatuses[ae.Kind()]; ok {
+		return s.status, s.code
+	}
+
+	return http.StatusBadRequest, CodeBadRequest
+}
+
+// writeError responds with dto.StatusResponse.
+// Details of internal errors are only logged
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	requestID := ensureRequestID(w, r)
+	status, code := errorToStatus(err)
+
+	message := err.Error()
+	if status >= http.StatusInternalServerError {
+		message = errInternalMessage
+		tracing.RecordError(trace.SpanFromContext(r.Context()), err)
+	}
+
+	// Machine-readable reason of client errors, e.g. "subscription_exists"
+	var reason string
+	var ae *apperrors.ApplicationError
+	if status < http.StatusInternalServerError && errors.As(err, &ae) {
+		reason = ae.Code()
+	}
+
+	// Panic is logged by Recovery along with stack
+	if status >= http.StatusInternalServerError && !errors.Is(err, errPanic) {
+		fields := []logger.Field{
+			logger.String("method", r.Method),
+			logger.String("path", r.URL.Path),
+		}
+
+		logger.FromContext(r.Context()).Error("request failed", append(fields, logger.ErrFields(err)...)...)
+	}
+
+	if status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", schemeAPIKey)
+	}
+
+	writeJSON(w, status, &dto.StatusResponse{
+		Code:      code,
+		Reason:    reason,
+		Message:   message,
+		RequestID: requestID,
+	})
+}
+
+// writeMessage responds with successful dto.StatusResponse
+func writeMessage(w http.ResponseWriter, r *http.Request, status int, message string) {
+	writeJSON(w, status, &dto.StatusResponse{
+		Code:      CodeOK,
+		Message:   message,
+		RequestID: ensureRequestID(w, r),
+	})
+}
+
+// Returns ID assigned by RequestID middleware.
+// Without middleware reuses client's X-Request-ID if any and echoes it in response
+func ensureRequestID(w http.ResponseWriter, r *http.Request) string {
+	if requestID := RequestIDFromContext(r.Context()); requestID != "" {
+		return requestID
+	}
+
+	requestID := r.Header.Get(requestIDHeader)
+	if requestID == "" || len(requestID) > 64 {
+		requestID = uuid.NewString()
+	}
+
+	w.Header().Set(requestIDHeader, requestID)
+
+	return requestID
+}
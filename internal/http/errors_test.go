@@ -0,0 +1,92 @@
+package http
+
+import (
+	"encoding/json"
+	goerrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	domain "parser/internal/domain/models"
+	"parser/internal/errors"
+	"parser/internal/http/dto"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorToStatus(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{errors.WrapDomain(domain.ErrSubscriptionExist), http.StatusConflict, CodeConflict},
+		{errors.WrapDomain(domain.ErrNoSubscriber), http.StatusNotFound, CodeNotFound},
+		{errors.WrapDomain(domain.ErrUnknownChannel), http.StatusBadRequest, CodeBadRequest},
+		{errors.Chain(errors.WrapDomain(domain.ErrNoSubscription), "service"), http.StatusNotFound, CodeNotFound},
+		{errors.WrapDomain(domain.ErrConfirmationExpired), http.StatusGone, CodeGone},
+		{errors.WrapDomain(domain.ErrInvalidCredentials), http.StatusUnauthorized, CodeUnauthorized},
+		{errors.WrapDomain(errors.Define(errors.UnavailableKind, "disabled", "disabled")), http.StatusServiceUnavailable, CodeUnavailable},
+		{errors.Wrap(goerrors.New("bad locale"), errors.ValidationKind, "unsupported_locale"), http.StatusBadRequest, CodeBadRequest},
+		{errors.WrapInternal(goerrors.New("conn refused"), "repo"), http.StatusInternalServerError, CodeInternal},
+		{ErrInvalidBody, http.StatusBadRequest, CodeBadRequest},
+		{goerrors.New("unknown"), http.StatusInternalServerError, CodeInternal},
+	}
+
+	for _, tt := range tests {
+		status, code := errorToStatus(tt.err)
+		require.Equal(t, tt.status, status, tt.err.Error())
+		require.Equal(t, tt.code, code, tt.err.Error())
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	t.Run("hides internal errors", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/subscribe", nil)
+		req.Header.Set("X-Request-ID", "req-1")
+		rec := httptest.NewRecorder()
+
+		writeError(rec, req, errors.WrapInternal(goerrors.New("password authentication failed"), "subscriptionService.NewSubscription"))
+
+		require.Equal(t, http.StatusInternalServerError, rec.Code)
+		require.Equal(t, "req-1", rec.Header().Get("X-Request-ID"))
+
+		var body dto.StatusResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+		require.Equal(t, dto.StatusResponse{Code: CodeInternal, Message: "internal server error", RequestID: "req-1"}, body)
+	})
+
+	t.Run("tells reason of client errors", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/subscribe", nil)
+		req.Header.Set("X-Request-ID", "req-1")
+		rec := httptest.NewRecorder()
+
+		writeError(rec, req, errors.Chain(errors.WrapDomain(domain.ErrSubscriptionExist), "subscriptionService.NewSubscription"))
+
+		require.Equal(t, http.StatusConflict, rec.Code)
+
+		var body dto.StatusResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+		require.Equal(t, CodeConflict, body.Code)
+		require.Equal(t, "subscription_exists", body.Reason)
+	})
+
+	t.Run("router responds with envelope", func(t *testing.T) {
+		router := NewMuxRouter()
+		router.Route("/subscribe", http.MethodPost, func(w http.ResponseWriter, r *http.Request) {})
+
+		for path, status := range map[string]int{
+			"/subscribe": http.StatusMethodNotAllowed,
+			"/unknown":   http.StatusNotFound,
+		} {
+			rec := httptest.NewRecorder()
+			router.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+			require.Equal(t, status, rec.Code, path)
+
+			var body dto.StatusResponse
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+			require.NotEmpty(t, body.RequestID)
+		}
+	})
+}
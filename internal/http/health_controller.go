@@ -0,0 +1,48 @@
+package http
+
+import (
+	"net/http"
+	"parser/internal/health"
+	"parser/internal/http/dto"
+	"time"
+)
+
+// Liveness probe. 503 means process should be restarted
+func (s *HTTPServer) Healthz(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, s.health.Liveness(r.Context()))
+}
+
+// Readiness probe. 503 means process should not receive traffic
+func (s *HTTPServer) Readyz(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, s.health.Readiness(r.Context()))
+}
+
+func writeHealth(w http.ResponseWriter, report *health.Report) {
+	out := &dto.HealthResponse{
+		Status:     string(report.Status),
+		Components: make([]*dto.ComponentHealth, 0, len(report.Components)),
+	}
+
+	for _, component := range report.Components {
+		c := &dto.ComponentHealth{
+			Name:       component.Name,
+			Status:     string(component.Status),
+			DurationMS: float64(component.Duration) / float64(time.Millisecond),
+		}
+
+		if component.Err != nil {
+			c.Error = component.Err.Error()
+		}
+
+		out.Components = append(out.Components, c)
+	}
+
+	status := http.StatusOK
+	if report.Status != health.StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+
+	// Probes must never see stale state
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, status, out)
+}
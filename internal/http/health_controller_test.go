@@ -0,0 +1,64 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"parser/client"
+	"parser/internal/domain/services"
+	"parser/internal/health"
+	"parser/internal/http/dto"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthProbes(t *testing.T) {
+	registry := health.NewRegistry()
+	registry.Register(&health.Check{Name: "postgres", Run: func(ctx context.Context) error { return errors.New("connection refused") }})
+	registry.Register(&health.Check{Name: "proxy", Liveness: true, Run: func(ctx context.Context) error { return nil }})
+
+	srv := NewHTTPServer(&ServerConfig{
+		Router:   NewMuxRouter(),
+		Services: &services.Services{AuthService: new(fakeAuthService)},
+		Health:   registry,
+	})
+
+	ts := httptest.NewServer(srv.server.Handler)
+	defer ts.Close()
+
+	c := client.New(ts.URL)
+
+	live, err := c.Healthz(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "ok", live.Status)
+	require.Len(t, live.Components, 1)
+	require.Equal(t, "proxy", live.Components[0].Name)
+
+	_, err = c.Readyz(context.Background())
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+
+	rec := httptest.NewRecorder()
+	srv.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	var out dto.HealthResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&out))
+
+	for _, component := range out.Components {
+		component.DurationMS = 0
+	}
+
+	require.Equal(t, &dto.HealthResponse{
+		Status: "fail",
+		Components: []*dto.ComponentHealth{
+			{Name: "postgres", Status: "fail", Error: "connection refused"},
+			{Name: "proxy", Status: "ok"},
+		},
+	}, &out)
+}
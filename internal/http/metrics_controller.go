@@ -0,0 +1,15 @@
+package http
+
+import (
+	"net/http"
+)
+
+// Scraped by Prometheus
+func (s *HTTPServer) Metrics(w http.ResponseWriter, r *http.Request) {
+	if s.metrics == nil {
+		writeError(w, r, ErrNotFound)
+		return
+	}
+
+	s.metrics.ServeHTTP(w, r)
+}
@@ -0,0 +1,301 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"parser/internal/logger"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	ErrBodyTooLarge = errors.New("request body is too large")
+
+	errPanic = errors.New("handler panicked")
+)
+
+type requestIDKey struct{}
+
+// RequestIDFromContext returns ID assigned by RequestID middleware
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// RequestID reuses client's X-Request-ID if any, otherwise generates one.
+// ID is echoed in response and put into request context along with log field
+func RequestID() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(requestIDHeader)
+			if requestID == "" || len(requestID) > 64 {
+				requestID = uuid.NewString()
+			}
+
+			w.Header().Set(requestIDHeader, requestID)
+
+			ctx := context.WithValue(r.Context(), requestIDKey{}, requestID)
+			ctx = logger.WithFields(ctx, logger.RequestID(requestID))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Remembers status and size of response for access log
+type statusRecorder struct {
+	http.ResponseWriter
+
+	status int
+	size   int
+}
+
+func (sr *statusRecorder) WriteHeader(status int) {
+	if sr.status == 0 {
+		sr.status = status
+	}
+
+	sr.ResponseWriter.WriteHeader(status)
+}
+
+func (sr *statusRecorder) Write(b []byte) (int, error) {
+	if sr.status == 0 {
+		sr.status = http.StatusOK
+	}
+
+	n, err := sr.ResponseWriter.Write(b)
+	sr.size += n
+
+	return n, err
+}
+
+// Keeps streaming responses working through the wrapper
+func (sr *statusRecorder) Flush() {
+	if f, ok := sr.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+type TracingOptions struct {
+	// Optional. Global provider if nil, see tracing.Setup
+	TracerProvider trace.TracerProvider
+	// Optional. Global propagator if nil
+	Propagator propagation.TextMapPropagator
+}
+
+// Tracing starts server span per request. Trace of caller is continued
+// if request carries W3C traceparent header. Span is renamed after matched route by router
+func Tracing(opts *TracingOptions) Middleware {
+	provider := opts.TracerProvider
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+
+	propagator := opts.Propagator
+	if propagator == nil {
+		propagator = otel.GetTextMapPropagator()
+	}
+
+	tracer := provider.Tracer("parser/internal/http")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := tracer.Start(ctx, r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPMethodKey.String(r.Method),
+					semconv.HTTPTargetKey.String(r.URL.Path),
+					attribute.String("request_id", RequestIDFromContext(ctx)),
+				),
+			)
+			defer span.End()
+
+			rec := &statusRecorder{ResponseWriter: w}
+			next.ServeHTTP(rec, r.WithContext(ctx))
+
+			if rec.status == 0 {
+				rec.status = http.StatusOK
+			}
+
+			span.SetAttributes(semconv.HTTPStatusCodeKey.Int(rec.status))
+			if rec.status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(rec.status))
+			}
+		})
+	}
+}
+
+// Logging writes access log entry per request.
+// log is put into request context for handlers, see logger.FromContext
+func Logging(log logger.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w}
+
+			r = r.WithContext(logger.NewContext(r.Context(), log))
+			next.ServeHTTP(rec, r)
+
+			if rec.status == 0 {
+				rec.status = http.StatusOK
+			}
+
+			log.WithContext(r.Context()).Info("request served",
+				logger.String("method", r.Method),
+				logger.String("path", r.URL.Path),
+				logger.Int("status", rec.status),
+				logger.Int("size", rec.size),
+				logger.Duration("elapsed", time.Since(start)),
+			)
+		})
+	}
+}
+
+// Recovery turns panic of handler into 500 instead of dropped connection
+func Recovery() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+
+				// Aborts response on purpose, see http.ErrAbortHandler
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				logger.FromContext(r.Context()).Error("handler panicked",
+					logger.String("method", r.Method),
+					logger.String("path", r.URL.Path),
+					logger.String("panic", fmt.Sprint(rec)),
+					logger.String("stack", string(debug.Stack())),
+				)
+
+				writeError(w, r, errPanic)
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type CORSOptions struct {
+	// Origins allowed to call API from browser.
+	// "*" allows any. CORS headers are not sent if empty
+	AllowedOrigins []string
+
+	// How long preflight response could be cached
+	MaxAge time.Duration
+}
+
+var (
+	corsMethods = strings.Join([]string{
+		http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+	}, ", ")
+	// Browser clients could continue their traces, see Tracing
+	corsHeaders = strings.Join([]string{"Content-Type", "Authorization", requestIDHeader, "traceparent", "tracestate"}, ", ")
+)
+
+// CORS allows browser clients of configured origins.
+// Answers preflight requests itself
+func CORS(opts *CORSOptions) Middleware {
+	allowed := make(map[string]bool, len(opts.AllowedOrigins))
+	for _, origin := range opts.AllowedOrigins {
+		allowed[origin] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" || !(allowed["*"] || allowed[origin]) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			header := w.Header()
+			header.Add("Vary", "Origin")
+			header.Set("Access-Control-Allow-Origin", origin)
+			header.Set("Access-Control-Expose-Headers", requestIDHeader)
+
+			if r.Method != http.MethodOptions || r.Header.Get("Access-Control-Request-Method") == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			header.Set("Access-Control-Allow-Methods", corsMethods)
+			header.Set("Access-Control-Allow-Headers", corsHeaders)
+			if opts.MaxAge > 0 {
+				header.Set("Access-Control-Max-Age", strconv.Itoa(int(opts.MaxAge.Seconds())))
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+// BodyLimit rejects requests with body larger than limit bytes
+func BodyLimit(limit int64) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				writeError(w, r, ErrBodyTooLarge)
+				return
+			}
+
+			// Body of unknown length fails on read past limit
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type timeoutKey struct{}
+
+// Timeout cancels request context after d. Zero disables it.
+// Long-lived routes are exempted at registration, see NoTimeout
+func Timeout(d time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if d <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithCancel(r.Context())
+			defer cancel()
+
+			// Timer rather than deadline so route could stop it
+			timer := time.AfterFunc(d, cancel)
+			defer timer.Stop()
+
+			ctx = context.WithValue(ctx, timeoutKey{}, timer)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// NoTimeout lifts limit of Timeout middleware for route, e.g. event stream
+func NoTimeout(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if timer, ok := r.Context().Value(timeoutKey{}).(*time.Timer); ok {
+			timer.Stop()
+		}
+
+		h(w, r)
+	}
+}
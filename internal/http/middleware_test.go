@@ -0,0 +1,217 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	apperrors "parser/internal/errors"
+	"parser/internal/http/dto"
+	"parser/internal/logger"
+	"parser/internal/logger/loggertest"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddlewares(t *testing.T) {
+	newRouter := func(h http.HandlerFunc) http.Handler {
+		router := NewMuxRouter()
+		router.Use(
+			RequestID(),
+			Logging(logger.Nop()),
+			Recovery(),
+			CORS(&CORSOptions{AllowedOrigins: []string{"https://example.com"}}),
+			BodyLimit(16),
+		)
+		router.Post("/subscribe", h)
+
+		return router.Handler()
+	}
+
+	t.Run("propagates request id", func(t *testing.T) {
+		var got string
+		handler := newRouter(func(w http.ResponseWriter, r *http.Request) {
+			got = RequestIDFromContext(r.Context())
+			writeMessage(w, r, http.StatusOK, "ok")
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/subscribe", nil)
+		req.Header.Set("X-Request-ID", "req-1")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		require.Equal(t, "req-1", got)
+		require.Equal(t, "req-1", rec.Header().Get("X-Request-ID"))
+
+		var body dto.StatusResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+		require.Equal(t, "req-1", body.RequestID)
+	})
+
+	t.Run("logs with request id", func(t *testing.T) {
+		log, logs := loggertest.New()
+
+		router := NewMuxRouter()
+		router.Use(RequestID(), Logging(log), Recovery())
+		router.Post("/subscribe", func(w http.ResponseWriter, r *http.Request) {
+			logger.FromContext(r.Context()).Info("handling")
+			panic("nil map")
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/subscribe", nil)
+		req.Header.Set("X-Request-ID", "req-2")
+		router.Handler().ServeHTTP(httptest.NewRecorder(), req)
+
+		entries := logs.All()
+		require.Len(t, entries, 3)
+		require.Equal(t, "handling", entries[0].Message)
+		require.Equal(t, "handler panicked", entries[1].Message)
+		require.Equal(t, "request served", entries[2].Message)
+
+		for _, entry := range entries {
+			require.Equal(t, "req-2", entry.ContextMap()["request_id"])
+		}
+
+		require.Equal(t, int64(http.StatusInternalServerError), entries[2].ContextMap()["status"])
+	})
+
+	t.Run("recovers from panic", func(t *testing.T) {
+		handler := newRouter(func(w http.ResponseWriter, r *http.Request) {
+			panic("nil map")
+		})
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/subscribe", nil))
+
+		require.Equal(t, http.StatusInternalServerError, rec.Code)
+
+		var body dto.StatusResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+		require.Equal(t, CodeInternal, body.Code)
+		require.NotEmpty(t, body.RequestID)
+	})
+
+	t.Run("answers preflight of allowed origins only", func(t *testing.T) {
+		handler := newRouter(func(w http.ResponseWriter, r *http.Request) {})
+
+		for origin, allowed := range map[string]bool{
+			"https://example.com": true,
+			"https://evil.com":    false,
+		} {
+			req := httptest.NewRequest(http.MethodOptions, "/subscribe", nil)
+			req.Header.Set("Origin", origin)
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if allowed {
+				require.Equal(t, http.StatusNoContent, rec.Code)
+				require.Equal(t, origin, rec.Header().Get("Access-Control-Allow-Origin"))
+				require.Contains(t, rec.Header().Get("Access-Control-Allow-Methods"), http.MethodPost)
+			} else {
+				require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+				require.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+			}
+		}
+	})
+
+	t.Run("limits body size", func(t *testing.T) {
+		handler := newRouter(func(w http.ResponseWriter, r *http.Request) {
+			var inp dto.SubscribeRequest
+			if err := decodeBody(r, &inp); err != nil {
+				writeError(w, r, err)
+				return
+			}
+
+			writeMessage(w, r, http.StatusCreated, "ok")
+		})
+
+		body := `{"advert_url": "https://www.avito.ru/moskva/telefony/iphone_123"}`
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/subscribe", strings.NewReader(body)))
+		require.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+		// Unknown length is cut while reading
+		req := httptest.NewRequest(http.MethodPost, "/subscribe", strings.NewReader(body))
+		req.ContentLength = -1
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/subscribe", strings.NewReader("{}")))
+		require.Equal(t, http.StatusCreated, rec.Code)
+	})
+
+	t.Run("cancels requests of routes not exempted from timeout", func(t *testing.T) {
+		wait := func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+				writeMessage(w, r, http.StatusOK, "canceled")
+			case <-time.After(200 * time.Millisecond):
+				writeMessage(w, r, http.StatusOK, "finished")
+			}
+		}
+
+		router := NewMuxRouter()
+		router.Use(Timeout(10 * time.Millisecond))
+		router.Get("/subscriptions", wait)
+		router.Get("/prices/stream", NoTimeout(wait))
+		handler := router.Handler()
+
+		serve := func(path string) string {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			// Header doesn't exempt request
+			req.Header.Set("Accept", "text/event-stream")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			var body dto.StatusResponse
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+			return body.Message
+		}
+
+		require.Equal(t, "canceled", serve("/subscriptions"))
+		require.Equal(t, "finished", serve("/prices/stream"))
+	})
+
+	t.Run("traces request under route name", func(t *testing.T) {
+		recorder := tracetest.NewSpanRecorder()
+
+		router := NewMuxRouter()
+		router.Use(RequestID(), Tracing(&TracingOptions{
+			TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)),
+			Propagator:     propagation.TraceContext{},
+		}))
+		router.Get("/subscriptions/{advert_id}", func(w http.ResponseWriter, r *http.Request) {
+			writeError(w, r, apperrors.WrapInternal(errors.New("connection refused"), "subscriptionService.GetSubscription.GetByID"))
+		})
+
+		const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+		req := httptest.NewRequest(http.MethodGet, "/subscriptions/42", nil)
+		req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+		router.Handler().ServeHTTP(httptest.NewRecorder(), req)
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+
+		span := spans[0]
+		require.Equal(t, "GET /subscriptions/{advert_id}", span.Name())
+		require.Equal(t, traceID, span.SpanContext().TraceID().String())
+		require.Equal(t, codes.Error, span.Status().Code)
+
+		require.Len(t, span.Events(), 1)
+		require.Contains(t, span.Events()[0].Attributes, attribute.String("error.trace", "subscriptionService.GetSubscription.GetByID"))
+	})
+}
@@ -0,0 +1,18 @@
+package http
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// OpenAPI 3 specification of every route.
+// Keep in sync with HTTPServer.routes, see openapi_test.go
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+func (s *HTTPServer) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(openAPISpec)
+}
@@ -0,0 +1,382 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"parser/client"
+	domain "parser/internal/domain/models"
+	"parser/internal/domain/services"
+	"parser/internal/errors"
+	"parser/internal/http/dto"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Parts of OpenAPI document checked by contract tests
+type openAPIDoc struct {
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components struct {
+		Schemas   map[string]*openAPISchema `json:"schemas"`
+		Responses map[string]struct {
+			Content map[string]struct {
+				Schema *openAPISchema `json:"schema"`
+			} `json:"content"`
+		} `json:"responses"`
+	} `json:"components"`
+}
+
+type openAPIOperation struct {
+	OperationID string `json:"operationId"`
+	// Empty list means operation is public
+	Security  []map[string][]string `json:"security"`
+	Scope     string                `json:"x-scope"`
+	Responses map[string]struct {
+		Ref     string `json:"$ref"`
+		Content map[string]struct {
+			Schema *openAPISchema `json:"schema"`
+		} `json:"content"`
+	} `json:"responses"`
+}
+
+type openAPISchema struct {
+	Ref        string                     `json:"$ref"`
+	Type       string                     `json:"type"`
+	Items      *openAPISchema             `json:"items"`
+	Required   []string                   `json:"required"`
+	Properties map[string]json.RawMessage `json:"properties"`
+}
+
+func loadOpenAPI(t *testing.T) *openAPIDoc {
+	t.Helper()
+
+	var doc openAPIDoc
+	require.NoError(t, json.Unmarshal(openAPISpec, &doc))
+
+	return &doc
+}
+
+// Finds operation by concrete path, e.g. /subscriptions/ad-1
+func (doc *openAPIDoc) operation(method, path string) *openAPIOperation {
+	segments := strings.Split(path, "/")
+
+	for template, operations := range doc.Paths {
+		rt := &route{segments: strings.Split(template, "/")}
+		if _, ok := rt.match(segments); ok {
+			return operations[strings.ToLower(method)]
+		}
+	}
+
+	return nil
+}
+
+// Resolves schema of response body. Arrays are resolved to schema of items
+func (doc *openAPIDoc) responseSchema(t *testing.T, op *openAPIOperation, status int) (*openAPISchema, bool) {
+	t.Helper()
+
+	response, ok := op.Responses[strconv.Itoa(status)]
+	require.True(t, ok, "%s does not document %d", op.OperationID, status)
+
+	schema := response.Content["application/json"].Schema
+	if response.Ref != "" {
+		shared := doc.Components.Responses[strings.TrimPrefix(response.Ref, "#/components/responses/")]
+		schema = shared.Content["application/json"].Schema
+	}
+
+	if schema == nil {
+		return nil, false
+	}
+
+	isArray := schema.Type == "array"
+	if isArray {
+		schema = schema.Items
+	}
+
+	if schema.Ref != "" {
+		schema = doc.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+	}
+
+	return schema, isArray
+}
+
+func newContractServer(auth services.AuthService, subscriptions services.SubscriptionService) *HTTPServer {
+	return NewHTTPServer(&ServerConfig{
+		Router: NewMuxRouter(),
+		Services: &services.Services{
+			AuthService:         auth,
+			SubscriptionService: subscriptions,
+		},
+	})
+}
+
+func TestOpenAPIRoutes(t *testing.T) {
+	doc := loadOpenAPI(t)
+	srv := newContractServer(new(fakeAuthService), nil)
+
+	var routed []string
+	for pattern, rt := range srv.router.(*muxRouter).routes {
+		for method := range rt.methods {
+			routed = append(routed, method+" "+pattern)
+		}
+	}
+
+	var documented []string
+	for path, operations := range doc.Paths {
+		for method := range operations {
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+		}
+	}
+
+	sort.Strings(routed)
+	sort.Strings(documented)
+	require.Equal(t, routed, documented)
+}
+
+func TestOpenAPISecurity(t *testing.T) {
+	doc := loadOpenAPI(t)
+	handler := newContractServer(new(fakeAuthService), nil).server.Handler
+
+	for path, operations := range doc.Paths {
+		for method, op := range operations {
+			isPublic := op.Security != nil && len(op.Security) == 0
+			require.Equal(t, isPublic, op.Scope == "", "%s should either be public or have x-scope", op.OperationID)
+
+			if isPublic {
+				continue
+			}
+
+			_, err := domain.ParseScopes([]string{op.Scope})
+			require.NoError(t, err, op.OperationID)
+
+			concrete := strings.NewReplacer("{advert_id}", "ad-1", "{api_key_id}", "key-1", "{snapshot_id}", "1").Replace(path)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(strings.ToUpper(method), concrete, nil))
+
+			require.Equal(t, http.StatusUnauthorized, rec.Code, op.OperationID)
+		}
+	}
+}
+
+// Both server DTOs and client types should have exactly the documented fields
+func TestOpenAPISchemas(t *testing.T) {
+	doc := loadOpenAPI(t)
+
+	types := map[string][]interface{}{
+		"StatusResponse":              {dto.StatusResponse{}, client.StatusResponse{}},
+		"SubscribeRequest":            {dto.SubscribeRequest{}, client.SubscribeRequest{}},
+		"SubscriptionChannelsRequest": {dto.SubscriptionChannelsRequest{}, client.SubscriptionChannelsRequest{}},
+		"ChannelsRequest":             {dto.ChannelsRequest{}, client.ChannelsRequest{}},
+		"LocaleRequest":               {dto.LocaleRequest{}, client.LocaleRequest{}},
+		"ScheduleRequest":             {dto.ScheduleRequest{}, client.ScheduleRequest{}},
+		"QuietHours":                  {dto.QuietHours{}, client.QuietHours{}},
+		"EmailRequest":                {dto.EmailRequest{}, client.EmailRequest{}},
+		"WebhookRequest":              {dto.WebhookRequest{}, client.WebhookRequest{}},
+		"UpdateSubscriptionRequest":   {dto.UpdateSubscriptionRequest{}, client.UpdateSubscriptionRequest{}},
+		"APIKeyRequest":               {dto.APIKeyRequest{}, client.APIKeyRequest{}},
+		"WebhookResponse":             {dto.WebhookResponse{}, client.WebhookResponse{}},
+		"SubscriptionResponse":        {dto.SubscriptionResponse{}, client.SubscriptionResponse{}},
+		"AdvertResponse":              {dto.AdvertResponse{}, client.AdvertResponse{}},
+		"SubscriptionListResponse":    {dto.SubscriptionListResponse{}, client.SubscriptionListResponse{}},
+		"APIKeyResponse":              {dto.APIKeyResponse{}, client.APIKeyResponse{}},
+		"SnapshotResponse":            {dto.SnapshotResponse{}, client.SnapshotResponse{}},
+		"PriceEvent":                  {dto.PriceEvent{}, client.PriceEvent{}},
+		"HealthResponse":              {dto.HealthResponse{}, client.HealthResponse{}},
+		"ComponentHealth":             {dto.ComponentHealth{}, client.ComponentHealth{}},
+	}
+
+	for name, schema := range doc.Components.Schemas {
+		implementations, ok := types[name]
+		require.True(t, ok, "schema %s has no DTO", name)
+
+		var properties []string
+		for property := range schema.Properties {
+			properties = append(properties, property)
+		}
+
+		for _, v := range implementations {
+			require.ElementsMatch(t, properties, jsonFields(v), "%s of %s", name, reflect.TypeOf(v).PkgPath())
+		}
+	}
+
+	require.Len(t, types, len(doc.Components.Schemas))
+}
+
+func jsonFields(v interface{}) []string {
+	typ := reflect.TypeOf(v)
+
+	fields := make([]string, 0, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		tag := typ.Field(i).Tag.Get("json")
+		if name := strings.Split(tag, ",")[0]; name != "" && name != "-" {
+			fields = append(fields, name)
+		}
+	}
+
+	return fields
+}
+
+type contractSubscriptionService struct {
+	services.SubscriptionService
+
+	subscriptions map[string]*domain.Subscription
+}
+
+func (f *contractSubscriptionService) NewSubscription(ctx context.Context, dto *dto.SubscribeRequest) error {
+	return nil
+}
+
+func (f *contractSubscriptionService) ListSubscriptions(ctx context.Context, telegramID int64, limit, offset int) ([]*domain.Subscription, int, error) {
+	out := make([]*domain.Subscription, 0, len(f.subscriptions))
+	for _, subscription := range f.subscriptions {
+		out = append(out, subscription)
+	}
+
+	return out, len(out), nil
+}
+
+func (f *contractSubscriptionService) GetSubscription(ctx context.Context, telegramID int64, advertID string) (*domain.Subscription, error) {
+	subscription, ok := f.subscriptions[advertID]
+	if !ok {
+		return nil, errors.WrapDomain(domain.ErrNoSubscription)
+	}
+
+	return subscription, nil
+}
+
+func (f *contractSubscriptionService) DeleteSubscription(ctx context.Context, telegramID int64, advertID string) error {
+	if _, ok := f.subscriptions[advertID]; !ok {
+		return errors.WrapDomain(domain.ErrNoSubscription)
+	}
+
+	delete(f.subscriptions, advertID)
+	return nil
+}
+
+func (f *contractSubscriptionService) UpdateSubscription(ctx context.Context, telegramID int64, advertID string, dto *dto.UpdateSubscriptionRequest) (*domain.Subscription, error) {
+	subscription, err := f.GetSubscription(ctx, telegramID, advertID)
+	if err != nil {
+		return nil, err
+	}
+
+	if dto.Muted != nil {
+		subscription.SetMuted(*dto.Muted)
+	}
+
+	return subscription, nil
+}
+
+type recordedResponse struct {
+	method string
+	path   string
+	status int
+	body   []byte
+}
+
+// Every response client gets should be documented with its fields
+func TestClientContract(t *testing.T) {
+	doc := loadOpenAPI(t)
+
+	const advertID = "6f1c9a4e-8b1e-4c8e-9a53-0c3f4c1a2b7d"
+
+	writeKey, err := domain.NewAPIKeyFromPlain("writer", "avt_write", []domain.Scope{domain.ScopeSubscriptionsRead, domain.ScopeSubscriptionsWrite})
+	require.NoError(t, err)
+
+	subscription := domain.NewSubscription("sub-1", advertID)
+	subscription.SetCreatedAt(time.Date(2023, 1, 10, 12, 0, 0, 0, time.UTC))
+	subscription.AttachAdvert(domain.NewAdvert(advertID, "https://www.avito.ru/moskva/telefony/iphone_123", "iPhone", 800, 1000, true))
+
+	srv := newContractServer(
+		&fakeAuthService{apiKeys: map[string]*domain.APIKey{"avt_write": writeKey}},
+		&contractSubscriptionService{subscriptions: map[string]*domain.Subscription{advertID: subscription}},
+	)
+
+	var recorded []*recordedResponse
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := httptest.NewRecorder()
+		srv.server.Handler.ServeHTTP(rec, r)
+
+		recorded = append(recorded, &recordedResponse{
+			method: r.Method,
+			path:   r.URL.Path,
+			status: rec.Code,
+			body:   rec.Body.Bytes(),
+		})
+
+		for key, values := range rec.Header() {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(rec.Code)
+		w.Write(rec.Body.Bytes())
+	}))
+	defer ts.Close()
+
+	ctx := context.Background()
+	c := client.New(ts.URL, client.WithAPIKey("avt_write"))
+
+	_, err = c.Subscribe(ctx, &client.SubscribeRequest{TelegramID: 1, AdvertURL: "https://www.avito.ru/moskva/telefony/iphone_123"})
+	require.NoError(t, err)
+
+	list, err := c.ListSubscriptions(ctx, 1, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, list.Total)
+	require.Equal(t, "iPhone", list.Items[0].Advert.Title)
+
+	muted := true
+	updated, err := c.UpdateSubscription(ctx, 1, advertID, &client.UpdateSubscriptionRequest{Muted: &muted})
+	require.NoError(t, err)
+	require.True(t, updated.Muted)
+
+	require.NoError(t, c.DeleteSubscription(ctx, 1, advertID))
+
+	_, err = c.GetSubscription(ctx, 1, advertID)
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	require.Equal(t, CodeNotFound, apiErr.Code)
+
+	_, err = c.ListAPIKeys(ctx)
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+
+	_, err = client.New(ts.URL).ListSubscriptions(ctx, 1, 0, 0)
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+
+	spec, err := client.New(ts.URL).OpenAPI(ctx)
+	require.NoError(t, err)
+	require.JSONEq(t, string(openAPISpec), string(spec))
+
+	for _, res := range recorded {
+		op := doc.operation(res.method, res.path)
+		require.NotNil(t, op, "%s %s is not documented", res.method, res.path)
+
+		schema, isArray := doc.responseSchema(t, op, res.status)
+		if schema == nil || len(schema.Required) == 0 {
+			continue
+		}
+
+		var objects []map[string]json.RawMessage
+		if isArray {
+			require.NoError(t, json.NewDecoder(bytes.NewReader(res.body)).Decode(&objects))
+		} else {
+			var object map[string]json.RawMessage
+			require.NoError(t, json.NewDecoder(bytes.NewReader(res.body)).Decode(&object))
+			objects = append(objects, object)
+		}
+
+		for _, object := range objects {
+			for _, field := range schema.Required {
+				require.Contains(t, object, field, "%s %d", op.OperationID, res.status)
+			}
+		}
+	}
+}
@@ -1,23 +1,72 @@
 package http
 
 import (
+	"context"
 	"net/http"
+	"strings"
+	"sync"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// Wraps handler with cross-cutting behavior, e.g. logging
+type Middleware func(http.Handler) http.Handler
+
 type Router interface {
+	// Path could contain parameters as whole segments,
+	// e.g. /subscriptions/{advert_id}. See PathParam
 	Route(path, method string, h http.HandlerFunc)
+
+	Get(path string, h http.HandlerFunc)
+	Post(path string, h http.HandlerFunc)
+	Put(path string, h http.HandlerFunc)
+	Patch(path string, h http.HandlerFunc)
+	Delete(path string, h http.HandlerFunc)
+
+	// Middlewares are applied in order of Use calls, the first one is the outermost.
+	// Should be called before Handler
+	Use(mw ...Middleware)
 	Handler() http.Handler
 }
 
 type muxRouter struct {
 	m      *http.ServeMux
 	prefix string
+
+	mu *sync.RWMutex
+	// Handlers of every path by method.
+	// http.ServeMux allows only one handler per path
+	routes map[string]*route
+	// Routes with parameters by their static prefix.
+	// Prefix is registered in http.ServeMux as subtree
+	paramRoutes map[string][]*route
+
+	middlewares []Middleware
+}
+
+type route struct {
+	pattern string
+	// Segments of pattern. Parameters are {name}
+	segments []string
+	methods  map[string]http.HandlerFunc
 }
 
+type pathParamsKey struct{}
+
 func NewMuxRouter() Router {
+	m := http.NewServeMux()
+	// Unknown paths get JSON error as well
+	m.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		writeError(w, req, ErrNotFound)
+	})
+
 	return &muxRouter{
-		m:      http.NewServeMux(),
-		prefix: "",
+		m:           m,
+		prefix:      "",
+		mu:          new(sync.RWMutex),
+		routes:      make(map[string]*route),
+		paramRoutes: make(map[string][]*route),
 	}
 }
 
@@ -26,20 +75,152 @@ func (r *muxRouter) SetGlobalPrefix(prefix string) {
 }
 
 func (r *muxRouter) Route(path, method string, h http.HandlerFunc) {
-	r.m.HandleFunc(r.prefix+path, validateMethod(h, method))
+	pattern := r.prefix + path
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rt, ok := r.routes[pattern]
+	if !ok {
+		rt = &route{
+			pattern:  pattern,
+			segments: strings.Split(pattern, "/"),
+			methods:  make(map[string]http.HandlerFunc),
+		}
+		r.routes[pattern] = rt
+
+		r.register(pattern, rt)
+	}
+
+	rt.methods[method] = h
+}
+
+func (r *muxRouter) Get(path string, h http.HandlerFunc) {
+	r.Route(path, http.MethodGet, h)
+}
+
+func (r *muxRouter) Post(path string, h http.HandlerFunc) {
+	r.Route(path, http.MethodPost, h)
+}
+
+func (r *muxRouter) Put(path string, h http.HandlerFunc) {
+	r.Route(path, http.MethodPut, h)
+}
+
+func (r *muxRouter) Patch(path string, h http.HandlerFunc) {
+	r.Route(path, http.MethodPatch, h)
+}
+
+func (r *muxRouter) Delete(path string, h http.HandlerFunc) {
+	r.Route(path, http.MethodDelete, h)
+}
+
+func (r *muxRouter) Use(mw ...Middleware) {
+	r.middlewares = append(r.middlewares, mw...)
 }
 
 func (r *muxRouter) Handler() http.Handler {
-	return r.m
+	var h http.Handler = r.m
+	for i := len(r.middlewares) - 1; i >= 0; i-- {
+		h = r.middlewares[i](h)
+	}
+
+	return h
+}
+
+// Must be called under lock
+func (r *muxRouter) register(pattern string, rt *route) {
+	i := strings.Index(pattern, "{")
+	if i == -1 {
+		r.m.HandleFunc(pattern, func(w http.ResponseWriter, req *http.Request) {
+			r.dispatchMethod(w, req, rt)
+		})
+		return
+	}
+
+	// Everything before the first parameter, e.g. /subscriptions/
+	static := pattern[:i]
+
+	if _, ok := r.paramRoutes[static]; !ok {
+		r.m.HandleFunc(static, r.dispatchParams(static))
+	}
+
+	r.paramRoutes[static] = append(r.paramRoutes[static], rt)
 }
 
-func validateMethod(h http.HandlerFunc, method string) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != method {
-			w.WriteHeader(http.StatusMethodNotAllowed)
+func (r *muxRouter) dispatchParams(static string) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		segments := strings.Split(req.URL.Path, "/")
+
+		r.mu.RLock()
+		var (
+			matched *route
+			params  map[string]string
+		)
+		for _, rt := range r.paramRoutes[static] {
+			if p, ok := rt.match(segments); ok {
+				matched, params = rt, p
+				break
+			}
+		}
+		r.mu.RUnlock()
+
+		if matched == nil {
+			writeError(w, req, ErrNotFound)
 			return
 		}
 
-		h.ServeHTTP(w, r)
+		ctx := context.WithValue(req.Context(), pathParamsKey{}, params)
+		r.dispatchMethod(w, req.WithContext(ctx), matched)
+	}
+}
+
+func (r *muxRouter) dispatchMethod(w http.ResponseWriter, req *http.Request, rt *route) {
+	r.mu.RLock()
+	h, ok := rt.methods[req.Method]
+	r.mu.RUnlock()
+
+	if !ok {
+		writeError(w, req, ErrMethodNotAllowed)
+		return
 	}
+
+	// Span started by Tracing is named after route rather than path with IDs
+	span := trace.SpanFromContext(req.Context())
+	span.SetName(req.Method + " " + rt.pattern)
+	span.SetAttributes(semconv.HTTPRouteKey.String(rt.pattern))
+
+	h.ServeHTTP(w, req)
+}
+
+// Parameters match any non-empty segment
+func (rt *route) match(segments []string) (map[string]string, bool) {
+	if len(segments) != len(rt.segments) {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	for i, segment := range rt.segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			if segments[i] == "" {
+				return nil, false
+			}
+
+			params[segment[1:len(segment)-1]] = segments[i]
+			continue
+		}
+
+		if segment != segments[i] {
+			return nil, false
+		}
+	}
+
+	return params, true
+}
+
+// PathParam returns value of {name} segment of matched route.
+// Empty if there is no such parameter
+func PathParam(r *http.Request, name string) string {
+	params, _ := r.Context().Value(pathParamsKey{}).(map[string]string)
+	return params[name]
 }
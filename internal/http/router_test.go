@@ -0,0 +1,71 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMuxRouter(t *testing.T) {
+	t.Run("matches path params", func(t *testing.T) {
+		router := NewMuxRouter()
+
+		var got []string
+		router.Get("/subscriptions/{advert_id}", func(w http.ResponseWriter, r *http.Request) {
+			got = append(got, "get "+PathParam(r, "advert_id"))
+		})
+		router.Delete("/subscriptions/{advert_id}", func(w http.ResponseWriter, r *http.Request) {
+			got = append(got, "delete "+PathParam(r, "advert_id"))
+		})
+		router.Get("/subscriptions/{advert_id}/prices", func(w http.ResponseWriter, r *http.Request) {
+			got = append(got, "prices "+PathParam(r, "advert_id"))
+		})
+
+		for _, tt := range []struct {
+			method string
+			path   string
+			status int
+		}{
+			{http.MethodGet, "/subscriptions/ad-1", http.StatusOK},
+			{http.MethodDelete, "/subscriptions/ad-2", http.StatusOK},
+			{http.MethodGet, "/subscriptions/ad-3/prices", http.StatusOK},
+			{http.MethodPost, "/subscriptions/ad-4", http.StatusMethodNotAllowed},
+			{http.MethodGet, "/subscriptions/", http.StatusNotFound},
+			{http.MethodGet, "/subscriptions/ad-5/unknown", http.StatusNotFound},
+		} {
+			rec := httptest.NewRecorder()
+			router.Handler().ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+			require.Equal(t, tt.status, rec.Code, tt.method+" "+tt.path)
+		}
+
+		require.Equal(t, []string{"get ad-1", "delete ad-2", "prices ad-3"}, got)
+	})
+
+	t.Run("applies middlewares in order", func(t *testing.T) {
+		router := NewMuxRouter()
+
+		var order []string
+		mark := func(name string) Middleware {
+			return func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					order = append(order, name)
+					next.ServeHTTP(w, r)
+				})
+			}
+		}
+
+		router.Use(mark("first"), mark("second"))
+		router.Post("/subscribe", func(w http.ResponseWriter, r *http.Request) {
+			order = append(order, "handler")
+		})
+
+		rec := httptest.NewRecorder()
+		router.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/subscribe", strings.NewReader("{}")))
+
+		require.Equal(t, []string{"first", "second", "handler"}, order)
+	})
+}
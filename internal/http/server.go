@@ -3,38 +3,119 @@ package http
 import (
 	"context"
 	"errors"
+	"net"
 	"net/http"
+	domain "parser/internal/domain/models"
 	"parser/internal/domain/services"
+	"parser/internal/health"
+	"parser/internal/logger"
 	"time"
 )
 
+// Used when ServerConfig.BodyLimit is not set
+const defaultBodyLimit = 1 << 20
+
 // Used to create HTTPServer instance
 type ServerConfig struct {
 	Router   Router
 	Addr     string
 	Services *services.Services
 
-	ReadTimeout  time.Duration
+	ReadTimeout time.Duration
+	// Deadline of request handling and of writing response.
+	// Event streams extend it per write, see NoTimeout
 	WriteTimeout time.Duration
+
+	// Maximum size of request body in bytes.
+	// 1MB if zero
+	BodyLimit int64
+	// Browser origins allowed to call API
+	CORSOrigins []string
+
+	// How often idle event stream sends comment to keep connection open.
+	// 15s if zero
+	StreamHeartbeat time.Duration
+
+	// Checks of components reported by /healthz and /readyz.
+	// Probes report no components if nil
+	Health *health.Registry
+	// Serves /metrics in Prometheus format. 404 if nil
+	Metrics http.Handler
+
+	// Optional. Receives telegram updates in webhook mode
+	TelegramWebhook *TelegramWebhook
+
+	// Optional. Access log and internal errors go there
+	Logger logger.Logger
+}
+
+type TelegramWebhook struct {
+	Path    string
+	Handler http.HandlerFunc
 }
 
 type HTTPServer struct {
 	server *http.Server
 	router Router
 
-	services *services.Services
+	services        *services.Services
+	telegramWebhook *TelegramWebhook
+	streamHeartbeat time.Duration
+	writeTimeout    time.Duration
+	health          *health.Registry
+	metrics         http.Handler
 }
 
 func NewHTTPServer(cfg *ServerConfig) *HTTPServer {
+	bodyLimit := cfg.BodyLimit
+	if bodyLimit == 0 {
+		bodyLimit = defaultBodyLimit
+	}
+
+	streamHeartbeat := cfg.StreamHeartbeat
+	if streamHeartbeat == 0 {
+		streamHeartbeat = defaultStreamHeartbeat
+	}
+
+	registry := cfg.Health
+	if registry == nil {
+		registry = health.NewRegistry()
+	}
+
+	log := cfg.Logger
+	if log == nil {
+		log = logger.Nop()
+	}
+
+	// Request ID goes first so every other middleware could log it.
+	// Access log refers to trace of request
+	cfg.Router.Use(
+		RequestID(),
+		Tracing(&TracingOptions{}),
+		Logging(log),
+		Recovery(),
+		Timeout(cfg.WriteTimeout),
+		CORS(&CORSOptions{AllowedOrigins: cfg.CORSOrigins, MaxAge: time.Hour}),
+		BodyLimit(bodyLimit),
+		Authenticate(cfg.Services.AuthService),
+	)
+
 	srv := &HTTPServer{
 		server: &http.Server{
 			Addr:         ":8000",
 			ReadTimeout:  cfg.ReadTimeout,
 			WriteTimeout: cfg.WriteTimeout,
 			Handler:      cfg.Router.Handler(),
+			// Lets event streams extend write deadline, see extendWriteDeadline
+			ConnContext: withConn,
 		},
-		router:   cfg.Router,
-		services: cfg.Services,
+		router:          cfg.Router,
+		services:        cfg.Services,
+		telegramWebhook: cfg.TelegramWebhook,
+		streamHeartbeat: streamHeartbeat,
+		writeTimeout:    cfg.WriteTimeout,
+		health:          registry,
+		metrics:         cfg.Metrics,
 	}
 
 	defer srv.routes()
@@ -44,10 +125,56 @@ func NewHTTPServer(cfg *ServerConfig) *HTTPServer {
 
 func (s *HTTPServer) routes() {
 
-	// TODO: add .post() .get() as shortcuts
-	rt := s.router.Route
+	rt := s.router
+
+	var (
+		read     = domain.ScopeSubscriptionsRead
+		write    = domain.ScopeSubscriptionsWrite
+		webhooks = domain.ScopeWebhooks
+		admin    = domain.ScopeAdmin
+	)
 
-	rt("/subscribe", http.MethodPost, s.Subscribe)
+	rt.Post("/subscribe", requireScope(write, s.Subscribe))
+	rt.Put("/subscribe/channels", requireScope(write, s.SetSubscriptionChannels))
+
+	rt.Get("/subscriptions", requireScope(read, s.ListSubscriptions))
+	rt.Get("/subscriptions/{advert_id}", requireScope(read, s.GetSubscription))
+	rt.Delete("/subscriptions/{advert_id}", requireScope(write, s.DeleteSubscription))
+	rt.Patch("/subscriptions/{advert_id}", requireScope(write, s.UpdateSubscription))
+
+	// Stream is long-lived by design
+	rt.Get("/prices/stream", NoTimeout(requireScope(read, s.StreamPrices)))
+
+	rt.Put("/channels", requireScope(write, s.SetChannels))
+	rt.Put("/locale", requireScope(write, s.SetLocale))
+	rt.Put("/schedule", requireScope(write, s.SetSchedule))
+	rt.Post("/email", requireScope(write, s.RequestEmail))
+	// Link from confirmation email, token is proof enough
+	rt.Get("/email/confirm", s.ConfirmEmail)
+
+	rt.Post("/webhooks", requireScope(webhooks, s.RegisterWebhook))
+	rt.Get("/webhooks", requireScope(webhooks, s.ListWebhooks))
+	rt.Delete("/webhooks", requireScope(webhooks, s.DeleteWebhook))
+
+	rt.Post("/api-keys", requireScope(admin, s.CreateAPIKey))
+	rt.Get("/api-keys", requireScope(admin, s.ListAPIKeys))
+	rt.Delete("/api-keys/{api_key_id}", requireScope(admin, s.RevokeAPIKey))
+
+	// Html of parsed pages for debugging of parser
+	rt.Get("/admin/snapshots", requireScope(admin, s.ListSnapshots))
+	rt.Get("/admin/snapshots/{snapshot_id}", requireScope(admin, s.DownloadSnapshot))
+
+	rt.Get("/openapi.json", s.OpenAPI)
+
+	// Probes of orchestrator
+	rt.Get("/healthz", s.Healthz)
+	rt.Get("/readyz", s.Readyz)
+	rt.Get("/metrics", s.Metrics)
+
+	// Telegram proves requests with secret token
+	if s.telegramWebhook != nil {
+		rt.Post(s.telegramWebhook.Path, s.telegramWebhook.Handler)
+	}
 }
 
 func (s *HTTPServer) Run() error {
@@ -59,6 +186,25 @@ func (s *HTTPServer) Run() error {
 	return err
 }
 
+type connKey struct{}
+
+func withConn(ctx context.Context, c net.Conn) context.Context {
+	return context.WithValue(ctx, connKey{}, c)
+}
+
+// Moves write deadline of request's connection to d from now.
+// Long-lived responses call it before every write. No-op if d is zero
+// or connection is unknown, e.g. server is not started by Run
+func extendWriteDeadline(r *http.Request, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+
+	if c, ok := r.Context().Value(connKey{}).(net.Conn); ok {
+		c.SetWriteDeadline(time.Now().Add(d))
+	}
+}
+
 func (s *HTTPServer) Shutdown(ctx context.Context) error {
 	return s.server.Shutdown(ctx)
 }
@@ -0,0 +1,80 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	domain "parser/internal/domain/models"
+	"parser/internal/errors"
+	"parser/internal/http/dto"
+	"strconv"
+)
+
+// Query: ?url=&limit=&offset=
+func (s *HTTPServer) ListSnapshots(w http.ResponseWriter, r *http.Request) {
+
+	query := r.URL.Query()
+
+	limit, err := queryInt(query.Get("limit"))
+	if err != nil {
+		writeError(w, r, ErrInvalidPagination)
+		return
+	}
+
+	offset, err := queryInt(query.Get("offset"))
+	if err != nil {
+		writeError(w, r, ErrInvalidPagination)
+		return
+	}
+
+	snapshots, err := s.services.SnapshotService.List(r.Context(), query.Get("url"), limit, offset)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	out := make([]*dto.SnapshotResponse, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		out = append(out, snapshotResponse(snapshot))
+	}
+
+	writeJSON(w, http.StatusOK, out)
+}
+
+// Path: /admin/snapshots/{snapshot_id}
+// Responds with html of page as attachment
+func (s *HTTPServer) DownloadSnapshot(w http.ResponseWriter, r *http.Request) {
+
+	snapshotID, err := strconv.ParseInt(PathParam(r, "snapshot_id"), 10, 64)
+	if err != nil {
+		writeError(w, r, ErrInvalidSnapshotID)
+		return
+	}
+
+	snapshot, err := s.services.SnapshotService.Get(r.Context(), snapshotID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	html, err := snapshot.HTML()
+	if err != nil {
+		writeError(w, r, errors.WrapInternal(err, "DownloadSnapshot.HTML"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="snapshot-%d.html"`, snapshot.SnapshotID))
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(html))
+}
+
+func snapshotResponse(snapshot *domain.Snapshot) *dto.SnapshotResponse {
+	return &dto.SnapshotResponse{
+		SnapshotID: snapshot.SnapshotID,
+		URL:        snapshot.URL(),
+		Failed:     snapshot.Failed(),
+		Error:      snapshot.Error(),
+		Size:       snapshot.Size(),
+		CreatedAt:  snapshot.CreatedAt(),
+	}
+}
@@ -0,0 +1,76 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"parser/client"
+	domain "parser/internal/domain/models"
+	"parser/internal/domain/repositories"
+	"parser/internal/domain/services"
+	"parser/internal/parser"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshots(t *testing.T) {
+	adminKey, err := domain.NewAPIKeyFromPlain("admin", "avt_admin", []domain.Scope{domain.ScopeAdmin})
+	require.NoError(t, err)
+
+	auth := &fakeAuthService{apiKeys: map[string]*domain.APIKey{"avt_admin": adminKey}}
+
+	snapshots := services.NewSnapshotService(
+		repositories.NewFSSnapshotRepo(t.TempDir()),
+		&domain.SnapshotRetention{KeepPerURL: 3, FailedMaxAge: time.Hour},
+	)
+
+	const url = "https://www.avito.ru/1"
+	html := "<html><h1>iPhone</h1></html>"
+	require.NoError(t, snapshots.Capture(context.Background(), parser.NewFailedParseResult(url, errors.New("no price"), &html)))
+
+	srv := NewHTTPServer(&ServerConfig{
+		Router:   NewMuxRouter(),
+		Services: &services.Services{AuthService: auth, SnapshotService: snapshots},
+	})
+
+	ts := httptest.NewServer(srv.server.Handler)
+	defer ts.Close()
+
+	ctx := context.Background()
+	c := client.New(ts.URL, client.WithAPIKey("avt_admin"))
+
+	list, err := c.ListSnapshots(ctx, url, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.True(t, list[0].Failed)
+	require.Equal(t, "no price", list[0].Error)
+	require.Equal(t, len(html), list[0].Size)
+
+	page, err := c.DownloadSnapshot(ctx, list[0].SnapshotID)
+	require.NoError(t, err)
+	require.Equal(t, html, string(page))
+
+	_, err = c.DownloadSnapshot(ctx, list[0].SnapshotID+1)
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	require.Equal(t, "snapshot_not_found", apiErr.Reason)
+
+	t.Run("disabled", func(t *testing.T) {
+		srv := NewHTTPServer(&ServerConfig{
+			Router:   NewMuxRouter(),
+			Services: &services.Services{AuthService: auth, SnapshotService: services.NewSnapshotService(nil, nil)},
+		})
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/admin/snapshots", nil)
+		req.Header.Set("Authorization", "Bearer avt_admin")
+		srv.server.Handler.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	})
+}
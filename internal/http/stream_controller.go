@@ -0,0 +1,159 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	domain "parser/internal/domain/models"
+	"parser/internal/http/dto"
+	"strconv"
+	"time"
+)
+
+const (
+	// Used when ServerConfig.StreamHeartbeat is not set
+	defaultStreamHeartbeat = 15 * time.Second
+	// Hint for EventSource how long to wait before reconnect
+	streamRetry = 3 * time.Second
+)
+
+var errStreamingUnsupported = errors.New("response writer does not support flushing")
+
+// Query: ?telegram_id=&advert_id=&last_event_id=
+// Last-Event-ID header takes precedence over last_event_id.
+// Streams "price" events until client disconnects.
+// "reset" event means missed changes can't be replayed, client refetches subscriptions
+func (s *HTTPServer) StreamPrices(w http.ResponseWriter, r *http.Request) {
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, r, errStreamingUnsupported)
+		return
+	}
+
+	telegramID, err := optionalTelegramID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	lastEventID, err := lastEventID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	feed, err := s.services.StreamService.Subscribe(r.Context(), telegramID, r.URL.Query().Get("advert_id"), lastEventID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	defer feed.Close()
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// Stops nginx from buffering events
+	header.Set("X-Accel-Buffering", "no")
+	// Server's write deadline would cut stream off, every write gets its own
+	extendWriteDeadline(r, s.writeTimeout)
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetry.Milliseconds())
+
+	sent := lastEventID
+	send := func(change *domain.PriceChange) error {
+		// History and live changes overlap
+		if change.ChangeID <= sent {
+			return nil
+		}
+
+		sent = change.ChangeID
+
+		extendWriteDeadline(r, s.writeTimeout)
+		return writePriceEvent(w, change)
+	}
+
+	if feed.Reset {
+		if _, err := fmt.Fprint(w, "event: reset\ndata: {}\n\n"); err != nil {
+			return
+		}
+	}
+
+	for _, change := range feed.History {
+		if err := send(change); err != nil {
+			return
+		}
+	}
+
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(s.streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case change, ok := <-feed.Live:
+			// Client fell behind, it resumes with Last-Event-ID
+			if !ok {
+				return
+			}
+
+			if err := send(change); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			extendWriteDeadline(r, s.writeTimeout)
+			// Comment line keeps proxies from closing idle connection
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+
+		flusher.Flush()
+	}
+}
+
+func writePriceEvent(w http.ResponseWriter, change *domain.PriceChange) error {
+	data, err := json.Marshal(&dto.PriceEvent{
+		ChangeID:  change.ChangeID,
+		AdvertID:  change.AdvertID,
+		Title:     change.Title(),
+		URL:       change.URL(),
+		OldPrice:  change.OldPrice(),
+		NewPrice:  change.NewPrice(),
+		ChangedAt: change.ChangedAt(),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: price\ndata: %s\n\n", change.ChangeID, data)
+
+	return err
+}
+
+// EventSource sends Last-Event-ID on reconnect.
+// Query parameter lets clients resume on first connect
+func lastEventID(r *http.Request) (int64, error) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("last_event_id")
+	}
+
+	if value == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 0 {
+		return 0, ErrInvalidEventID
+	}
+
+	return id, nil
+}
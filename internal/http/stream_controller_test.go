@@ -0,0 +1,170 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"parser/client"
+	domain "parser/internal/domain/models"
+	"parser/internal/domain/services"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStreamService struct {
+	services.StreamService
+
+	history []*domain.PriceChange
+	reset   bool
+	live    chan *domain.PriceChange
+
+	telegramID  int64
+	lastEventID int64
+}
+
+func (f *fakeStreamService) Subscribe(ctx context.Context, telegramID int64, advertID string, lastEventID int64) (*services.PriceFeed, error) {
+	f.telegramID = telegramID
+	f.lastEventID = lastEventID
+
+	return &services.PriceFeed{History: f.history, Reset: f.reset, Live: f.live}, nil
+}
+
+func TestStreamPrices(t *testing.T) {
+	readKey, err := domain.NewAPIKeyFromPlain("reader", "avt_read", []domain.Scope{domain.ScopeSubscriptionsRead})
+	require.NoError(t, err)
+
+	auth := &fakeAuthService{apiKeys: map[string]*domain.APIKey{"avt_read": readKey}}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	stream := &fakeStreamService{
+		history: []*domain.PriceChange{
+			domain.NewPriceChange(3, "ad-1", "iPhone", "https://www.avito.ru/1", 100, 90, now),
+		},
+		live: make(chan *domain.PriceChange, 2),
+	}
+
+	srv := NewHTTPServer(&ServerConfig{
+		Router:          NewMuxRouter(),
+		Services:        &services.Services{AuthService: auth, StreamService: stream},
+		StreamHeartbeat: 10 * time.Millisecond,
+	})
+
+	ts := httptest.NewServer(srv.server.Handler)
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	prices, err := client.New(ts.URL, client.WithAPIKey("avt_read")).
+		StreamPrices(ctx, &client.PriceStreamQuery{TelegramID: 1, LastEventID: 2})
+	require.NoError(t, err)
+	defer prices.Close()
+
+	require.Equal(t, int64(1), stream.telegramID)
+	require.Equal(t, int64(2), stream.lastEventID)
+
+	event, err := prices.Next()
+	require.NoError(t, err)
+	require.Equal(t, &client.PriceEvent{
+		ChangeID:  3,
+		AdvertID:  "ad-1",
+		Title:     "iPhone",
+		URL:       "https://www.avito.ru/1",
+		OldPrice:  100,
+		NewPrice:  90,
+		ChangedAt: now,
+	}, event)
+
+	// Live change already sent within history is skipped
+	stream.live <- stream.history[0]
+	stream.live <- domain.NewPriceChange(4, "ad-1", "iPhone", "https://www.avito.ru/1", 90, 80, now)
+
+	event, err = prices.Next()
+	require.NoError(t, err)
+	require.Equal(t, int64(4), event.ChangeID)
+
+	// Stream ends when client falls behind
+	close(stream.live)
+
+	_, err = prices.Next()
+	require.ErrorIs(t, err, io.EOF)
+
+	_, err = client.New(ts.URL).StreamPrices(ctx, &client.PriceStreamQuery{TelegramID: 1})
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, 401, apiErr.StatusCode)
+}
+
+func TestStreamOutlivesWriteTimeout(t *testing.T) {
+	readKey, err := domain.NewAPIKeyFromPlain("reader", "avt_read", []domain.Scope{domain.ScopeSubscriptionsRead})
+	require.NoError(t, err)
+
+	auth := &fakeAuthService{apiKeys: map[string]*domain.APIKey{"avt_read": readKey}}
+	stream := &fakeStreamService{live: make(chan *domain.PriceChange, 1)}
+
+	srv := NewHTTPServer(&ServerConfig{
+		Router:          NewMuxRouter(),
+		Services:        &services.Services{AuthService: auth, StreamService: stream},
+		WriteTimeout:    50 * time.Millisecond,
+		StreamHeartbeat: 20 * time.Millisecond,
+	})
+
+	// Served with server's write timeout and connection context
+	ts := httptest.NewUnstartedServer(srv.server.Handler)
+	ts.Config = srv.server
+	ts.Start()
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	prices, err := client.New(ts.URL, client.WithAPIKey("avt_read")).
+		StreamPrices(ctx, &client.PriceStreamQuery{TelegramID: 1})
+	require.NoError(t, err)
+	defer prices.Close()
+
+	// Several write timeouts pass
+	time.Sleep(200 * time.Millisecond)
+	stream.live <- domain.NewPriceChange(1, "ad-1", "iPhone", "https://www.avito.ru/1", 100, 90, time.Now())
+
+	event, err := prices.Next()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), event.ChangeID)
+}
+
+func TestStreamReset(t *testing.T) {
+	readKey, err := domain.NewAPIKeyFromPlain("reader", "avt_read", []domain.Scope{domain.ScopeSubscriptionsRead})
+	require.NoError(t, err)
+
+	auth := &fakeAuthService{apiKeys: map[string]*domain.APIKey{"avt_read": readKey}}
+	stream := &fakeStreamService{reset: true, live: make(chan *domain.PriceChange, 1)}
+
+	srv := NewHTTPServer(&ServerConfig{
+		Router:   NewMuxRouter(),
+		Services: &services.Services{AuthService: auth, StreamService: stream},
+	})
+
+	ts := httptest.NewServer(srv.server.Handler)
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	prices, err := client.New(ts.URL, client.WithAPIKey("avt_read")).
+		StreamPrices(ctx, &client.PriceStreamQuery{TelegramID: 1, LastEventID: 1})
+	require.NoError(t, err)
+	defer prices.Close()
+
+	_, err = prices.Next()
+	require.ErrorIs(t, err, client.ErrStreamReset)
+
+	// Live changes follow reset
+	stream.live <- domain.NewPriceChange(5000, "ad-1", "iPhone", "https://www.avito.ru/1", 100, 90, time.Now())
+
+	event, err := prices.Next()
+	require.NoError(t, err)
+	require.Equal(t, int64(5000), event.ChangeID)
+}
@@ -0,0 +1,163 @@
+package http
+
+import (
+	"net/http"
+	domain "parser/internal/domain/models"
+	"parser/internal/http/dto"
+	"strconv"
+)
+
+// Query: ?telegram_id=&limit=&offset=
+func (s *HTTPServer) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+
+	query := r.URL.Query()
+
+	telegramID, err := queryTelegramID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	limit, err := queryInt(query.Get("limit"))
+	if err != nil {
+		writeError(w, r, ErrInvalidPagination)
+		return
+	}
+
+	offset, err := queryInt(query.Get("offset"))
+	if err != nil {
+		writeError(w, r, ErrInvalidPagination)
+		return
+	}
+
+	subscriptions, total, err := s.services.SubscriptionService.ListSubscriptions(r.Context(), telegramID, limit, offset)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if limit == 0 {
+		limit = domain.DefaultPageLimit
+	}
+
+	out := &dto.SubscriptionListResponse{
+		Items:  make([]*dto.SubscriptionResponse, 0, len(subscriptions)),
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	for _, subscription := range subscriptions {
+		out.Items = append(out.Items, subscriptionResponse(subscription))
+	}
+
+	writeJSON(w, http.StatusOK, out)
+}
+
+// Path: /subscriptions/{advert_id}
+// Query: ?telegram_id=
+func (s *HTTPServer) GetSubscription(w http.ResponseWriter, r *http.Request) {
+
+	telegramID, advertID, ok := subscriptionParams(w, r)
+	if !ok {
+		return
+	}
+
+	subscription, err := s.services.SubscriptionService.GetSubscription(r.Context(), telegramID, advertID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, subscriptionResponse(subscription))
+}
+
+// Path: /subscriptions/{advert_id}
+// Query: ?telegram_id=
+func (s *HTTPServer) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+
+	telegramID, advertID, ok := subscriptionParams(w, r)
+	if !ok {
+		return
+	}
+
+	err := s.services.SubscriptionService.DeleteSubscription(r.Context(), telegramID, advertID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Path: /subscriptions/{advert_id}
+// Query: ?telegram_id=
+func (s *HTTPServer) UpdateSubscription(w http.ResponseWriter, r *http.Request) {
+
+	telegramID, advertID, ok := subscriptionParams(w, r)
+	if !ok {
+		return
+	}
+
+	var inp dto.UpdateSubscriptionRequest
+	err := decodeBody(r, &inp)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	subscription, err := s.services.SubscriptionService.UpdateSubscription(r.Context(), telegramID, advertID, &inp)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, subscriptionResponse(subscription))
+}
+
+// Reads telegram_id from query and advert_id from path.
+// Writes response if any is invalid
+func subscriptionParams(w http.ResponseWriter, r *http.Request) (int64, string, bool) {
+	advertID := PathParam(r, "advert_id")
+
+	telegramID, err := queryTelegramID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return 0, "", false
+	}
+
+	return telegramID, advertID, true
+}
+
+// Empty value means zero
+func queryInt(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	return strconv.Atoi(value)
+}
+
+func subscriptionResponse(subscription *domain.Subscription) *dto.SubscriptionResponse {
+	out := &dto.SubscriptionResponse{
+		AdvertID:  subscription.AdvertID,
+		Muted:     subscription.IsMuted(),
+		CreatedAt: subscription.CreatedAt(),
+	}
+
+	if subscription.HasChannels() {
+		out.Channels = domain.ChannelsToStrings(subscription.Channels())
+	}
+
+	if ad := subscription.Advert(); ad != nil {
+		out.Advert = &dto.AdvertResponse{
+			URL:          ad.URL(),
+			Title:        ad.Title(),
+			CurrentPrice: ad.CurrentPrice(),
+			LastPrice:    ad.LastPrice(),
+			IsParsed:     ad.IsParsed(),
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,30 @@
+package logger
+
+import (
+	goerrors "errors"
+	"parser/internal/errors"
+)
+
+// ErrFields describes err. Code, kind, operations, stack and fields
+// of errors.ApplicationError are added, so failure could be found by any of them
+func ErrFields(err error) []Field {
+	fields := []Field{Err(err)}
+
+	var ae *errors.ApplicationError
+	if !goerrors.As(err, &ae) {
+		return fields
+	}
+
+	fields = append(fields,
+		String("error_kind", ae.Kind().K),
+		String("error_code", ae.Code()),
+		String("trace", ae.PrintStacktrace()),
+		String("stack", ae.StackTrace()),
+	)
+
+	for _, f := range ae.Fields() {
+		fields = append(fields, Any(f.Key, f.Value))
+	}
+
+	return fields
+}
@@ -0,0 +1,65 @@
+package logger
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type Field = zap.Field
+
+func String(key, value string) Field {
+	return zap.String(key, value)
+}
+
+func Int(key string, value int) Field {
+	return zap.Int(key, value)
+}
+
+func Int64(key string, value int64) Field {
+	return zap.Int64(key, value)
+}
+
+func Float64(key string, value float64) Field {
+	return zap.Float64(key, value)
+}
+
+func Bool(key string, value bool) Field {
+	return zap.Bool(key, value)
+}
+
+func Duration(key string, value time.Duration) Field {
+	return zap.Duration(key, value)
+}
+
+// Any picks encoding by type of value. Prefer typed constructors
+func Any(key string, value interface{}) Field {
+	return zap.Any(key, value)
+}
+
+// Err is logged under "error" key
+func Err(err error) Field {
+	return zap.Error(err)
+}
+
+// Fields shared by components, so entries of single request or advert could be grepped
+
+func RequestID(id string) Field {
+	return zap.String("request_id", id)
+}
+
+func AdvertID(id string) Field {
+	return zap.String("advert_id", id)
+}
+
+func URL(url string) Field {
+	return zap.String("url", url)
+}
+
+func TelegramID(id int64) Field {
+	return zap.Int64("telegram_id", id)
+}
+
+func TraceID(id string) Field {
+	return zap.String("trace_id", id)
+}
@@ -0,0 +1,144 @@
+// Package logger writes leveled structured logs.
+// Components get their own child loggers, see Logger.Named.
+// Fields describing current work, e.g. request ID or advert, travel with context
+package logger
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type Logger interface {
+	Debug(msg string, fields ...Field)
+	Info(msg string, fields ...Field)
+	Warn(msg string, fields ...Field)
+	Error(msg string, fields ...Field)
+
+	// Returns child logger that adds fields to every entry
+	With(fields ...Field) Logger
+	// Returns child logger of component, e.g. "parser".
+	// Names of nested components are joined with dot
+	Named(component string) Logger
+	// Returns child logger with fields carried by ctx, see WithFields.
+	// ID of trace ctx belongs to is added as well
+	WithContext(ctx context.Context) Logger
+
+	// Flushes buffered entries. Should be called before exit
+	Sync() error
+}
+
+// New writes JSON at info level. In debug mode it writes
+// human readable lines at debug level instead
+func New(debug bool) (Logger, error) {
+	cfg := zap.NewProductionConfig()
+	cfg.EncoderConfig.TimeKey = "time"
+	cfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+
+	if debug {
+		cfg = zap.NewDevelopmentConfig()
+		cfg.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+	}
+
+	l, err := cfg.Build(zap.AddCallerSkip(1))
+	if err != nil {
+		return nil, err
+	}
+
+	return &zapLogger{l: l}, nil
+}
+
+// Wrap adapts configured zap logger, e.g. one writing to observer in tests
+func Wrap(l *zap.Logger) Logger {
+	return &zapLogger{l: l}
+}
+
+// Nop discards everything. Used in tests and when logger is not configured
+func Nop() Logger {
+	return &zapLogger{l: zap.NewNop()}
+}
+
+type zapLogger struct {
+	l *zap.Logger
+}
+
+func (z *zapLogger) Debug(msg string, fields ...Field) {
+	z.l.Debug(msg, fields...)
+}
+
+func (z *zapLogger) Info(msg string, fields ...Field) {
+	z.l.Info(msg, fields...)
+}
+
+func (z *zapLogger) Warn(msg string, fields ...Field) {
+	z.l.Warn(msg, fields...)
+}
+
+func (z *zapLogger) Error(msg string, fields ...Field) {
+	z.l.Error(msg, fields...)
+}
+
+func (z *zapLogger) With(fields ...Field) Logger {
+	return &zapLogger{l: z.l.With(fields...)}
+}
+
+func (z *zapLogger) Named(component string) Logger {
+	return &zapLogger{l: z.l.Named(component)}
+}
+
+func (z *zapLogger) WithContext(ctx context.Context) Logger {
+	fields := fieldsFromContext(ctx)
+
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		fields = append(fields[:len(fields):len(fields)], TraceID(sc.TraceID().String()))
+	}
+
+	if len(fields) == 0 {
+		return z
+	}
+
+	return z.With(fields...)
+}
+
+func (z *zapLogger) Sync() error {
+	return z.l.Sync()
+}
+
+type fieldsKey struct{}
+type loggerKey struct{}
+
+// WithFields returns context carrying fields in addition to ones ctx already has.
+// Loggers pick them up with Logger.WithContext
+func WithFields(ctx context.Context, fields ...Field) context.Context {
+	parent := fieldsFromContext(ctx)
+
+	// Copy, so siblings sharing parent don't overwrite each other
+	merged := make([]Field, 0, len(parent)+len(fields))
+	merged = append(merged, parent...)
+	merged = append(merged, fields...)
+
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+func fieldsFromContext(ctx context.Context) []Field {
+	fields, _ := ctx.Value(fieldsKey{}).([]Field)
+	return fields
+}
+
+// NewContext returns context carrying l, for code that has no logger of its own
+func NewContext(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext returns logger put by NewContext with fields of ctx.
+// Nop if there is none
+func FromContext(ctx context.Context) Logger {
+	l, ok := ctx.Value(loggerKey{}).(Logger)
+	if !ok {
+		return Nop()
+	}
+
+	return l.WithContext(ctx)
+}
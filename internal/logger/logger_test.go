@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogger(t *testing.T) {
+	newObserved := func() (Logger, *observer.ObservedLogs) {
+		core, logs := observer.New(zapcore.DebugLevel)
+		return Wrap(zap.New(core)), logs
+	}
+
+	t.Run("adds fields carried by context", func(t *testing.T) {
+		log, logs := newObserved()
+
+		ctx := WithFields(context.Background(), RequestID("req-1"))
+		ctx = WithFields(ctx, AdvertID("ad-1"))
+
+		log.Named("parser").WithContext(ctx).Info("parsed", URL("https://www.avito.ru/1"))
+
+		entries := logs.All()
+		require.Len(t, entries, 1)
+		require.Equal(t, "parser", entries[0].LoggerName)
+		require.Equal(t, map[string]interface{}{
+			"request_id": "req-1",
+			"advert_id":  "ad-1",
+			"url":        "https://www.avito.ru/1",
+		}, entries[0].ContextMap())
+	})
+
+	t.Run("siblings don't share fields", func(t *testing.T) {
+		log, logs := newObserved()
+
+		parent := WithFields(context.Background(), RequestID("req-1"))
+		first := WithFields(parent, AdvertID("ad-1"))
+		second := WithFields(parent, AdvertID("ad-2"))
+
+		log.WithContext(first).Info("first")
+		log.WithContext(second).Info("second")
+
+		entries := logs.All()
+		require.Equal(t, "ad-1", entries[0].ContextMap()["advert_id"])
+		require.Equal(t, "ad-2", entries[1].ContextMap()["advert_id"])
+	})
+
+	t.Run("takes logger from context", func(t *testing.T) {
+		log, logs := newObserved()
+
+		// No logger in context, entry is discarded
+		FromContext(context.Background()).Error("lost")
+
+		ctx := NewContext(context.Background(), log)
+		ctx = WithFields(ctx, RequestID("req-1"))
+		FromContext(ctx).Error("kept")
+
+		entries := logs.All()
+		require.Len(t, entries, 1)
+		require.Equal(t, "kept", entries[0].Message)
+		require.Equal(t, "req-1", entries[0].ContextMap()["request_id"])
+	})
+}
@@ -0,0 +1,16 @@
+// Package loggertest provides logger that keeps entries in memory for tests.
+package loggertest
+
+import (
+	"parser/internal/logger"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// New returns logger of debug level and entries it has written
+func New() (logger.Logger, *observer.ObservedLogs) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	return logger.Wrap(zap.New(core)), logs
+}
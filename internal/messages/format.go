@@ -0,0 +1,71 @@
+package messages
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// formatNumber prints v with at most 2 decimals.
+// Decimals are omitted for whole numbers, e.g. 1000 -> "1 000", 1000.5 -> "1 000,50"
+func (f numberFormat) formatNumber(v float64) string {
+	cents := int64(math.Round(math.Abs(v) * 100))
+	whole, fraction := cents/100, cents%100
+
+	number := f.groupDigits(whole)
+	if fraction != 0 {
+		number += f.decimal + strconv.FormatInt(fraction/10, 10) + strconv.FormatInt(fraction%10, 10)
+	}
+
+	if v < 0 && cents != 0 {
+		number = "-" + number
+	}
+
+	return number
+}
+
+func (f numberFormat) formatPrice(v float64) string {
+	return f.currency(f.formatNumber(v))
+}
+
+// formatChange prints relative change from old to new with one decimal, e.g. "-15%", "+2,5%".
+// Returns empty string if old is zero
+func (f numberFormat) formatChange(old, new float64) string {
+	if old == 0 {
+		return ""
+	}
+
+	percent := (new - old) / old * 100
+
+	tenths := int64(math.Round(math.Abs(percent) * 10))
+	whole, fraction := tenths/10, tenths%10
+
+	number := f.groupDigits(whole)
+	if fraction != 0 {
+		number += f.decimal + strconv.FormatInt(fraction, 10)
+	}
+
+	switch {
+	case tenths == 0:
+		return number + "%"
+	case percent > 0:
+		return "+" + number + "%"
+	default:
+		return "-" + number + "%"
+	}
+}
+
+// groupDigits splits n by thousands, e.g. 1234567 -> "1,234,567"
+func (f numberFormat) groupDigits(n int64) string {
+	digits := strconv.FormatInt(n, 10)
+
+	var buff strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			buff.WriteString(f.group)
+		}
+		buff.WriteRune(d)
+	}
+
+	return buff.String()
+}
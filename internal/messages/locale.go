@@ -0,0 +1,69 @@
+package messages
+
+import (
+	"errors"
+	"strings"
+)
+
+type Locale string
+
+const (
+	LocaleRU Locale = "ru"
+	LocaleEN Locale = "en"
+
+	// Most of users speak russian
+	DefaultLocale = LocaleRU
+)
+
+var (
+	ErrUnsupportedLocale = errors.New("unsupported locale")
+)
+
+// Describes how numbers and prices look in locale
+type numberFormat struct {
+	group   string
+	decimal string
+	// Prints price with currency, e.g. "1 000 ₽"
+	currency func(amount string) string
+}
+
+var formats = map[Locale]numberFormat{
+	LocaleRU: {
+		// Non-breaking spaces keep price on one line
+		group:    "\u00a0",
+		decimal:  ",",
+		currency: func(amount string) string { return amount + "\u00a0₽" },
+	},
+	LocaleEN: {
+		group:    ",",
+		decimal:  ".",
+		currency: func(amount string) string { return "RUB " + amount },
+	},
+}
+
+// ParseLocale turns IETF language tag (e.g. telegram's language_code "en-US")
+// into supported Locale. Unsupported ones fallback to DefaultLocale
+func ParseLocale(tag string) Locale {
+	lang := strings.ToLower(tag)
+	if i := strings.IndexAny(lang, "-_"); i != -1 {
+		lang = lang[:i]
+	}
+
+	switch Locale(lang) {
+	case LocaleRU, LocaleEN:
+		return Locale(lang)
+	}
+
+	// Russian is more familiar for users of these
+	switch lang {
+	case "uk", "be", "kk", "uz", "ky", "hy", "az", "tg":
+		return LocaleRU
+	}
+
+	return DefaultLocale
+}
+
+func IsSupported(locale Locale) bool {
+	_, ok := formats[locale]
+	return ok
+}
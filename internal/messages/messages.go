@@ -0,0 +1,161 @@
+// Package messages renders localized notification texts.
+//
+// Every locale has a catalog of text/template templates in catalog/{locale}/{name}.tmpl.
+// Templates could use locale-aware funcs:
+//
+//	{{ price .NewPrice }}              // "1 000 ₽" in ru, "RUB 1,000" in en
+//	{{ change .OldPrice .NewPrice }}   // "-15%"
+package messages
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"text/template"
+)
+
+// Names of templates in catalogs
+const (
+	PriceChanged = "price_changed"
+	// Subject of email alert, rendered with PriceChangedData
+	PriceChangedSubject = "price_changed_subject"
+	Digest              = "digest"
+)
+
+var (
+	ErrUnknownTemplate = errors.New("unknown template")
+)
+
+//go:embed catalog
+var catalogFS embed.FS
+
+// PriceChangedData is passed to PriceChanged template
+type PriceChangedData struct {
+	Title    string
+	URL      string
+	OldPrice float64
+	NewPrice float64
+	// Optional. Price history from OldPrice to NewPrice
+	// if rapid changes are merged into one alert
+	Prices []float64
+}
+
+// DigestData is passed to Digest template
+type DigestData struct {
+	Adverts []*DigestAdvert
+}
+
+// DigestAdvert is price history of advert since the last digest
+type DigestAdvert struct {
+	Title string
+	URL   string
+	// At least two prices from oldest to newest
+	Prices []float64
+}
+
+func (da *DigestAdvert) OldPrice() float64 {
+	return da.Prices[0]
+}
+
+func (da *DigestAdvert) NewPrice() float64 {
+	return da.Prices[len(da.Prices)-1]
+}
+
+type Options struct {
+	// Used when subscriber's locale is not supported
+	DefaultLocale Locale
+
+	// Replaces built-in templates: locale -> template name -> template text
+	Overrides map[Locale]map[string]string
+}
+
+type Renderer struct {
+	templates     map[Locale]*template.Template
+	defaultLocale Locale
+}
+
+func NewRenderer(opts *Options) (*Renderer, error) {
+	defaultLocale := opts.DefaultLocale
+	if !IsSupported(defaultLocale) {
+		defaultLocale = DefaultLocale
+	}
+
+	r := &Renderer{
+		templates:     make(map[Locale]*template.Template, len(formats)),
+		defaultLocale: defaultLocale,
+	}
+
+	for locale, format := range formats {
+		tmpl, err := parseCatalog(locale, format)
+		if err != nil {
+			return nil, err
+		}
+
+		for name, text := range opts.Overrides[locale] {
+			if tmpl.Lookup(name) == nil {
+				return nil, fmt.Errorf("override %s/%s: %w", locale, name, ErrUnknownTemplate)
+			}
+
+			if _, err := tmpl.New(name).Parse(text); err != nil {
+				return nil, fmt.Errorf("override %s/%s: %w", locale, name, err)
+			}
+		}
+
+		r.templates[locale] = tmpl
+	}
+
+	return r, nil
+}
+
+// Render executes template name of locale with data.
+// Unsupported locale fallbacks to default one
+func (r *Renderer) Render(locale Locale, name string, data interface{}) (string, error) {
+	tmpl, ok := r.templates[locale]
+	if !ok {
+		tmpl = r.templates[r.defaultLocale]
+	}
+
+	if tmpl.Lookup(name) == nil {
+		return "", fmt.Errorf("%s: %w", name, ErrUnknownTemplate)
+	}
+
+	var buff bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buff, name, data); err != nil {
+		return "", fmt.Errorf("render %s/%s: %w", locale, name, err)
+	}
+
+	return strings.TrimSpace(buff.String()), nil
+}
+
+func parseCatalog(locale Locale, format numberFormat) (*template.Template, error) {
+	tmpl := template.New(string(locale)).Funcs(template.FuncMap{
+		"price":  format.formatPrice,
+		"number": format.formatNumber,
+		"change": format.formatChange,
+	})
+
+	dir := path.Join("catalog", string(locale))
+
+	entries, err := fs.ReadDir(catalogFS, dir)
+	if err != nil {
+		return nil, fmt.Errorf("catalog %s: %w", locale, err)
+	}
+
+	for _, entry := range entries {
+		content, err := fs.ReadFile(catalogFS, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		name := strings.TrimSuffix(entry.Name(), ".tmpl")
+		if _, err := tmpl.New(name).Parse(string(content)); err != nil {
+			return nil, fmt.Errorf("catalog %s/%s: %w", locale, name, err)
+		}
+	}
+
+	return tmpl, nil
+}
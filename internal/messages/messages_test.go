@@ -0,0 +1,103 @@
+package messages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLocale(t *testing.T) {
+	tests := map[string]Locale{
+		"ru":    LocaleRU,
+		"en":    LocaleEN,
+		"en-US": LocaleEN,
+		"EN_gb": LocaleEN,
+		"uk":    LocaleRU,
+		"de":    DefaultLocale,
+		"":      DefaultLocale,
+	}
+
+	for tag, expected := range tests {
+		require.Equal(t, expected, ParseLocale(tag), tag)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	ru, en := formats[LocaleRU], formats[LocaleEN]
+
+	require.Equal(t, "1\u00a0234\u00a0567\u00a0₽", ru.formatPrice(1234567))
+	require.Equal(t, "999,50\u00a0₽", ru.formatPrice(999.5))
+	require.Equal(t, "RUB 1,234,567", en.formatPrice(1234567))
+	require.Equal(t, "RUB 0.05", en.formatPrice(0.05))
+
+	require.Equal(t, "-15%", ru.formatChange(1000, 850))
+	require.Equal(t, "+2,5%", ru.formatChange(1000, 1025))
+	require.Equal(t, "+2.5%", en.formatChange(1000, 1025))
+	require.Equal(t, "0%", en.formatChange(1000, 1000))
+	require.Equal(t, "+10%", en.formatChange(1000, 1100))
+	require.Equal(t, "", en.formatChange(0, 1000))
+}
+
+func TestRenderer(t *testing.T) {
+	data := &PriceChangedData{
+		Title:    "iPhone 13",
+		URL:      "https://www.avito.ru/moskva/telefony/iphone_123",
+		OldPrice: 1000,
+		NewPrice: 850,
+	}
+
+	t.Run("renders built-in catalogs", func(t *testing.T) {
+		r, err := NewRenderer(&Options{DefaultLocale: LocaleRU})
+		require.NoError(t, err)
+
+		text, err := r.Render(LocaleRU, PriceChanged, data)
+		require.NoError(t, err)
+		require.Equal(t, "Цена изменилась!\niPhone 13\n1\u00a0000\u00a0₽ → 850\u00a0₽ (-15%)\nhttps://www.avito.ru/moskva/telefony/iphone_123", text)
+
+		text, err = r.Render(LocaleEN, PriceChanged, data)
+		require.NoError(t, err)
+		require.Equal(t, "Price changed!\niPhone 13\nRUB 1,000 → RUB 850 (-15%)\nhttps://www.avito.ru/moskva/telefony/iphone_123", text)
+
+		// Unsupported locale
+		fallback, err := r.Render(Locale("de"), PriceChanged, data)
+		require.NoError(t, err)
+		require.Contains(t, fallback, "Цена изменилась!")
+	})
+
+	t.Run("renders digest", func(t *testing.T) {
+		r, err := NewRenderer(&Options{DefaultLocale: LocaleRU})
+		require.NoError(t, err)
+
+		text, err := r.Render(LocaleEN, Digest, &DigestData{
+			Adverts: []*DigestAdvert{
+				{Title: "iPhone 13", URL: "https://www.avito.ru/1", Prices: []float64{1000, 850, 800}},
+				{Title: "Bike", URL: "https://www.avito.ru/2", Prices: []float64{100, 110}},
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "Price changes digest:\n\niPhone 13\nRUB 1,000 → RUB 850 → RUB 800 (-20%)\nhttps://www.avito.ru/1\n\nBike\nRUB 100 → RUB 110 (+10%)\nhttps://www.avito.ru/2", text)
+	})
+
+	t.Run("applies overrides", func(t *testing.T) {
+		r, err := NewRenderer(&Options{
+			DefaultLocale: LocaleRU,
+			Overrides: map[Locale]map[string]string{
+				LocaleEN: {PriceChanged: "{{ .Title }}: {{ price .NewPrice }}"},
+			},
+		})
+		require.NoError(t, err)
+
+		text, err := r.Render(LocaleEN, PriceChanged, data)
+		require.NoError(t, err)
+		require.Equal(t, "iPhone 13: RUB 850", text)
+	})
+
+	t.Run("rejects unknown overrides", func(t *testing.T) {
+		_, err := NewRenderer(&Options{
+			Overrides: map[Locale]map[string]string{
+				LocaleEN: {"no_such_template": "text"},
+			},
+		})
+		require.ErrorIs(t, err, ErrUnknownTemplate)
+	})
+}
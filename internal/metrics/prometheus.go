@@ -0,0 +1,259 @@
+// Package metrics exposes measurements of components in Prometheus format.
+// Components are instrumented through their own small Metrics interfaces,
+// Prometheus implements all of them
+package metrics
+
+import (
+	"net/http"
+	domain "parser/internal/domain/models"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "tracker"
+
+// Pages load in seconds, timeouts are around 10s
+var parseBuckets = []float64{0.25, 0.5, 1, 2, 3, 5, 7.5, 10, 15, 30}
+
+type Prometheus struct {
+	registry *prometheus.Registry
+
+	parseDuration *prometheus.HistogramVec
+	parses        *prometheus.CounterVec
+	cycleDuration prometheus.Histogram
+	cacheLookups  *prometheus.CounterVec
+	ringSize      prometheus.Gauge
+	outDepth      prometheus.Gauge
+
+	updateDuration *prometheus.HistogramVec
+	failures       *prometheus.CounterVec
+	failingURLs    prometheus.Gauge
+
+	notifications *prometheus.CounterVec
+}
+
+func NewPrometheus() *Prometheus {
+	p := &Prometheus{
+		registry: prometheus.NewRegistry(),
+
+		parseDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "parser",
+			Name:      "parse_duration_seconds",
+			Help:      "Time spent parsing single advert page.",
+			Buckets:   parseBuckets,
+		}, []string{"host"}),
+		parses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "parser",
+			Name:      "parses_total",
+			Help:      "Parsed advert pages by outcome.",
+		}, []string{"host", "outcome"}),
+		cycleDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "parser",
+			Name:      "cycle_duration_seconds",
+			Help:      "Time of full pass over every parsed advert.",
+			Buckets:   prometheus.ExponentialBuckets(10, 2, 10),
+		}),
+		cacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "parser",
+			Name:      "url_cache_lookups_total",
+			Help:      "Lookups of recently parsed URLs. Hit means URL is skipped.",
+		}, []string{"result"}),
+		ringSize: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "parser",
+			Name:      "ring_size",
+			Help:      "Amount of adverts being parsed.",
+		}),
+		outDepth: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "parser",
+			Name:      "out_queue_depth",
+			Help:      "Parse results waiting to be handled.",
+		}),
+
+		updateDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "proxy",
+			Name:      "update_duration_seconds",
+			Help:      "Time spent handling parse result by outcome.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"outcome"}),
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "proxy",
+			Name:      "failures_total",
+			Help:      "Failed updates by class, e.g. malformed or handler_internal.",
+		}, []string{"class"}),
+		failingURLs: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "proxy",
+			Name:      "failing_urls",
+			Help:      "Adverts whose last update failed.",
+		}),
+
+		notifications: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "notify",
+			Name:      "notifications_total",
+			Help:      "Notifications by channel and result.",
+		}, []string{"channel", "result"}),
+	}
+
+	p.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		p.parseDuration,
+		p.parses,
+		p.cycleDuration,
+		p.cacheLookups,
+		p.ringSize,
+		p.outDepth,
+		p.updateDuration,
+		p.failures,
+		p.failingURLs,
+		p.notifications,
+	)
+
+	return p
+}
+
+// Handler serves metrics to Prometheus
+func (p *Prometheus) Handler() http.Handler {
+	return promhttp.HandlerFor(p.registry, promhttp.HandlerOpts{Registry: p.registry})
+}
+
+// RegisterPool exposes statistics of database connection pool
+func (p *Prometheus) RegisterPool(pool PoolStater) {
+	p.registry.MustRegister(newPoolCollector(pool))
+}
+
+// parser.Metrics
+
+func (p *Prometheus) ObserveParse(host, outcome string, d time.Duration) {
+	p.parseDuration.WithLabelValues(host).Observe(d.Seconds())
+	p.parses.WithLabelValues(host, outcome).Inc()
+}
+
+func (p *Prometheus) ObserveCycle(d time.Duration) {
+	p.cycleDuration.Observe(d.Seconds())
+}
+
+func (p *Prometheus) ObserveCacheLookup(hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+
+	p.cacheLookups.WithLabelValues(result).Inc()
+}
+
+func (p *Prometheus) SetRingSize(n int) {
+	p.ringSize.Set(float64(n))
+}
+
+func (p *Prometheus) SetOutDepth(n int) {
+	p.outDepth.Set(float64(n))
+}
+
+// proxy.Metrics
+
+func (p *Prometheus) ObserveUpdate(outcome string, d time.Duration) {
+	p.updateDuration.WithLabelValues(outcome).Observe(d.Seconds())
+}
+
+// Proxy reads the same queue parser writes to
+func (p *Prometheus) SetQueueDepth(n int) {
+	p.outDepth.Set(float64(n))
+}
+
+// services.FailureMetrics
+
+func (p *Prometheus) ObserveFailure(class string) {
+	p.failures.WithLabelValues(class).Inc()
+}
+
+func (p *Prometheus) SetFailingURLs(n int) {
+	p.failingURLs.Set(float64(n))
+}
+
+// notify.Metrics
+
+func (p *Prometheus) NotificationSent(channel domain.Channel) {
+	p.notifications.WithLabelValues(string(channel), "sent").Inc()
+}
+
+func (p *Prometheus) NotificationFailed(channel domain.Channel) {
+	p.notifications.WithLabelValues(string(channel), "failed").Inc()
+}
+
+// PoolStater is implemented by postgres.Postgres
+type PoolStater interface {
+	Stat() *pgxpool.Stat
+}
+
+// Reads pool statistics on scrape
+type poolCollector struct {
+	pool PoolStater
+
+	acquiredConns     *prometheus.Desc
+	idleConns         *prometheus.Desc
+	totalConns        *prometheus.Desc
+	maxConns          *prometheus.Desc
+	acquires          *prometheus.Desc
+	acquireDuration   *prometheus.Desc
+	emptyAcquires     *prometheus.Desc
+	canceledAcquires  *prometheus.Desc
+	constructingConns *prometheus.Desc
+}
+
+func newPoolCollector(pool PoolStater) *poolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+	}
+
+	return &poolCollector{
+		pool:              pool,
+		acquiredConns:     desc("acquired_conns", "Connections currently in use."),
+		idleConns:         desc("idle_conns", "Idle connections."),
+		totalConns:        desc("total_conns", "Open connections."),
+		maxConns:          desc("max_conns", "Maximum size of pool."),
+		acquires:          desc("acquires_total", "Successful acquires of connection."),
+		acquireDuration:   desc("acquire_duration_seconds_total", "Time spent acquiring connections."),
+		emptyAcquires:     desc("empty_acquires_total", "Acquires that waited for connection because pool was empty."),
+		canceledAcquires:  desc("canceled_acquires_total", "Acquires canceled by context."),
+		constructingConns: desc("constructing_conns", "Connections being established."),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+
+	gauge := func(desc *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v)
+	}
+	counter := func(desc *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, v)
+	}
+
+	gauge(c.acquiredConns, float64(stat.AcquiredConns()))
+	gauge(c.idleConns, float64(stat.IdleConns()))
+	gauge(c.totalConns, float64(stat.TotalConns()))
+	gauge(c.maxConns, float64(stat.MaxConns()))
+	gauge(c.constructingConns, float64(stat.ConstructingConns()))
+	counter(c.acquires, float64(stat.AcquireCount()))
+	counter(c.acquireDuration, stat.AcquireDuration().Seconds())
+	counter(c.emptyAcquires, float64(stat.EmptyAcquireCount()))
+	counter(c.canceledAcquires, float64(stat.CanceledAcquireCount()))
+}
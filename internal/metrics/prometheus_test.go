@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	domain "parser/internal/domain/models"
+	"parser/internal/domain/services"
+	"parser/internal/notify"
+	"parser/internal/parser"
+	"parser/internal/proxy"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// Prometheus is plugged into every instrumented component
+var (
+	_ parser.Metrics = (*Prometheus)(nil)
+	_ proxy.Metrics  = (*Prometheus)(nil)
+	_ notify.Metrics = (*Prometheus)(nil)
+
+	_ services.FailureMetrics = (*Prometheus)(nil)
+)
+
+func TestPrometheus(t *testing.T) {
+	p := NewPrometheus()
+
+	p.ObserveParse("www.avito.ru", parser.OutcomeOK, time.Second)
+	p.ObserveParse("www.avito.ru", parser.OutcomeOK, time.Second)
+	p.ObserveParse("www.avito.ru", parser.OutcomeTimeout, 10*time.Second)
+	p.ObserveCacheLookup(true)
+	p.ObserveCacheLookup(false)
+	p.ObserveCacheLookup(false)
+	p.SetRingSize(5)
+	p.SetOutDepth(2)
+	p.SetQueueDepth(1)
+	p.NotificationSent(domain.ChannelTelegram)
+	p.NotificationFailed(domain.ChannelWebhook)
+	p.ObserveFailure(parser.OutcomeMalformed)
+	p.SetFailingURLs(3)
+
+	require.Equal(t, float64(2), testutil.ToFloat64(p.parses.WithLabelValues("www.avito.ru", parser.OutcomeOK)))
+	require.Equal(t, float64(1), testutil.ToFloat64(p.parses.WithLabelValues("www.avito.ru", parser.OutcomeTimeout)))
+	require.Equal(t, float64(1), testutil.ToFloat64(p.cacheLookups.WithLabelValues("hit")))
+	require.Equal(t, float64(2), testutil.ToFloat64(p.cacheLookups.WithLabelValues("miss")))
+	require.Equal(t, float64(5), testutil.ToFloat64(p.ringSize))
+	require.Equal(t, float64(1), testutil.ToFloat64(p.outDepth))
+	require.Equal(t, float64(1), testutil.ToFloat64(p.notifications.WithLabelValues("telegram", "sent")))
+	require.Equal(t, float64(1), testutil.ToFloat64(p.notifications.WithLabelValues("webhook", "failed")))
+	require.Equal(t, float64(1), testutil.ToFloat64(p.failures.WithLabelValues(parser.OutcomeMalformed)))
+	require.Equal(t, float64(3), testutil.ToFloat64(p.failingURLs))
+
+	rec := httptest.NewRecorder()
+	p.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), `tracker_parser_parse_duration_seconds_count{host="www.avito.ru"} 3`)
+	require.Contains(t, string(body), "go_goroutines")
+}
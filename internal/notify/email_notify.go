@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	domain "parser/internal/domain/models"
+	"parser/internal/email"
+)
+
+var (
+	ErrNoEmail            = errors.New("missing email in args")
+	ErrInvalidEmailFormat = errors.New("email should be string")
+
+	ErrNoSubject            = errors.New("missing subject in args")
+	ErrInvalidSubjectFormat = errors.New("subject should be string")
+)
+
+type emailArgs struct {
+	to      string
+	subject string
+	message string
+}
+
+type emailNotifier struct {
+	mailer email.Mailer
+}
+
+func NewEmailNotifier(mailer email.Mailer) Notifier {
+	return &emailNotifier{mailer: mailer}
+}
+
+// args[0] - email address to send alert to (string)
+// args[1] - subject of email (string)
+// args[2] - message that's sent to end user (string)
+//
+// Both are localized by caller, message is laid out by email.TemplateAlert
+func (en *emailNotifier) Notify(target *domain.Advert, args ...interface{}) error {
+	eargs, err := en.validateArgs(args)
+	if err != nil {
+		return err
+	}
+
+	text, html, err := email.Render(email.TemplateAlert, &email.AlertData{Text: eargs.message})
+	if err != nil {
+		return err
+	}
+
+	err = en.mailer.Send(&email.Message{
+		To:      eargs.to,
+		Subject: eargs.subject,
+		Text:    text,
+		HTML:    html,
+	})
+	if err != nil {
+		return fmt.Errorf("error sending email: %w", err)
+	}
+
+	return nil
+}
+
+func (en *emailNotifier) validateArgs(args []interface{}) (*emailArgs, error) {
+	if len(args) == 0 {
+		return nil, ErrNoEmail
+	}
+
+	to, ok := args[0].(string)
+	if !ok {
+		return nil, ErrInvalidEmailFormat
+	}
+
+	if to == "" {
+		return nil, ErrNoEmail
+	}
+
+	if len(args) < 2 {
+		return nil, ErrNoSubject
+	}
+
+	subject, ok := args[1].(string)
+	if !ok {
+		return nil, ErrInvalidSubjectFormat
+	}
+
+	if len(args) < 3 {
+		return nil, ErrNoMessage
+	}
+
+	message, ok := args[2].(string)
+	if !ok {
+		return nil, ErrInvalidMessageFormat
+	}
+
+	return &emailArgs{to: to, subject: subject, message: message}, nil
+}
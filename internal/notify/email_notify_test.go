@@ -0,0 +1,51 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+
+	domain "parser/internal/domain/models"
+	"parser/internal/email"
+	"parser/internal/email/emailtest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmailNotifier(t *testing.T) {
+	srv, err := emailtest.NewServer()
+	require.NoError(t, err)
+	defer srv.Close()
+
+	mailer, err := email.NewSMTPMailer(&email.SMTPOptions{
+		Addr: srv.Addr,
+		From: "tracker@example.com",
+	})
+	require.NoError(t, err)
+
+	notifier := NewEmailNotifier(mailer)
+	ad := domain.NewAdvert("id", "https://www.avito.ru/moskva/telefony/iphone_123", "iPhone 13", 800, 1000, true)
+
+	t.Run("sends rendered alert", func(t *testing.T) {
+		err := notifier.Notify(ad, "user@example.com", "Price changed: iPhone 13", "Price changed!\niPhone 13\n<1000 → 800>")
+		require.NoError(t, err)
+
+		messages := srv.Messages()
+		require.Len(t, messages, 1)
+		require.Equal(t, []string{"user@example.com"}, messages[0].To)
+
+		data := string(messages[0].Data)
+		require.True(t, strings.Contains(data, "Subject: Price changed: iPhone 13"))
+		require.True(t, strings.Contains(data, "<p>iPhone 13</p>"))
+		// HTML version is escaped
+		require.True(t, strings.Contains(data, "&lt;1000"))
+	})
+
+	t.Run("validates args", func(t *testing.T) {
+		require.ErrorIs(t, notifier.Notify(ad), ErrNoEmail)
+		require.ErrorIs(t, notifier.Notify(ad, int64(1)), ErrInvalidEmailFormat)
+		require.ErrorIs(t, notifier.Notify(ad, "user@example.com"), ErrNoSubject)
+		require.ErrorIs(t, notifier.Notify(ad, "user@example.com", 1), ErrInvalidSubjectFormat)
+		require.ErrorIs(t, notifier.Notify(ad, "user@example.com", "subject"), ErrNoMessage)
+		require.ErrorIs(t, notifier.Notify(ad, "user@example.com", "subject", 1), ErrInvalidMessageFormat)
+	})
+}
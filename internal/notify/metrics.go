@@ -0,0 +1,14 @@
+package notify
+
+import domain "parser/internal/domain/models"
+
+// Metrics receives delivery results of Multiplexer. See metrics.Prometheus
+type Metrics interface {
+	NotificationSent(channel domain.Channel)
+	NotificationFailed(channel domain.Channel)
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) NotificationSent(channel domain.Channel)   {}
+func (nopMetrics) NotificationFailed(channel domain.Channel) {}
@@ -0,0 +1,68 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	domain "parser/internal/domain/models"
+)
+
+var (
+	ErrNoChannel            = errors.New("missing channel in args")
+	ErrInvalidChannelFormat = errors.New("channel should be domain.Channel")
+
+	// Returned if no notifier is registered for the channel.
+	// e.g. email notifications are disabled in config
+	ErrChannelDisabled = errors.New("notification channel is disabled")
+)
+
+// Multiplexer routes notification to notifier of specific channel
+type Multiplexer struct {
+	notifiers map[domain.Channel]Notifier
+	metrics   Metrics
+}
+
+func NewMultiplexer() *Multiplexer {
+	return &Multiplexer{
+		notifiers: make(map[domain.Channel]Notifier),
+		metrics:   nopMetrics{},
+	}
+}
+
+// Should be called before first Notify
+func (m *Multiplexer) SetMetrics(metrics Metrics) {
+	m.metrics = metrics
+}
+
+// Should be called before first Notify
+func (m *Multiplexer) Register(channel domain.Channel, notifier Notifier) {
+	m.notifiers[channel] = notifier
+}
+
+// args[0] - channel to send notification to (domain.Channel)
+// args[1:] - args of notifier registered for the channel
+// e.g. Notify(ad, domain.ChannelTelegram, chatID, msg)
+func (m *Multiplexer) Notify(target *domain.Advert, args ...interface{}) error {
+	if len(args) == 0 {
+		return ErrNoChannel
+	}
+
+	channel, ok := args[0].(domain.Channel)
+	if !ok {
+		return ErrInvalidChannelFormat
+	}
+
+	notifier, ok := m.notifiers[channel]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrChannelDisabled, channel)
+	}
+
+	err := notifier.Notify(target, args[1:]...)
+	if err != nil {
+		m.metrics.NotificationFailed(channel)
+		return err
+	}
+
+	m.metrics.NotificationSent(channel)
+
+	return nil
+}
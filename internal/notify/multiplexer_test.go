@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"errors"
+	"testing"
+
+	domain "parser/internal/domain/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+type recordingNotifier struct {
+	args [][]interface{}
+}
+
+func (rn *recordingNotifier) Notify(ad *domain.Advert, args ...interface{}) error {
+	rn.args = append(rn.args, args)
+	return nil
+}
+
+type failingNotifier struct{}
+
+func (fn failingNotifier) Notify(ad *domain.Advert, args ...interface{}) error {
+	return errors.New("smtp is down")
+}
+
+type countingMetrics struct {
+	sent, failed map[domain.Channel]int
+}
+
+func (cm *countingMetrics) NotificationSent(channel domain.Channel) {
+	cm.sent[channel]++
+}
+
+func (cm *countingMetrics) NotificationFailed(channel domain.Channel) {
+	cm.failed[channel]++
+}
+
+func TestMultiplexer(t *testing.T) {
+	telegram := new(recordingNotifier)
+	metrics := &countingMetrics{sent: map[domain.Channel]int{}, failed: map[domain.Channel]int{}}
+
+	mux := NewMultiplexer()
+	mux.SetMetrics(metrics)
+	mux.Register(domain.ChannelTelegram, telegram)
+
+	ad := domain.NewAdvert("id", "url", "title", 800, 1000, true)
+
+	err := mux.Notify(ad, domain.ChannelTelegram, int64(1), "msg")
+	require.NoError(t, err)
+	require.Equal(t, [][]interface{}{{int64(1), "msg"}}, telegram.args)
+
+	err = mux.Notify(ad, domain.ChannelEmail, "user@example.com")
+	require.ErrorIs(t, err, ErrChannelDisabled)
+
+	require.ErrorIs(t, mux.Notify(ad), ErrNoChannel)
+	require.ErrorIs(t, mux.Notify(ad, "telegram"), ErrInvalidChannelFormat)
+
+	mux.Register(domain.ChannelEmail, failingNotifier{})
+	require.Error(t, mux.Notify(ad, domain.ChannelEmail, "user@example.com"))
+
+	// Disabled channel is neither sent nor failed
+	require.Equal(t, map[domain.Channel]int{domain.ChannelTelegram: 1}, metrics.sent)
+	require.Equal(t, map[domain.Channel]int{domain.ChannelEmail: 1}, metrics.failed)
+}
@@ -1,6 +1,15 @@
 package notify
 
-import domain "parser/internal/domain/models"
+import (
+	"errors"
+	domain "parser/internal/domain/models"
+)
+
+var (
+	// Returned by Notifier when recipient could not receive notifications
+	// anymore (e.g. blocked the bot). Caller should stop notifying such recipient.
+	ErrRecipientUnreachable = errors.New("recipient is unreachable")
+)
 
 type Notifier interface {
 	// `args` are specific for every Notifier impl.
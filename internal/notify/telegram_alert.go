@@ -0,0 +1,39 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"parser/internal/telegram"
+)
+
+// TelegramAlerter sends alerts meant for operators of the tracker.
+// See services.Alerter
+type TelegramAlerter struct {
+	tg      telegram.Telegram
+	chatIDs []int64
+}
+
+func NewTelegramAlerter(telegram telegram.Telegram, chatIDs []int64) *TelegramAlerter {
+	return &TelegramAlerter{tg: telegram, chatIDs: chatIDs}
+}
+
+// Alert is sent to every chat even if some of them fail
+func (ta *TelegramAlerter) Alert(ctx context.Context, text string) error {
+	var (
+		failed  int
+		lastErr error
+	)
+
+	for _, chatID := range ta.chatIDs {
+		if err := ta.tg.SendMessage(chatID, text); err != nil {
+			failed++
+			lastErr = err
+		}
+	}
+
+	if lastErr != nil {
+		return fmt.Errorf("alert failed in %d of %d chats: %w", failed, len(ta.chatIDs), lastErr)
+	}
+
+	return nil
+}
@@ -41,6 +41,10 @@ func (tn *telegramNotifier) Notify(target *domain.Advert, args ...interface{}) e
 
 	err = tn.tg.SendMessage(nargs.chatIdentifier, nargs.message)
 	if err != nil {
+		if telegram.IsUnreachable(err) {
+			return fmt.Errorf("%w: %v", ErrRecipientUnreachable, err)
+		}
+
 		return fmt.Errorf("error sending message: %w", err)
 	}
 
@@ -0,0 +1,276 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	domain "parser/internal/domain/models"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Bumped on every breaking change of WebhookEvent
+	WebhookEventVersion = "1"
+
+	EventPriceChanged = "advert.price_changed"
+
+	// HMAC-SHA256 of "{timestamp}.{body}" with webhook secret, hex encoded.
+	// e.g. sha256=5257a869e7ecebeda32affa62cdca3fa51cad7e77a0e56ff536d0ce8e108d8bd
+	SignatureHeader = "X-Tracker-Signature"
+	// Unix time when request is signed. Receivers should reject old ones
+	TimestampHeader = "X-Tracker-Timestamp"
+	// Same for every retry of one event
+	EventIDHeader = "X-Tracker-Event-Id"
+
+	defaultWebhookTimeout = time.Second * 5
+	defaultWebhookBackoff = time.Second
+)
+
+var (
+	ErrNoWebhookURL            = errors.New("missing url in args")
+	ErrInvalidWebhookURLFormat = errors.New("url should be string")
+
+	ErrNoWebhookSecret            = errors.New("missing secret in args")
+	ErrInvalidWebhookSecretFormat = errors.New("secret should be string")
+	ErrInvalidWebhookCtxFormat    = errors.New("third arg should be context.Context")
+)
+
+// WebhookEvent is JSON payload POSTed to webhooks
+type WebhookEvent struct {
+	Version   string    `json:"version"`
+	Type      string    `json:"type"`
+	EventID   string    `json:"event_id"`
+	Timestamp time.Time `json:"timestamp"`
+
+	Advert struct {
+		ID    string `json:"id"`
+		URL   string `json:"url"`
+		Title string `json:"title"`
+	} `json:"advert"`
+
+	OldPrice      float64  `json:"old_price"`
+	NewPrice      float64  `json:"new_price"`
+	ChangedFields []string `json:"changed_fields"`
+}
+
+type WebhookNotifierOptions struct {
+	// Maximum amount of time for one request
+	Timeout time.Duration
+	// Amount of extra attempts after first failed one
+	Retries int
+	// Delay before first retry. Doubled on every next retry
+	Backoff time.Duration
+	// Lets webhooks reach loopback and private networks, e.g. in tests.
+	// Otherwise such addresses are refused when dialed
+	AllowPrivateHosts bool
+}
+
+type webhookNotifier struct {
+	client  *http.Client
+	retries int
+	backoff time.Duration
+}
+
+func NewWebhookNotifier(opts *WebhookNotifierOptions) Notifier {
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = defaultWebhookTimeout
+	}
+
+	backoff := opts.Backoff
+	if backoff == 0 {
+		backoff = defaultWebhookBackoff
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	if !opts.AllowPrivateHosts {
+		// Checked after name is resolved, so DNS can't point webhook inside
+		dialer.Control = refusePrivate
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	// Environment proxy would be dialed instead of webhook host
+	transport.Proxy = nil
+
+	return &webhookNotifier{
+		client:  &http.Client{Timeout: timeout, Transport: transport},
+		retries: opts.Retries,
+		backoff: backoff,
+	}
+}
+
+func refusePrivate(network, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	if ip := net.ParseIP(host); ip == nil || !domain.IsPublicIP(ip) {
+		return fmt.Errorf("%w: %s", domain.ErrPrivateWebhookHost, host)
+	}
+
+	return nil
+}
+
+// args[0] - url to POST event to (string)
+// args[1] - secret to sign payload with (string)
+// args[2] - optional, bounds every attempt and backoff between them (context.Context)
+func (wn *webhookNotifier) Notify(target *domain.Advert, args ...interface{}) error {
+	url, secret, err := wn.validateArgs(args)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if len(args) > 2 {
+		ctx, err = wn.validateCtx(args[2])
+		if err != nil {
+			return err
+		}
+	}
+
+	event := NewWebhookEvent(target)
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	backoff := wn.backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := wn.post(ctx, url, secret, event.EventID, body)
+		if err == nil {
+			return nil
+		}
+
+		if !retry || attempt == wn.retries {
+			return fmt.Errorf("error delivering webhook after %d attempt(s): %w", attempt+1, err)
+		}
+
+		wait := time.NewTimer(backoff)
+		select {
+		case <-wait.C:
+		case <-ctx.Done():
+			wait.Stop()
+			return fmt.Errorf("error delivering webhook after %d attempt(s): %w", attempt+1, ctx.Err())
+		}
+
+		backoff *= 2
+	}
+}
+
+func NewWebhookEvent(ad *domain.Advert) *WebhookEvent {
+	event := &WebhookEvent{
+		Version:       WebhookEventVersion,
+		Type:          EventPriceChanged,
+		EventID:       uuid.NewString(),
+		Timestamp:     time.Now().UTC(),
+		OldPrice:      ad.LastPrice(),
+		NewPrice:      ad.CurrentPrice(),
+		ChangedFields: ad.ChangedFields(),
+	}
+
+	event.Advert.ID = ad.AdvertID
+	event.Advert.URL = ad.URL()
+	event.Advert.Title = ad.Title()
+
+	if event.ChangedFields == nil {
+		event.ChangedFields = []string{}
+	}
+
+	return event
+}
+
+// Sign returns hex encoded HMAC-SHA256 of "{timestamp}.{body}"
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// post returns true if request could be retried
+func (wn *webhookNotifier) post(ctx context.Context, url, secret, eventID string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	// Sign every attempt so receivers could check freshness
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventIDHeader, eventID)
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, "sha256="+Sign(secret, timestamp, body))
+
+	res, err := wn.client.Do(req)
+	if errors.Is(err, domain.ErrPrivateWebhookHost) || ctx.Err() != nil {
+		return false, err
+	}
+
+	if err != nil {
+		// Network error or timeout
+		return true, err
+	}
+	defer res.Body.Close()
+
+	// Drain body to reuse connection
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 4096))
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return false, nil
+	}
+
+	err = fmt.Errorf("unexpected status: %d", res.StatusCode)
+
+	// Receiver could recover from these
+	retry := res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests || res.StatusCode == http.StatusRequestTimeout
+	return retry, err
+}
+
+func (wn *webhookNotifier) validateArgs(args []interface{}) (string, string, error) {
+	if len(args) == 0 {
+		return "", "", ErrNoWebhookURL
+	}
+
+	url, ok := args[0].(string)
+	if !ok {
+		return "", "", ErrInvalidWebhookURLFormat
+	}
+
+	if len(args) < 2 {
+		return "", "", ErrNoWebhookSecret
+	}
+
+	secret, ok := args[1].(string)
+	if !ok {
+		return "", "", ErrInvalidWebhookSecretFormat
+	}
+
+	return url, secret, nil
+}
+
+func (wn *webhookNotifier) validateCtx(arg interface{}) (context.Context, error) {
+	ctx, ok := arg.(context.Context)
+	if !ok {
+		return nil, ErrInvalidWebhookCtxFormat
+	}
+
+	return ctx, nil
+}
@@ -0,0 +1,143 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	domain "parser/internal/domain/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testWebhookSecret = "webhook-secret"
+
+func TestWebhookNotifier(t *testing.T) {
+	ad := domain.NewAdvert("advert-id", "https://www.avito.ru/moskva/telefony/iphone_123", "iPhone 13", 1000, 900, true)
+	ad.UpdatePrice(800)
+
+	notifier := NewWebhookNotifier(&WebhookNotifierOptions{
+		Timeout: time.Second,
+		Retries: 2,
+		Backoff: time.Millisecond,
+		// Test servers listen on loopback
+		AllowPrivateHosts: true,
+	})
+
+	t.Run("posts signed event", func(t *testing.T) {
+		var event WebhookEvent
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+
+			signature := "sha256=" + Sign(testWebhookSecret, r.Header.Get(TimestampHeader), body)
+			require.Equal(t, signature, r.Header.Get(SignatureHeader))
+			require.NoError(t, json.Unmarshal(body, &event))
+			require.Equal(t, event.EventID, r.Header.Get(EventIDHeader))
+		}))
+		defer srv.Close()
+
+		err := notifier.Notify(ad, srv.URL, testWebhookSecret)
+		require.NoError(t, err)
+
+		require.Equal(t, WebhookEventVersion, event.Version)
+		require.Equal(t, EventPriceChanged, event.Type)
+		require.Equal(t, "advert-id", event.Advert.ID)
+		require.Equal(t, 1000.0, event.OldPrice)
+		require.Equal(t, 800.0, event.NewPrice)
+		require.Equal(t, []string{domain.FieldPrice}, event.ChangedFields)
+	})
+
+	t.Run("retries server errors", func(t *testing.T) {
+		var attempts int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&attempts, 1) < 3 {
+				w.WriteHeader(http.StatusBadGateway)
+			}
+		}))
+		defer srv.Close()
+
+		err := notifier.Notify(ad, srv.URL, testWebhookSecret)
+		require.NoError(t, err)
+		require.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+	})
+
+	t.Run("gives up after retries", func(t *testing.T) {
+		var attempts int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&attempts, 1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer srv.Close()
+
+		err := notifier.Notify(ad, srv.URL, testWebhookSecret)
+		require.Error(t, err)
+		require.Equal(t, int32(3) /* first attempt + 2 retries */, atomic.LoadInt32(&attempts))
+	})
+
+	t.Run("does not retry client errors", func(t *testing.T) {
+		var attempts int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&attempts, 1)
+			w.WriteHeader(http.StatusGone)
+		}))
+		defer srv.Close()
+
+		err := notifier.Notify(ad, srv.URL, testWebhookSecret)
+		require.Error(t, err)
+		require.True(t, strings.Contains(err.Error(), "410"))
+		require.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+	})
+
+	t.Run("stops retrying once context is done", func(t *testing.T) {
+		var attempts int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&attempts, 1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer srv.Close()
+
+		slow := NewWebhookNotifier(&WebhookNotifierOptions{
+			Retries:           5,
+			Backoff:           time.Hour,
+			AllowPrivateHosts: true,
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		err := slow.Notify(ad, srv.URL, testWebhookSecret, ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Less(t, time.Since(start), time.Second)
+		require.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+	})
+
+	t.Run("refuses private hosts when dialed", func(t *testing.T) {
+		var attempts int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&attempts, 1)
+		}))
+		defer srv.Close()
+
+		strict := NewWebhookNotifier(&WebhookNotifierOptions{Retries: 2, Backoff: time.Millisecond})
+
+		// Name resolves to loopback
+		err := strict.Notify(ad, strings.Replace(srv.URL, "127.0.0.1", "localhost", 1), testWebhookSecret)
+		require.ErrorIs(t, err, domain.ErrPrivateWebhookHost)
+		require.Equal(t, int32(0), atomic.LoadInt32(&attempts))
+	})
+
+	t.Run("validates args", func(t *testing.T) {
+		require.ErrorIs(t, notifier.Notify(ad), ErrNoWebhookURL)
+		require.ErrorIs(t, notifier.Notify(ad, "http://localhost"), ErrNoWebhookSecret)
+		require.ErrorIs(t, notifier.Notify(ad, 1, "secret"), ErrInvalidWebhookURLFormat)
+		require.ErrorIs(t, notifier.Notify(ad, "http://localhost", "secret", "ctx"), ErrInvalidWebhookCtxFormat)
+	})
+}
@@ -3,48 +3,69 @@ package parser
 import (
 	"context"
 	"fmt"
-	"regexp"
-	"strconv"
-	"strings"
 	"time"
 
+	"github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/cdproto/dom"
 	"github.com/chromedp/chromedp"
 )
 
-// Uses chrome dev tools protocol to parse data
+// Uses chrome dev tools protocol to load pages.
+// Advert is extracted from html by CurrentExtractor
 type ChromeParser struct {
-	matchers [4]*regexp.Regexp
-	ctx      context.Context
-	cancel   context.CancelFunc
+	extractor *Extractor
+	ctx       context.Context
+	cancel    context.CancelFunc
+
+	// Closed once connection to browser is lost
+	lost chan struct{}
 }
 
 func NewChromeParser() (*ChromeParser, error) {
 
-	titlerx := regexp.MustCompile(`"title-info-title-text.*?<`)
-	pricerx := regexp.MustCompile(`js-item-price.*?<`)
-	imagerx1 := regexp.MustCompile("image-frame-cover.*?<div")
-	imagerx2 := regexp.MustCompile(`src="(.*?)"`)
-
 	// Start browser instance
 	ctx, cancel := chromedp.NewContext(context.Background())
 	if err := chromedp.Run(ctx); err != nil {
 		return nil, fmt.Errorf("error booting a browser: %w", err)
 	}
 
-	// TODO: remove panic
+	// Dead browser fails liveness check, see Health
+	lost := make(chan struct{})
 	go func() {
 		<-chromedp.FromContext(ctx).Browser.LostConnection
-		panic("browser is down")
+		close(lost)
 	}()
 
 	return &ChromeParser{
-		matchers: [4]*regexp.Regexp{titlerx, pricerx, imagerx1, imagerx2},
-		ctx:      ctx,
-		cancel:   cancel,
+		extractor: CurrentExtractor(),
+		ctx:       ctx,
+		cancel:    cancel,
+		lost:      lost,
 	}, nil
 }
 
+// Health checks that browser is alive and responds to commands
+func (p *ChromeParser) Health(ctx context.Context) error {
+	select {
+	case <-p.lost:
+		return ErrBrowserDown
+	default:
+	}
+
+	c := chromedp.FromContext(p.ctx)
+	if c == nil || c.Browser == nil {
+		return ErrBrowserDown
+	}
+
+	_, _, _, _, _, err := browser.GetVersion().Do(cdp.WithExecutor(ctx, c.Browser))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBrowserDown, err)
+	}
+
+	return nil
+}
+
 // TODO: find better way to signal for ErrURLUnavailable
 // Current solution is very side-effectiveish and not clear!!
 func (p *ChromeParser) Parse(timeout time.Duration, url string) *ParseResult {
@@ -74,74 +95,5 @@ func (p *ChromeParser) Parse(timeout time.Duration, url string) *ParseResult {
 		return NewParseResultWithError(err, &html)
 	}
 
-	title, err := p.parseTitle(&html)
-	if err != nil {
-		err = fmt.Errorf("parser: title parsing error: %w", err)
-		return NewParseResultWithError(err, &html)
-	}
-
-	price, err := p.parsePrice(&html)
-	if err != nil {
-		err = fmt.Errorf("parser: price parsing error: %w", err)
-		return NewParseResultWithError(err, &html)
-	}
-
-	return NewParseResult(title, price, url)
-}
-
-func (p *ChromeParser) parseTitle(buff *string) (string, error) {
-
-	rx := p.matchers[0]
-	results := rx.FindAllString(*buff, 1)
-
-	// URL is unavailable or ip is banned
-	if len(results) == 0 {
-		return "", ErrURLUnavailable
-	}
-
-	spl := strings.Split(results[0], "")
-
-	var title string
-	for i := len(spl) - 2; i >= 0; i-- {
-		if spl[i] == ">" {
-			break
-		}
-
-		title = spl[i] + title
-	}
-
-	return title, nil
-}
-
-func (p *ChromeParser) parsePrice(buff *string) (float64, error) {
-
-	rx := p.matchers[1]
-	results := rx.FindAllString(*buff, 1)
-
-	if len(results) == 0 {
-		return 0.0, ErrURLUnavailable
-	}
-
-	spl := strings.Split(results[0], "")
-
-	var pricestr string
-	for i := len(spl) - 2; i >= 0; i-- {
-		if spl[i] == ">" {
-			break
-		}
-
-		// Compare by charcode (leave only numbers)
-		if spl[i][0] < 48 || spl[i][0] > 57 {
-			continue
-		}
-
-		pricestr = spl[i] + pricestr
-	}
-
-	pricefloat, err := strconv.ParseFloat(pricestr, 64)
-	if err != nil {
-		return 0.0, err
-	}
-
-	return pricefloat, nil
+	return p.extractor.Extract(url, &html)
 }
@@ -0,0 +1,165 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var ErrUnknownExtractor = errors.New("unknown extractor version")
+
+// Fields of advert extracted from page
+const (
+	FieldTitle = "title"
+	FieldPrice = "price"
+)
+
+// Extractor pulls advert out of page html. It needs no browser,
+// so archived pages could be parsed again offline, see replay package
+type Extractor struct {
+	Version string
+
+	title *regexp.Regexp
+	price *regexp.Regexp
+}
+
+// Versions of extractor, oldest first. Fixed matchers go to new version
+// instead of changing old one, so replay could compare them
+var extractors = []*Extractor{
+	mustExtractor("v1", `"title-info-title-text.*?<`, `js-item-price.*?<`),
+}
+
+// NewExtractor takes patterns matching text of field up to closing tag,
+// e.g. `js-item-price.*?<`. Used for experiments and tests, see replay package
+func NewExtractor(version, titlePattern, pricePattern string) (*Extractor, error) {
+	title, err := regexp.Compile(titlePattern)
+	if err != nil {
+		return nil, fmt.Errorf("title: %w", err)
+	}
+
+	price, err := regexp.Compile(pricePattern)
+	if err != nil {
+		return nil, fmt.Errorf("price: %w", err)
+	}
+
+	return &Extractor{Version: version, title: title, price: price}, nil
+}
+
+func mustExtractor(version, titlePattern, pricePattern string) *Extractor {
+	e, err := NewExtractor(version, titlePattern, pricePattern)
+	if err != nil {
+		panic(err)
+	}
+
+	return e
+}
+
+// TitlePattern is source of title matcher, see NewExtractor
+func (e *Extractor) TitlePattern() string {
+	return e.title.String()
+}
+
+// PricePattern is source of price matcher, see NewExtractor
+func (e *Extractor) PricePattern() string {
+	return e.price.String()
+}
+
+// CurrentExtractor is used by ChromeParser
+func CurrentExtractor() *Extractor {
+	return extractors[len(extractors)-1]
+}
+
+// PreviousExtractor is version before current one. Nil if there is none
+func PreviousExtractor() *Extractor {
+	if len(extractors) < 2 {
+		return nil
+	}
+
+	return extractors[len(extractors)-2]
+}
+
+func ExtractorVersion(version string) (*Extractor, error) {
+	for _, e := range extractors {
+		if e.Version == version {
+			return e, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnknownExtractor, version)
+}
+
+// Extract returns result the same way ChromeParser does, html is kept
+func (e *Extractor) Extract(url string, html *string) *ParseResult {
+	title, err := e.Title(html)
+	if err != nil {
+		err = fmt.Errorf("parser: title parsing error: %w", err)
+		return NewFailedParseResult(url, err, html)
+	}
+
+	price, err := e.Price(html)
+	if err != nil {
+		err = fmt.Errorf("parser: price parsing error: %w", err)
+		return NewFailedParseResult(url, err, html)
+	}
+
+	return NewParseResultWithRaw(title, price, url, html)
+}
+
+// Title fails with ErrURLUnavailable if there is no title,
+// e.g. advert is removed or ip is banned
+func (e *Extractor) Title(buff *string) (string, error) {
+
+	results := e.title.FindAllString(*buff, 1)
+
+	// URL is unavailable or ip is banned
+	if len(results) == 0 {
+		return "", ErrURLUnavailable
+	}
+
+	spl := strings.Split(results[0], "")
+
+	var title string
+	for i := len(spl) - 2; i >= 0; i-- {
+		if spl[i] == ">" {
+			break
+		}
+
+		title = spl[i] + title
+	}
+
+	return title, nil
+}
+
+func (e *Extractor) Price(buff *string) (float64, error) {
+
+	results := e.price.FindAllString(*buff, 1)
+
+	if len(results) == 0 {
+		return 0.0, ErrURLUnavailable
+	}
+
+	spl := strings.Split(results[0], "")
+
+	var pricestr string
+	for i := len(spl) - 2; i >= 0; i-- {
+		if spl[i] == ">" {
+			break
+		}
+
+		// Compare by charcode (leave only numbers)
+		if spl[i][0] < 48 || spl[i][0] > 57 {
+			continue
+		}
+
+		pricestr = spl[i] + pricestr
+	}
+
+	pricefloat, err := strconv.ParseFloat(pricestr, 64)
+	if err != nil {
+		return 0.0, err
+	}
+
+	return pricefloat, nil
+}
@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// go test ./internal/parser -run TestExtractorGolden -update
+var update = flag.Bool("update", false, "rewrite golden results of testdata/pages")
+
+const (
+	pagesDir   = "testdata/pages"
+	goldenPath = "testdata/golden.json"
+)
+
+// Expected extraction result of recorded page
+type golden struct {
+	Title   string  `json:"title"`
+	Price   float64 `json:"price"`
+	Outcome string  `json:"outcome"`
+}
+
+func goldenOf(result *ParseResult) *golden {
+	return &golden{Title: result.Title(), Price: result.Price(), Outcome: Outcome(result.Err())}
+}
+
+func readGolden(t *testing.T) map[string]*golden {
+	t.Helper()
+
+	raw, err := os.ReadFile(goldenPath)
+	require.NoError(t, err)
+
+	var want map[string]*golden
+	require.NoError(t, json.Unmarshal(raw, &want))
+
+	return want
+}
+
+func TestExtractorGolden(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join(pagesDir, "*.html"))
+	require.NoError(t, err)
+	require.NotEmpty(t, paths)
+
+	got := make(map[string]*golden, len(paths))
+	for _, path := range paths {
+		html, err := os.ReadFile(path)
+		require.NoError(t, err)
+
+		page := string(html)
+		got[strings.TrimSuffix(filepath.Base(path), ".html")] = goldenOf(CurrentExtractor().Extract(path, &page))
+	}
+
+	if *update {
+		raw, err := json.MarshalIndent(got, "", "  ")
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(goldenPath, append(raw, '\n'), 0o644))
+	}
+
+	want := readGolden(t)
+	for name := range got {
+		require.Contains(t, want, name, "page has no golden result, run with -update")
+	}
+
+	for name, w := range want {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, w, got[name])
+		})
+	}
+}
+
+func TestExtractorVersions(t *testing.T) {
+	current := CurrentExtractor()
+
+	e, err := ExtractorVersion(current.Version)
+	require.NoError(t, err)
+	require.Same(t, current, e)
+
+	_, err = ExtractorVersion("v0")
+	require.ErrorIs(t, err, ErrUnknownExtractor)
+
+	_, err = NewExtractor("broken", `(`, `js-item-price.*?<`)
+	require.Error(t, err)
+}
@@ -0,0 +1,130 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"parser/internal/fakemarket"
+	"parser/internal/timer"
+
+	"github.com/stretchr/testify/require"
+)
+
+// httpParser loads pages without browser, the rest of pipeline is the same as with ChromeParser
+type httpParser struct{}
+
+func (hp *httpParser) Parse(timeout time.Duration, url string) *ParseResult {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return NewParseResultWithError(err, nil)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return NewParseResultWithError(fmt.Errorf("parser: intenal: %w", err), nil)
+	}
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return NewParseResultWithError(fmt.Errorf("parser: intenal: %w", err), nil)
+	}
+
+	// Like browser, status is ignored, page tells what happened to advert
+	html := string(body)
+	return CurrentExtractor().Extract(url, &html)
+}
+
+func newFakeMarket(t *testing.T) *fakemarket.Server {
+	t.Helper()
+
+	market, err := fakemarket.New(pagesDir)
+	require.NoError(t, err)
+	t.Cleanup(market.Close)
+
+	return market
+}
+
+func TestFakeMarketPipeline(t *testing.T) {
+	want := readGolden(t)
+
+	t.Run("test parses recorded pages through ring", func(t *testing.T) {
+		t.Parallel()
+
+		market := newFakeMarket(t)
+		// Removed advert is answered with 404 by marketplace
+		market.SetRoute("removed", fakemarket.Route{Status: http.StatusNotFound})
+
+		metrics := new(recordingMetrics)
+		ringParser := NewRingParser(&RingParserOptions{
+			Parser:         new(httpParser),
+			UrlCache:       new(NoOpUrlCacher),
+			ParsingTimeout: time.Second,
+			Timer:          timer.NewAppTimer(),
+			OutChanBuff:    int32(len(want)),
+			Metrics:        metrics,
+		})
+
+		for name := range want {
+			ringParser.AddTarget(market.URL(name))
+		}
+
+		for range want {
+			ringParser.parse()
+		}
+
+		for range want {
+			result := <-ringParser.Out()
+			name := result.URL()[strings.LastIndex(result.URL(), "/")+1:]
+
+			require.Equal(t, want[name], goldenOf(result), name)
+			require.Equal(t, 1, market.Hits(name))
+		}
+
+		require.Len(t, metrics.outcomes, len(want))
+	})
+
+	t.Run("test times out slow marketplace", func(t *testing.T) {
+		t.Parallel()
+
+		market := newFakeMarket(t)
+		market.SetLatency(time.Second)
+
+		result := new(httpParser).Parse(50*time.Millisecond, market.URL("active"))
+		require.Equal(t, OutcomeTimeout, Outcome(result.Err()))
+
+		// Slow page within timeout is parsed
+		market.SetRoute("active", fakemarket.Route{Latency: 10 * time.Millisecond})
+		result = new(httpParser).Parse(time.Second, market.URL("active"))
+		require.Equal(t, want["active"], goldenOf(result))
+	})
+
+	t.Run("test chrome parser extracts recorded pages", func(t *testing.T) {
+		if testing.Short() {
+			t.Skip("browser is slow to start")
+		}
+
+		chrome, err := NewChromeParser()
+		if err != nil {
+			t.Skipf("no browser: %v", err)
+		}
+
+		defer chrome.cancel()
+
+		market := newFakeMarket(t)
+
+		for name, w := range want {
+			result := chrome.Parse(10*time.Second, market.URL(name))
+			require.Equal(t, w, goldenOf(result), name)
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Outcomes of parsing
+const (
+	OutcomeOK = "ok"
+	// Page didn't load within parsing timeout
+	OutcomeTimeout = "timeout"
+	// Advert is removed or IP is banned, see ErrURLUnavailable
+	OutcomeUnavailable = "unavailable"
+	// Page has changed and price is not a number anymore
+	OutcomeMalformed = "malformed"
+	OutcomeFailed    = "failed"
+)
+
+// Metrics receives measurements of RingParser. See metrics.Prometheus
+type Metrics interface {
+	ObserveParse(host, outcome string, d time.Duration)
+	// Duration of full pass over every target
+	ObserveCycle(d time.Duration)
+	// Hit means URL is skipped because it has been parsed recently
+	ObserveCacheLookup(hit bool)
+	SetRingSize(n int)
+	// Results queued in Out channel
+	SetOutDepth(n int)
+}
+
+// Outcome classifies error of ParseResult
+func Outcome(err error) string {
+	var numErr *strconv.NumError
+
+	switch {
+	case err == nil:
+		return OutcomeOK
+	case errors.Is(err, context.DeadlineExceeded):
+		return OutcomeTimeout
+	case errors.Is(err, ErrURLUnavailable):
+		return OutcomeUnavailable
+	case errors.As(err, &numErr):
+		return OutcomeMalformed
+	}
+
+	return OutcomeFailed
+}
+
+// Keeps cardinality of host label bounded by marketplaces
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return "unknown"
+	}
+
+	return u.Hostname()
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) ObserveParse(host, outcome string, d time.Duration) {}
+func (nopMetrics) ObserveCycle(d time.Duration)                       {}
+func (nopMetrics) ObserveCacheLookup(hit bool)                        {}
+func (nopMetrics) SetRingSize(n int)                                  {}
+func (nopMetrics) SetOutDepth(n int)                                  {}
@@ -3,10 +3,14 @@ package parser
 import (
 	"errors"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
 	ErrURLUnavailable = errors.New("URL is unavailable")
+	ErrBrowserDown    = errors.New("browser is down")
+	ErrParserStalled  = errors.New("parsing loop is stalled")
 )
 
 type Parser interface {
@@ -17,6 +21,16 @@ type TargetAdder interface {
 	AddTarget(url string)
 }
 
+type TargetRemover interface {
+	RemoveTarget(url string)
+}
+
+// TargetManager controls what URLs are parsed
+type TargetManager interface {
+	TargetAdder
+	TargetRemover
+}
+
 type ParseResult struct {
 	url string
 
@@ -26,15 +40,28 @@ type ParseResult struct {
 
 	// original html that was parsed
 	raw *string
+
+	// Span of parsing. Handling of result continues its trace
+	spanContext trace.SpanContext
 }
 
 func NewParseResult(title string, price float64, URL string) *ParseResult {
 	return &ParseResult{title: title, price: price, url: URL, err: nil, raw: nil}
 }
 
+// NewParseResultWithRaw keeps html advert was extracted from, e.g. for snapshots
+func NewParseResultWithRaw(title string, price float64, URL string, raw *string) *ParseResult {
+	return &ParseResult{title: title, price: price, url: URL, raw: raw}
+}
+
 func NewParseResultWithError(err error, raw *string) *ParseResult {
 	return &ParseResult{title: "", price: 0.0, err: err, raw: raw}
 }
+
+// NewFailedParseResult is result with error of known URL, e.g. one filled by RingParser
+func NewFailedParseResult(URL string, err error, raw *string) *ParseResult {
+	return &ParseResult{url: URL, err: err, raw: raw}
+}
 func (pr *ParseResult) Title() string {
 	return pr.title
 }
@@ -54,3 +81,8 @@ func (pr *ParseResult) URL() string {
 func (pr *ParseResult) Raw() *string {
 	return pr.raw
 }
+
+// SpanContext is invalid if result wasn't produced by RingParser
+func (pr *ParseResult) SpanContext() trace.SpanContext {
+	return pr.spanContext
+}
@@ -1,15 +1,29 @@
 package parser
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"parser/internal/clock"
+	"parser/internal/logger"
 	"parser/internal/timer"
+	"parser/internal/tracing"
 	"parser/internal/urlcache"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("parser/internal/parser")
+
+// Loop is stalled if it hasn't started a cycle for that many intervals
+// plus parsing timeout
+const stallIntervals = 3
+
 type RingParserOptions struct {
 	Parser         Parser
 	UrlCache       urlcache.UrlCacher
@@ -17,15 +31,20 @@ type RingParserOptions struct {
 	Timer          timer.Timer
 	// Buffer length of .Out() chan
 	OutChanBuff int32
+	// Optional. Receives parse latency, outcomes, ring size etc.
+	Metrics Metrics
+	// Optional
+	Logger logger.Logger
+	// Optional. Measures cycles and stalls, should be the one Timer uses
+	Clock clock.Clock
 }
 
 type RingParser struct {
 	// List of URLs to parse.
 	// Main operands to perform ring operations
 	targets []string
-	offset  int32
-
-	targetlen int32
+	// Index of next target
+	offset int
 
 	// Keep track of what urls are targets. See RingParser.AddTarget
 	urls map[string]struct{}
@@ -58,11 +77,37 @@ type RingParser struct {
 	timeout time.Duration
 	timer   timer.Timer
 
+	// Zero until Run. Stored as int64 to be read by Health
+	interval int64
+	// Start of the last cycle in unix nanoseconds
+	lastCycle int64
+
+	metrics Metrics
+	log     logger.Logger
+	clock   clock.Clock
+	// Start of current pass over targets. Protected by mu
+	cycleStart time.Time
+
 	out      chan *ParseResult
 	shutdown chan struct{}
 }
 
 func NewRingParser(opts *RingParserOptions) *RingParser {
+	var metrics Metrics = nopMetrics{}
+	if opts.Metrics != nil {
+		metrics = opts.Metrics
+	}
+
+	log := opts.Logger
+	if log == nil {
+		log = logger.Nop()
+	}
+
+	clk := opts.Clock
+	if clk == nil {
+		clk = clock.Real()
+	}
+
 	return &RingParser{
 		offset:   0,
 		parser:   opts.Parser,
@@ -74,14 +119,37 @@ func NewRingParser(opts *RingParserOptions) *RingParser {
 		urls:     make(map[string]struct{}),
 		shutdown: make(chan struct{}),
 		out:      make(chan *ParseResult, opts.OutChanBuff),
+		metrics:  metrics,
+		log:      log,
+		clock:    clk,
 	}
 }
 
 // Run spawns a goroutine that performs a parsing within an interval
 func (rp *RingParser) Run(interval time.Duration) {
+	atomic.StoreInt64(&rp.lastCycle, rp.clock.Now().UnixNano())
+	atomic.StoreInt64(&rp.interval, int64(interval))
+
 	rp.timer.Every(interval, rp.parse)
 }
 
+// Health reports loop that doesn't start new cycles,
+// e.g. it is blocked by consumer of Out
+func (rp *RingParser) Health(ctx context.Context) error {
+	interval := time.Duration(atomic.LoadInt64(&rp.interval))
+	// Not running yet
+	if interval == 0 {
+		return nil
+	}
+
+	since := rp.clock.Now().Sub(time.Unix(0, atomic.LoadInt64(&rp.lastCycle)))
+	if since > stallIntervals*interval+rp.timeout {
+		return fmt.Errorf("%w: last cycle started %s ago", ErrParserStalled, since.Round(time.Second))
+	}
+
+	return nil
+}
+
 func (rp *RingParser) Close() {
 	// Stop emitting intervals
 	rp.timer.Stop()
@@ -95,66 +163,134 @@ func (rp *RingParser) Out() <-chan *ParseResult {
 }
 
 func (rp *RingParser) AddTarget(url string) {
-	rp.mu.RLock()
-	_, ok := rp.urls[url]
-	rp.mu.RUnlock()
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
 
 	// URL is already being parsed
-	if ok {
+	if _, ok := rp.urls[url]; ok {
 		return
 	}
 
-	// Add url
-	rp.mu.Lock()
 	rp.targets = append(rp.targets, url)
 	rp.urls[url] = struct{}{}
-	rp.mu.Unlock()
+	rp.metrics.SetRingSize(len(rp.targets))
+	rp.log.Debug("target added", logger.URL(url))
+}
+
+// RemoveTarget stops parsing url, e.g. advert has no subscribers left
+func (rp *RingParser) RemoveTarget(url string) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
 
-	atomic.AddInt32(&rp.targetlen, 1)
-	fmt.Println("added: ", url)
+	// URL is not being parsed
+	if _, ok := rp.urls[url]; !ok {
+		return
+	}
+
+	for i, target := range rp.targets {
+		if target != url {
+			continue
+		}
+
+		rp.targets = append(rp.targets[:i], rp.targets[i+1:]...)
+
+		// Keep offset pointing to the same next target
+		if i < rp.offset {
+			rp.offset--
+		}
+
+		if rp.offset >= len(rp.targets) {
+			rp.offset = 0
+		}
+
+		break
+	}
+
+	delete(rp.urls, url)
+	rp.metrics.SetRingSize(len(rp.targets))
+	rp.log.Debug("target removed", logger.URL(url))
 }
 
 func (rp *RingParser) parse() {
+	atomic.StoreInt64(&rp.lastCycle, rp.clock.Now().UnixNano())
 
-	targetlen := atomic.LoadInt32(&rp.targetlen)
-	if targetlen == 0 {
+	url, ok := rp.next()
+	if !ok {
 		return
 	}
 
-	// Get url to parse
-	rp.mu.RLock()
+	select {
+	case <-rp.shutdown:
+		rp.onClose()
+		return
+	default:
+		// Every parse starts new trace, it ends with notifications sent
+		_, span := tracer.Start(context.Background(), "RingParser.parse",
+			trace.WithNewRoot(),
+			trace.WithAttributes(attribute.String("url", url)),
+		)
+
+		start := rp.clock.Now()
+		result := rp.parser.Parse(rp.timeout, url)
+		elapsed := rp.clock.Now().Sub(start)
+		rp.metrics.ObserveParse(hostOf(url), Outcome(result.Err()), elapsed)
+
+		// Results with error don't know URL
+		if result.url == "" {
+			result.url = url
+		}
+
+		result.spanContext = span.SpanContext()
+		tracing.RecordError(span, result.Err())
+		span.End()
+
+		// Failures are reported by consumer of Out
+		rp.log.Debug("parsed", logger.URL(url), logger.Duration("elapsed", elapsed), logger.Err(result.Err()))
+
+		rp.out <- result
+		rp.metrics.SetOutDepth(len(rp.out))
+
+		// After successful parsing cache the url
+		rp.urlCache.Set(url)
+	}
+}
+
+// next picks url to parse and moves offset to the following target.
+// Both happen under one lock, so RemoveTarget can't leave offset out of range
+func (rp *RingParser) next() (string, bool) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	if len(rp.targets) == 0 {
+		return "", false
+	}
+
 	url := rp.targets[rp.offset]
-	rp.mu.RUnlock()
 
 	// Beforehand check if url should be parsed
 	should := rp.urlCache.ShouldParse(url)
+	rp.metrics.ObserveCacheLookup(!should)
 	if !should {
-		fmt.Println("hitting cache")
-		return
+		rp.log.Debug("url is cached, skipping", logger.URL(url))
+		return "", false
 	}
 
-	mx := targetlen - 1
 	// If reached end of targets
-	// rp.offset == mx
-	if atomic.LoadInt32(&rp.offset) == mx {
+	if rp.offset == len(rp.targets)-1 {
 		// Move offset ptr to start of array
-		// rp.offset = 0
-		atomic.StoreInt32(&rp.offset, 0)
+		rp.offset = 0
+
+		// Every target has been parsed
+		if !rp.cycleStart.IsZero() {
+			rp.metrics.ObserveCycle(rp.clock.Now().Sub(rp.cycleStart))
+		}
+		rp.cycleStart = rp.clock.Now()
 	} else {
 		// Next item
-		// rp.offset += 1
-		atomic.AddInt32(&rp.offset, 1)
+		rp.offset++
 	}
 
-	select {
-	case <-rp.shutdown:
-		rp.onClose()
-		return
-	default:
-		rp.out <- rp.parser.Parse(rp.timeout, url)
-		// After successful parsing cache the url
-		rp.urlCache.Set(url)
-	}
+	return url, true
 }
 
 func (rp *RingParser) onClose() {
@@ -1,10 +1,15 @@
 package parser
 
 import (
-	"sync/atomic"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
+	"parser/internal/clock"
 	"parser/internal/timer"
 	"parser/internal/urlcache"
 
@@ -17,8 +22,10 @@ var (
 
 type NoOpParser struct{}
 
+// Returns copy of mockParseResult, RingParser fills span context of every result
 func (np *NoOpParser) Parse(timeout time.Duration, url string) *ParseResult {
-	return mockParseResult
+	result := *mockParseResult
+	return &result
 }
 
 type NoOpUrlCacher struct{}
@@ -31,6 +38,39 @@ func (no *NoOpUrlCacher) ShouldParse(url string) bool {
 	return true
 }
 
+type recordingMetrics struct {
+	outcomes  []string
+	cycles    int
+	hits      int
+	misses    int
+	ringSize  int
+	outDepths []int
+}
+
+func (rm *recordingMetrics) ObserveParse(host, outcome string, d time.Duration) {
+	rm.outcomes = append(rm.outcomes, host+" "+outcome)
+}
+
+func (rm *recordingMetrics) ObserveCycle(d time.Duration) {
+	rm.cycles++
+}
+
+func (rm *recordingMetrics) ObserveCacheLookup(hit bool) {
+	if hit {
+		rm.hits++
+	} else {
+		rm.misses++
+	}
+}
+
+func (rm *recordingMetrics) SetRingSize(n int) {
+	rm.ringSize = n
+}
+
+func (rm *recordingMetrics) SetOutDepth(n int) {
+	rm.outDepths = append(rm.outDepths, n)
+}
+
 func TestRingParser(t *testing.T) {
 	t.Run("test can add", func(t *testing.T) {
 		t.Parallel()
@@ -38,7 +78,7 @@ func TestRingParser(t *testing.T) {
 		ringParser := NewRingParser(&RingParserOptions{
 			Parser:         new(NoOpParser),
 			ParsingTimeout: 10,
-			Timer:          new(timer.AppTimer),
+			Timer:          timer.NewAppTimer(),
 			OutChanBuff:    2,
 		})
 
@@ -60,62 +100,216 @@ func TestRingParser(t *testing.T) {
 		require.True(t, len(ringParser.targets) == len(urls))
 	})
 
-	t.Run("test can run, read, gracefully close", func(t *testing.T) {
+	t.Run("test can remove", func(t *testing.T) {
+		t.Parallel()
+
+		ringParser := rpWithURLs()
+		// Next target is "zxcv"
+		ringParser.offset = 2
+
+		ringParser.RemoveTarget("abcd")
+		ringParser.RemoveTarget("unknown")
+
+		require.Equal(t, []string{"efgh", "zxcv", "fhdia", "qiwnx"}, ringParser.targets)
+		require.Len(t, ringParser.targets, 4)
+		require.Equal(t, "zxcv", ringParser.targets[ringParser.offset])
+
+		// Removing the last target wraps offset to start
+		ringParser.offset = 3
+		ringParser.RemoveTarget("qiwnx")
+		require.Equal(t, 0, ringParser.offset)
+
+		// Could be added back
+		ringParser.AddTarget("abcd")
+		require.Len(t, ringParser.targets, 4)
+	})
+
+	t.Run("test parses while targets are added and removed", func(t *testing.T) {
 		t.Parallel()
 
 		ringParser := rpWithURLs()
-		// Add simple reader from output chan
-		var countUpdates int32
+		// Reader keeps parse from blocking
 		go func() {
-			for update := range ringParser.Out() {
-				require.EqualValues(t, mockParseResult, update)
-				atomic.AddInt32(&countUpdates, 1)
+			for range ringParser.Out() {
 			}
 		}()
 
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				for j := 0; j < 200; j++ {
+					url := strconv.Itoa(j % 7)
+					ringParser.AddTarget(url)
+					ringParser.parse()
+					ringParser.RemoveTarget(url)
+				}
+			}()
+		}
+
+		wg.Wait()
+
+		// Concurrent adds of the same url don't duplicate it
+		require.Len(t, ringParser.targets, len(ringParser.urls))
+		require.Less(t, ringParser.offset, len(ringParser.targets))
+	})
+
+	t.Run("test can run, read, gracefully close", func(t *testing.T) {
+		t.Parallel()
+
+		clk := clock.NewFake(time.Now())
+		ringParser := rpWithURLs()
+		ringParser.timer = timer.NewAppTimerWithClock(clk)
+
 		// Spawns a goroutine under the hood
 		ringParser.Run(time.Second * 1 /* Parsing interval */)
+		require.Equal(t, 1, clk.Tickers())
 
-		// Sleep for abit longer than 2000ms(2s) because of some overhead
-		time.Sleep(time.Millisecond * 2005)
+		// Every interval is one parsing
+		for i := 0; i < 2; i++ {
+			clk.Advance(time.Second)
+			require.EqualValues(t, mockParseResult, <-ringParser.Out())
+		}
 
 		ringParser.Close()
-		// Can run two complete parsings.
-		// ringParser.Run has interval - 1s
-		// Time asleep - 2.005s =>
-		// Two complete parsings could be performed
-		require.Equal(t, int32(2) /* expected count updates */, atomic.LoadInt32(&countUpdates))
+
+		// Nothing schedules parsing after close
+		require.Zero(t, clk.Tickers())
+
+		clk.Advance(time.Minute)
+		select {
+		case update := <-ringParser.Out():
+			t.Fatalf("unexpected update after close: %v", update)
+		default:
+		}
 	})
 
 	t.Run("can use urlCache", func(t *testing.T) {
 		t.Parallel()
 
+		clk := clock.NewFake(time.Now())
 		ringParser := rpWithURLs()
 		// Replace NoOp for real impl.
-		ringParser.urlCache = urlcache.NewUrlCache(time.Second * 10 /* cache TTL */)
+		ringParser.urlCache = urlcache.NewUrlCacheWithClock(time.Second*10 /* cache TTL */, clk)
 
-		// Add simple reader from output chan
-		var countUpdates int32
-		go func() {
-			for update := range ringParser.Out() {
-				require.EqualValues(t, mockParseResult, update)
-				atomic.AddInt32(&countUpdates, 1)
-			}
-		}()
+		// Parsing interval is 100ms, 20 intervals are 2 seconds.
+		// Each url set to cache inside ringParser would be parsed
+		// not more often than 10s (cache TTL).
+		// So, expect only 5 updates. (len of ringParser.targets)
+		require.Equal(t, len(ringParser.targets), countParsed(ringParser, clk, 20, time.Millisecond*100))
 
-		ringParser.Run(time.Millisecond * 100)
+		// Cache TTL has passed, every url is parsed again
+		clk.Advance(time.Second * 10)
+		require.Equal(t, len(ringParser.targets), countParsed(ringParser, clk, 20, time.Millisecond*100))
+	})
 
-		time.Sleep(time.Second * 2)
+	t.Run("test reports metrics", func(t *testing.T) {
+		t.Parallel()
 
-		ringParser.Close()
-		// Time slept is 2 seconds (2000ms).
-		// Parsing interval is 100ms, theoretically 20 updates will reach reader.
-		// Actually, we test urlCache here. Each url set to cache inside ringParser
-		// would be parsed not more often than 10s (cache TTL).
-		// So, expect only 5 updates. (len of ringParser.targets)
-		require.Equal(t, int32(len(ringParser.targets)) /* expected count updates */, atomic.LoadInt32(&countUpdates))
+		metrics := new(recordingMetrics)
+		ringParser := NewRingParser(&RingParserOptions{
+			Parser:         new(NoOpParser),
+			UrlCache:       new(NoOpUrlCacher),
+			ParsingTimeout: 10,
+			Timer:          timer.NewAppTimer(),
+			OutChanBuff:    10,
+			Metrics:        metrics,
+		})
+
+		ringParser.AddTarget("https://www.avito.ru/1")
+		ringParser.AddTarget("https://www.avito.ru/2")
+		ringParser.AddTarget("https://www.avito.ru/3")
+		ringParser.RemoveTarget("https://www.avito.ru/3")
+		require.Equal(t, 2, metrics.ringSize)
+
+		// Two full passes, the first one starts measuring
+		for i := 0; i < 4; i++ {
+			ringParser.parse()
+		}
+
+		require.Equal(t, []string{
+			"www.avito.ru ok", "www.avito.ru ok", "www.avito.ru ok", "www.avito.ru ok",
+		}, metrics.outcomes)
+		require.Equal(t, 1, metrics.cycles)
+		require.Equal(t, 4, metrics.misses)
+		require.Equal(t, []int{1, 2, 3, 4}, metrics.outDepths)
+	})
+
+	t.Run("test classifies outcomes", func(t *testing.T) {
+		t.Parallel()
+
+		_, numErr := strconv.ParseFloat("", 64)
+
+		require.Equal(t, OutcomeOK, Outcome(nil))
+		require.Equal(t, OutcomeTimeout, Outcome(fmt.Errorf("parser: intenal: %w", context.DeadlineExceeded)))
+		require.Equal(t, OutcomeUnavailable, Outcome(fmt.Errorf("parser: title parsing error: %w", ErrURLUnavailable)))
+		require.Equal(t, OutcomeMalformed, Outcome(fmt.Errorf("parser: price parsing error: %w", numErr)))
+		require.Equal(t, OutcomeFailed, Outcome(errors.New("websocket closed")))
 	})
 
+	t.Run("test reports stalled loop", func(t *testing.T) {
+		t.Parallel()
+
+		ringParser := rpWithURLs()
+
+		// Not running yet
+		require.NoError(t, ringParser.Health(context.Background()))
+
+		ringParser.interval = int64(time.Second)
+		ringParser.lastCycle = time.Now().UnixNano()
+		require.NoError(t, ringParser.Health(context.Background()))
+
+		// Consumer of Out blocks the loop
+		ringParser.lastCycle = time.Now().Add(-time.Minute).UnixNano()
+		require.ErrorIs(t, ringParser.Health(context.Background()), ErrParserStalled)
+	})
+
+	t.Run("test tolerates slow parsing of configured timeout", func(t *testing.T) {
+		t.Parallel()
+
+		clk := clock.NewFake(time.Now())
+		ringParser := NewRingParser(&RingParserOptions{
+			Parser: new(NoOpParser),
+			// Wired like app does with default parsing.timeout
+			ParsingTimeout: 10 * time.Second,
+			// Never ticks, so the loop looks stuck in parsing
+			Timer:       timer.NewAppTimerWithClock(clock.NewFake(time.Now())),
+			OutChanBuff: 2,
+			Clock:       clk,
+		})
+
+		ringParser.Run(time.Second)
+		defer ringParser.Close()
+
+		require.NoError(t, ringParser.Health(context.Background()))
+
+		// Slow parse within timeout
+		clk.Advance(stallIntervals*time.Second + 10*time.Second)
+		require.NoError(t, ringParser.Health(context.Background()))
+
+		clk.Advance(time.Second)
+		require.ErrorIs(t, ringParser.Health(context.Background()), ErrParserStalled)
+	})
+
+}
+
+// Parses once every interval, times times. Updates are read right away
+func countParsed(rp *RingParser, clk *clock.Fake, times int, interval time.Duration) int {
+	var count int
+	for i := 0; i < times; i++ {
+		clk.Advance(interval)
+		rp.parse()
+
+		select {
+		case <-rp.Out():
+			count++
+		default:
+		}
+	}
+
+	return count
 }
 
 func rpWithURLs() *RingParser {
@@ -1,6 +1,8 @@
 package postgres
 
 import (
+	"time"
+
 	domain "parser/internal/domain/models"
 
 	"github.com/google/uuid"
@@ -22,8 +24,180 @@ func (adb *AdvertDB) ToDomain() *domain.Advert {
 type SubscriberDB struct {
 	SubscriberID uuid.UUID `db:"subscriber_id"`
 	TelegramID   int64     `db:"telegram_id"`
+	// False once user has blocked the bot
+	TelegramReachable bool     `db:"telegram_reachable"`
+	Email             *string  `db:"email"`
+	Channels          []string `db:"channels"`
+	Locale            string   `db:"locale"`
+	Timezone          string   `db:"timezone"`
+	// NULL if subscriber has no quiet hours
+	QuietFrom    *int       `db:"quiet_from"`
+	QuietTo      *int       `db:"quiet_to"`
+	DigestMode   string     `db:"digest_mode"`
+	LastDigestAt *time.Time `db:"last_digest_at"`
 }
 
 func (sdb *SubscriberDB) ToDomain() *domain.Subscriber {
-	return domain.NewSubscriber(sdb.SubscriberID.String(), sdb.TelegramID)
+	var email string
+	if sdb.Email != nil {
+		email = *sdb.Email
+	}
+
+	subscriber := domain.NewSubscriber(sdb.SubscriberID.String(), sdb.TelegramID, email, channelsToDomain(sdb.Channels), sdb.Locale)
+	subscriber.SetTelegramReachable(sdb.TelegramReachable)
+
+	var quiet *domain.QuietHours
+	if sdb.QuietFrom != nil && sdb.QuietTo != nil {
+		quiet = &domain.QuietHours{From: *sdb.QuietFrom, To: *sdb.QuietTo}
+	}
+
+	// Schedule is validated before insert.
+	// Fallback to default one if timezone database has changed
+	schedule, err := domain.NewSchedule(sdb.Timezone, quiet, domain.DigestMode(sdb.DigestMode))
+	if err != nil {
+		schedule = domain.DefaultSchedule()
+	}
+	subscriber.SetSchedule(schedule)
+
+	if sdb.LastDigestAt != nil {
+		subscriber.SetLastDigestAt(*sdb.LastDigestAt)
+	}
+
+	return subscriber
+}
+
+type SubscriptionDB struct {
+	SubscriberID uuid.UUID `db:"subscriber_id"`
+	AdvertID     uuid.UUID `db:"advert_id"`
+	// NULL if subscriber's channels are used
+	Channels  []string  `db:"channels"`
+	IsMuted   bool      `db:"is_muted"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func (sdb *SubscriptionDB) ToDomain() *domain.Subscription {
+	subscription := domain.NewSubscription(sdb.SubscriberID.String(), sdb.AdvertID.String())
+	subscription.OverrideChannels(channelsToDomain(sdb.Channels))
+	subscription.SetMuted(sdb.IsMuted)
+	subscription.SetCreatedAt(sdb.CreatedAt)
+
+	return subscription
+}
+
+// Channels are validated before insert so no need to parse them
+func channelsToDomain(channels []string) []domain.Channel {
+	if channels == nil {
+		return nil
+	}
+
+	out := make([]domain.Channel, 0, len(channels))
+	for _, channel := range channels {
+		out = append(out, domain.Channel(channel))
+	}
+
+	return out
+}
+
+type EmailConfirmationDB struct {
+	Token        string    `db:"token"`
+	SubscriberID uuid.UUID `db:"subscriber_id"`
+	Email        string    `db:"email"`
+	ExpiresAt    time.Time `db:"expires_at"`
+}
+
+func (edb *EmailConfirmationDB) ToDomain() *domain.EmailConfirmation {
+	return domain.NewEmailConfirmation(edb.Token, edb.SubscriberID.String(), edb.Email, edb.ExpiresAt)
+}
+
+type WebhookDB struct {
+	WebhookID    uuid.UUID `db:"webhook_id"`
+	SubscriberID uuid.UUID `db:"subscriber_id"`
+	URL          string    `db:"url"`
+	Secret       string    `db:"secret"`
+	IsActive     bool      `db:"is_active"`
+	Failures     int       `db:"failures"`
+}
+
+func (wdb *WebhookDB) ToDomain() *domain.Webhook {
+	return domain.NewWebhook(wdb.WebhookID.String(), wdb.SubscriberID.String(), wdb.URL, wdb.Secret, wdb.IsActive, wdb.Failures)
+}
+
+type PendingNotificationDB struct {
+	NotificationID uuid.UUID `db:"notification_id"`
+	SubscriberID   uuid.UUID `db:"subscriber_id"`
+	AdvertID       uuid.UUID `db:"advert_id"`
+	Kind           string    `db:"kind"`
+	Title          string    `db:"title"`
+	URL            string    `db:"url"`
+	OldPrice       float64   `db:"old_price"`
+	NewPrice       float64   `db:"new_price"`
+	CreatedAt      time.Time `db:"created_at"`
+}
+
+func (pdb *PendingNotificationDB) ToDomain() *domain.PendingNotification {
+	return domain.NewPendingNotification(
+		pdb.NotificationID.String(),
+		pdb.SubscriberID.String(),
+		pdb.AdvertID.String(),
+		domain.PendingKind(pdb.Kind),
+		pdb.Title,
+		pdb.URL,
+		pdb.OldPrice,
+		pdb.NewPrice,
+		pdb.CreatedAt,
+	)
+}
+
+type APIKeyDB struct {
+	APIKeyID   uuid.UUID  `db:"api_key_id"`
+	Name       string     `db:"name"`
+	KeyHash    string     `db:"key_hash"`
+	Hint       string     `db:"hint"`
+	Scopes     []string   `db:"scopes"`
+	CreatedAt  time.Time  `db:"created_at"`
+	LastUsedAt *time.Time `db:"last_used_at"`
+}
+
+func (kdb *APIKeyDB) ToDomain() *domain.APIKey {
+	// Scopes are validated before insert
+	scopes := make([]domain.Scope, 0, len(kdb.Scopes))
+	for _, scope := range kdb.Scopes {
+		scopes = append(scopes, domain.Scope(scope))
+	}
+
+	var lastUsedAt time.Time
+	if kdb.LastUsedAt != nil {
+		lastUsedAt = *kdb.LastUsedAt
+	}
+
+	return domain.NewAPIKey(kdb.APIKeyID.String(), kdb.Name, kdb.KeyHash, kdb.Hint, scopes, kdb.CreatedAt, lastUsedAt)
+}
+
+// Joined with adverts for title and url
+type PriceChangeDB struct {
+	ChangeID  int64     `db:"change_id"`
+	AdvertID  uuid.UUID `db:"advert_id"`
+	Title     string    `db:"title"`
+	URL       string    `db:"url"`
+	OldPrice  float64   `db:"old_price"`
+	NewPrice  float64   `db:"new_price"`
+	ChangedAt time.Time `db:"changed_at"`
+}
+
+func (pdb *PriceChangeDB) ToDomain() *domain.PriceChange {
+	return domain.NewPriceChange(pdb.ChangeID, pdb.AdvertID.String(), pdb.Title, pdb.URL, pdb.OldPrice, pdb.NewPrice, pdb.ChangedAt)
+}
+
+// Page is read from large object separately
+type SnapshotDB struct {
+	SnapshotID int64     `db:"snapshot_id"`
+	URL        string    `db:"url"`
+	Error      string    `db:"error"`
+	Size       int       `db:"size"`
+	PageOID    uint32    `db:"page_oid"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+func (sdb *SnapshotDB) ToDomain(page []byte) *domain.Snapshot {
+	return domain.SnapshotFromDB(sdb.SnapshotID, sdb.URL, sdb.Error, sdb.Size, page, sdb.CreatedAt)
 }
@@ -3,13 +3,19 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"parser/internal/tracing"
 
 	"github.com/georgysavva/scany/pgxscan"
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("parser/internal/postgres")
+
 type Postgres struct {
 	pool *pgxpool.Pool
 }
@@ -17,32 +23,100 @@ type Postgres struct {
 type ReleaseFunc func()
 
 func (p *Postgres) Exec(ctx context.Context, sql string, args []interface{}) (*pgconn.CommandTag, ReleaseFunc, error) {
+	ctx, span := startSpan(ctx, "postgres.Exec", sql)
+	defer span.End()
+
 	conn, err := p.pool.Acquire(ctx)
 	if err != nil {
-		return nil, nil, fmt.Errorf("postgres: connection acquire error: %w", err)
+		err = fmt.Errorf("postgres: connection acquire error: %w", err)
+		tracing.RecordError(span, err)
+		return nil, nil, err
 	}
 
 	tag, err := conn.Exec(ctx, sql, args...)
 	if err != nil {
-		return nil, nil, fmt.Errorf("postgres: query error: %w", err)
+		conn.Release()
+
+		err = fmt.Errorf("postgres: query error: %w", err)
+		tracing.RecordError(span, err)
+		return nil, nil, err
 	}
 
 	return &tag, conn.Release, nil
 }
 
+// Span of query lasts until release, so it covers reading of rows
 func (p *Postgres) Query(ctx context.Context, sql string, args []interface{}) (pgx.Rows, ReleaseFunc, error) {
+	ctx, span := startSpan(ctx, "postgres.Query", sql)
 
 	conn, err := p.pool.Acquire(ctx)
 	if err != nil {
-		return nil, nil, fmt.Errorf("postgres: connection acquire error: %w", err)
+		err = fmt.Errorf("postgres: connection acquire error: %w", err)
+		tracing.RecordError(span, err)
+		span.End()
+		return nil, nil, err
 	}
 
 	rows, err := conn.Query(ctx, sql, args...)
 	if err != nil {
-		return nil, nil, fmt.Errorf("postgres: query error: %w", err)
+		conn.Release()
+
+		err = fmt.Errorf("postgres: query error: %w", err)
+		tracing.RecordError(span, err)
+		span.End()
+		return nil, nil, err
+	}
+
+	return rows, func() {
+		conn.Release()
+		span.End()
+	}, nil
+}
+
+// InTx runs fn in transaction. Transaction is committed if fn succeeds.
+// Needed for large objects, they are only accessible within transaction
+func (p *Postgres) InTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	ctx, span := startSpan(ctx, "postgres.InTx", "BEGIN")
+	defer span.End()
+
+	conn, err := p.pool.Acquire(ctx)
+	if err != nil {
+		err = fmt.Errorf("postgres: connection acquire error: %w", err)
+		tracing.RecordError(span, err)
+		return err
+	}
+
+	defer conn.Release()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		err = fmt.Errorf("postgres: begin error: %w", err)
+		tracing.RecordError(span, err)
+		return err
 	}
 
-	return rows, conn.Release, nil
+	// No-op once committed
+	defer tx.Rollback(ctx)
+
+	if err := fn(tx); err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		err = fmt.Errorf("postgres: commit error: %w", err)
+		tracing.RecordError(span, err)
+		return err
+	}
+
+	return nil
+}
+
+func startSpan(ctx context.Context, name, sql string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL, semconv.DBStatementKey.String(sql)),
+	)
 }
 
 func (p *Postgres) ScanAll(rows pgx.Rows, dst interface{}) error {
@@ -57,6 +131,23 @@ func (p *Postgres) ConnAcquire(ctx context.Context) (*pgxpool.Conn, error) {
 	return p.pool.Acquire(ctx)
 }
 
+// Ping checks that database is reachable
+func (p *Postgres) Ping(ctx context.Context) error {
+	conn, err := p.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("postgres: connection acquire error: %w", err)
+	}
+
+	defer conn.Release()
+
+	return conn.Conn().Ping(ctx)
+}
+
+// Stat returns statistics of connection pool
+func (p *Postgres) Stat() *pgxpool.Stat {
+	return p.pool.Stat()
+}
+
 func (p *Postgres) Close() {
 	p.pool.Close()
 }
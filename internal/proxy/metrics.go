@@ -0,0 +1,24 @@
+package proxy
+
+import "time"
+
+// Outcomes of handled update
+const (
+	OutcomeOK = "ok"
+	// Parser failed, see parser.Outcome for details
+	OutcomeParseError = "parse_error"
+	// Update handler failed
+	OutcomeHandlerError = "handler_error"
+)
+
+// Metrics receives measurements of Proxy. See metrics.Prometheus
+type Metrics interface {
+	ObserveUpdate(outcome string, d time.Duration)
+	// Results left in receive queue
+	SetQueueDepth(n int)
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) ObserveUpdate(outcome string, d time.Duration) {}
+func (nopMetrics) SetQueueDepth(n int)                           {}
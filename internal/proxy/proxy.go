@@ -1,71 +1,140 @@
 package proxy
 
 import (
+	"context"
 	goerrors "errors"
 	"fmt"
 	"parser/internal/domain/services"
-	"parser/internal/errors"
+	"parser/internal/logger"
 	"parser/internal/parser"
+	"parser/internal/tracing"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("parser/internal/proxy")
+
+// Update handled longer is considered stuck
+const stallTimeout = time.Minute
+
+var (
+	ErrNotRunning = goerrors.New("proxy is not running")
+	ErrStalled    = goerrors.New("proxy is stuck handling update")
+)
+
+// FailureHandler receives failed updates, see services.FailureService
+type FailureHandler interface {
+	HandleFailure(ctx context.Context, update *parser.ParseResult, err error)
+	HandleSuccess(ctx context.Context, url string)
+}
+
+// Archiver keeps html of parsed pages, see services.SnapshotService
+type Archiver interface {
+	Capture(ctx context.Context, result *parser.ParseResult) error
+}
+
 // Proxy handles output from `rcvq` and handles it via `updateHandler`
-// On error the callback `onError` is executed
+// Failed updates are passed to `failures`, pages are archived by `snapshots`
 type Proxy struct {
 	rcvq          <-chan *parser.ParseResult
 	shutdown      chan struct{}
 	updateHandler services.UpdateHandler
-	onError       func(err error)
+	failures      FailureHandler
+	snapshots     Archiver
+
+	metrics Metrics
+	log     logger.Logger
+
+	running int32
+	// Start of handling of current update in unix nanoseconds. Zero if idle
+	busySince int64
 }
 
-func NewProxy(rcvq <-chan *parser.ParseResult, updateHandler services.UpdateHandler, onError func(err error)) *Proxy {
-	return &Proxy{rcvq: rcvq, updateHandler: updateHandler, onError: onError, shutdown: make(chan struct{})}
+// metrics and log are optional
+func NewProxy(
+	rcvq <-chan *parser.ParseResult,
+	updateHandler services.UpdateHandler,
+	failures FailureHandler,
+	snapshots Archiver,
+	metrics Metrics,
+	log logger.Logger) *Proxy {
+	if metrics == nil {
+		metrics = nopMetrics{}
+	}
+
+	if log == nil {
+		log = logger.Nop()
+	}
+
+	return &Proxy{rcvq: rcvq, updateHandler: updateHandler, failures: failures, snapshots: snapshots, metrics: metrics, log: log, shutdown: make(chan struct{})}
 }
 
 // Run starts listening to rcvq and execute updateHandler
 // To stop running caller should close rcvq channel
 func (p *Proxy) Run() {
+	atomic.StoreInt32(&p.running, 1)
+	defer atomic.StoreInt32(&p.running, 0)
+
 	for update := range p.rcvq {
-		fmt.Printf("proxy rsv: %+v\n", update)
+		p.log.Debug("update received", logger.URL(update.URL()), logger.String("title", update.Title()), logger.Float64("price", update.Price()))
+		p.metrics.SetQueueDepth(len(p.rcvq))
 
-		// Parsing result occured
-		if err := update.Err(); err != nil {
-			p.handleError(err, update)
-			continue
-		}
+		start := time.Now()
+		atomic.StoreInt64(&p.busySince, start.UnixNano())
+		outcome := p.handle(update)
+		atomic.StoreInt64(&p.busySince, 0)
 
-		p.handleUpdate(update)
+		p.metrics.ObserveUpdate(outcome, time.Since(start))
 	}
 }
 
-// Report should be called when ErrURLUnavailable occurs.
-// Mainly for debugging purposes
-func (p *Proxy) Report(text *string) {
-	// TODO: logger
-	fmt.Printf("err: %s occured with: \n\t%s\n", parser.ErrURLUnavailable.Error(), *text)
-}
+// Health reports stopped consumer and update handler that doesn't return
+func (p *Proxy) Health(ctx context.Context) error {
+	if atomic.LoadInt32(&p.running) == 0 {
+		return ErrNotRunning
+	}
 
-func (p *Proxy) handleError(err error, update *parser.ParseResult) {
-	p.onError(err)
+	busySince := atomic.LoadInt64(&p.busySince)
+	if busySince == 0 {
+		return nil
+	}
 
-	// Report web-page that caused an error
-	if goerrors.Is(err, parser.ErrURLUnavailable) {
-		p.Report(update.Raw())
+	if busy := time.Since(time.Unix(0, busySince)); busy > stallTimeout {
+		return fmt.Errorf("%w for %s", ErrStalled, busy.Round(time.Second))
 	}
+
+	return nil
 }
 
-func (p *Proxy) handleUpdate(update *parser.ParseResult) {
+// Returns outcome of update, see Metrics
+func (p *Proxy) handle(update *parser.ParseResult) string {
+	// Continues trace started by parsing
+	ctx := trace.ContextWithSpanContext(context.Background(), update.SpanContext())
+	ctx, span := tracer.Start(ctx, "Proxy.handle", trace.WithAttributes(attribute.String("url", update.URL())))
+	defer span.End()
+
+	if err := p.snapshots.Capture(ctx, update); err != nil {
+		p.log.WithContext(ctx).Warn("page snapshot failed", append([]logger.Field{logger.URL(update.URL())}, logger.ErrFields(err)...)...)
+	}
+
+	// Parsing result occured
+	if err := update.Err(); err != nil {
+		tracing.RecordError(span, err)
+		p.failures.HandleFailure(ctx, update, err)
+		return OutcomeParseError
+	}
 
-	err := p.updateHandler(update)
-	// TODO: handle errors somewhere else
-	// TODO: proxy is just proxy :D
-	var appErr *errors.ApplicationError
-	if goerrors.As(err, &appErr) {
-		// TODO: upgrade logger
-		fmt.Println("trace: ", appErr.PrintStacktrace(), "error: ", appErr.Error())
-		return
+	if err := p.updateHandler(ctx, update); err != nil {
+		tracing.RecordError(span, err)
+		p.failures.HandleFailure(ctx, update, err)
+		return OutcomeHandlerError
 	}
 
-	// updateHandler never returns ErrURLUnavailable.
-	// Can pass nil as second argument
-	p.handleError(err, nil)
+	p.failures.HandleSuccess(ctx, update.URL())
+
+	return OutcomeOK
 }
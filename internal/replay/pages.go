@@ -0,0 +1,71 @@
+package replay
+
+import (
+	"context"
+	"os"
+	"parser/internal/domain/repositories"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// LoadDir reads .html files of dir
+func LoadDir(dir string) ([]*Page, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.html"))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(paths)
+
+	pages := make([]*Page, 0, len(paths))
+	for _, path := range paths {
+		html, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		pages = append(pages, &Page{Name: filepath.Base(path), HTML: string(html)})
+	}
+
+	return pages, nil
+}
+
+// LoadSnapshots reads every snapshot of repo, see services.SnapshotService.
+// Only pages parser failed on are read if failedOnly is set
+func LoadSnapshots(ctx context.Context, repo repositories.SnapshotRepository, failedOnly bool) ([]*Page, error) {
+	snapshots, err := repo.List(ctx, "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	pages := make([]*Page, 0, len(snapshots))
+	for _, s := range snapshots {
+		if failedOnly && !s.Failed() {
+			continue
+		}
+
+		snapshot, err := repo.Get(ctx, s.SnapshotID)
+		if err != nil {
+			return nil, err
+		}
+
+		// Deleted meanwhile
+		if snapshot == nil {
+			continue
+		}
+
+		html, err := snapshot.HTML()
+		if err != nil {
+			return nil, err
+		}
+
+		pages = append(pages, &Page{
+			Name: strconv.FormatInt(snapshot.SnapshotID, 10),
+			URL:  snapshot.URL(),
+			HTML: html,
+		})
+	}
+
+	return pages, nil
+}
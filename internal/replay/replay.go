@@ -0,0 +1,200 @@
+// Package replay runs extractors over archived pages offline.
+// Candidate extractor is compared with baseline one, so fixed matchers
+// could be checked against every page collected before shipping
+package replay
+
+import (
+	"fmt"
+	"io"
+	"parser/internal/parser"
+	"sort"
+	"strconv"
+)
+
+var fields = []string{parser.FieldTitle, parser.FieldPrice}
+
+// Page is html to extract advert from
+type Page struct {
+	// File name or snapshot ID
+	Name string
+	// Empty for plain html files
+	URL  string
+	HTML string
+}
+
+// FieldStats counts pages field was extracted from
+type FieldStats struct {
+	OK     int
+	Failed int
+}
+
+// Diff is field extracted differently by baseline and candidate
+type Diff struct {
+	Page  string
+	Field string
+	// Extracted value or error
+	Baseline  string
+	Candidate string
+	// Baseline extracted field, candidate failed to
+	Regression bool
+}
+
+type Report struct {
+	Candidate string
+	// Empty if there was nothing to compare with
+	Baseline string
+	Pages    int
+
+	// Per field success of candidate
+	Fields map[string]*FieldStats
+	// Per field success of baseline, nil without baseline
+	BaselineFields map[string]*FieldStats
+
+	Diffs []*Diff
+}
+
+// Run extracts advert from every page with candidate. Baseline is optional
+func Run(pages []*Page, candidate, baseline *parser.Extractor) *Report {
+	report := &Report{
+		Candidate: candidate.Version,
+		Pages:     len(pages),
+		Fields:    newStats(),
+	}
+
+	if baseline != nil {
+		report.Baseline = baseline.Version
+		report.BaselineFields = newStats()
+	}
+
+	for _, page := range pages {
+		got := extract(candidate, page)
+		count(report.Fields, got)
+
+		if baseline == nil {
+			continue
+		}
+
+		want := extract(baseline, page)
+		count(report.BaselineFields, want)
+
+		for _, field := range fields {
+			if want[field].String() == got[field].String() {
+				continue
+			}
+
+			report.Diffs = append(report.Diffs, &Diff{
+				Page:       page.Name,
+				Field:      field,
+				Baseline:   want[field].String(),
+				Candidate:  got[field].String(),
+				Regression: want[field].err == nil && got[field].err != nil,
+			})
+		}
+	}
+
+	sort.SliceStable(report.Diffs, func(i, j int) bool {
+		return report.Diffs[i].Page < report.Diffs[j].Page
+	})
+
+	return report
+}
+
+// Regressions are fields baseline extracted and candidate didn't
+func (r *Report) Regressions() []*Diff {
+	var regressions []*Diff
+	for _, diff := range r.Diffs {
+		if diff.Regression {
+			regressions = append(regressions, diff)
+		}
+	}
+
+	return regressions
+}
+
+// Write prints report for humans
+func (r *Report) Write(w io.Writer) {
+	fmt.Fprintf(w, "pages: %d\n", r.Pages)
+
+	for _, field := range fields {
+		fmt.Fprintf(w, "%s %s: %s", r.Candidate, field, r.Fields[field])
+		if r.BaselineFields != nil {
+			fmt.Fprintf(w, " (%s: %s)", r.Baseline, r.BaselineFields[field])
+		}
+
+		fmt.Fprintln(w)
+	}
+
+	if r.Baseline == "" {
+		return
+	}
+
+	fmt.Fprintf(w, "diffs: %d, regressions: %d\n", len(r.Diffs), len(r.Regressions()))
+
+	for _, diff := range r.Diffs {
+		mark := " "
+		if diff.Regression {
+			mark = "!"
+		}
+
+		fmt.Fprintf(w, "%s %s %s: %q -> %q\n", mark, diff.Page, diff.Field, diff.Baseline, diff.Candidate)
+	}
+}
+
+func (s *FieldStats) String() string {
+	total := s.OK + s.Failed
+	if total == 0 {
+		return "0/0"
+	}
+
+	return fmt.Sprintf("%d/%d (%.1f%%)", s.OK, total, float64(s.OK)*100/float64(total))
+}
+
+// Extracted value of field or error
+type outcome struct {
+	value string
+	err   error
+}
+
+func (o outcome) String() string {
+	if o.err != nil {
+		return "error: " + o.err.Error()
+	}
+
+	return o.value
+}
+
+// Fields are extracted separately, failed title doesn't hide state of price
+func extract(e *parser.Extractor, page *Page) map[string]outcome {
+	title, err := e.Title(&page.HTML)
+	titleOutcome := outcome{value: title, err: err}
+
+	price, err := e.Price(&page.HTML)
+	priceOutcome := outcome{value: strconv.FormatFloat(price, 'f', -1, 64), err: err}
+	if err != nil {
+		priceOutcome.value = ""
+	}
+
+	return map[string]outcome{
+		parser.FieldTitle: titleOutcome,
+		parser.FieldPrice: priceOutcome,
+	}
+}
+
+func count(stats map[string]*FieldStats, outcomes map[string]outcome) {
+	for field, o := range outcomes {
+		if o.err != nil {
+			stats[field].Failed++
+		} else {
+			stats[field].OK++
+		}
+	}
+}
+
+func newStats() map[string]*FieldStats {
+	stats := make(map[string]*FieldStats, len(fields))
+	for _, field := range fields {
+		stats[field] = new(FieldStats)
+	}
+
+	return stats
+}
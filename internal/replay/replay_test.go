@@ -0,0 +1,122 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	domain "parser/internal/domain/models"
+	"parser/internal/domain/repositories"
+	"parser/internal/parser"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	advertPage = `<span class="title-info-title-text" itemprop="name">Bicycle</span>` +
+		`<span class="js-item-price" content="1500">1 500</span>`
+	// Price has been moved to another class
+	redesignedPage = `<span class="title-info-title-text" itemprop="name">Lamp</span>` +
+		`<span class="style-price-value">2 000</span>`
+	bannedPage = `<html>Access denied</html>`
+)
+
+func writePages(t *testing.T, pages map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, html := range pages {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(html), 0o644))
+	}
+
+	return dir
+}
+
+func TestReplay(t *testing.T) {
+	dir := writePages(t, map[string]string{
+		"1-advert.html":     advertPage,
+		"2-redesigned.html": redesignedPage,
+		"3-banned.html":     bannedPage,
+		"notes.txt":         "not a page",
+	})
+
+	pages, err := LoadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, pages, 3)
+	require.Equal(t, "1-advert.html", pages[0].Name)
+
+	v1, err := parser.ExtractorVersion("v1")
+	require.NoError(t, err)
+
+	t.Run("test reports per field success without baseline", func(t *testing.T) {
+		report := Run(pages, v1, nil)
+
+		require.Equal(t, 3, report.Pages)
+		require.Equal(t, FieldStats{OK: 2, Failed: 1}, *report.Fields[parser.FieldTitle])
+		require.Equal(t, FieldStats{OK: 1, Failed: 2}, *report.Fields[parser.FieldPrice])
+		require.Nil(t, report.BaselineFields)
+		require.Empty(t, report.Diffs)
+	})
+
+	t.Run("test diffs fixed candidate against baseline", func(t *testing.T) {
+		candidate, err := parser.NewExtractor("v2", `"title-info-title-text.*?<`, `(?:js-item-price|style-price-value).*?<`)
+		require.NoError(t, err)
+
+		report := Run(pages, candidate, v1)
+
+		require.Equal(t, "v1", report.Baseline)
+		require.Equal(t, FieldStats{OK: 2, Failed: 1}, *report.Fields[parser.FieldPrice])
+		require.Equal(t, FieldStats{OK: 1, Failed: 2}, *report.BaselineFields[parser.FieldPrice])
+
+		require.Len(t, report.Diffs, 1)
+		require.Equal(t, "2-redesigned.html", report.Diffs[0].Page)
+		require.Equal(t, parser.FieldPrice, report.Diffs[0].Field)
+		require.Equal(t, "2000", report.Diffs[0].Candidate)
+		require.False(t, report.Diffs[0].Regression)
+		require.Empty(t, report.Regressions())
+	})
+
+	t.Run("test tells regressions", func(t *testing.T) {
+		candidate, err := parser.NewExtractor("v2", `"title-info-title-text.*?<`, `style-price-value.*?<`)
+		require.NoError(t, err)
+
+		report := Run(pages, candidate, v1)
+
+		regressions := report.Regressions()
+		require.Len(t, regressions, 1)
+		require.Equal(t, "1-advert.html", regressions[0].Page)
+		require.Equal(t, "1500", regressions[0].Baseline)
+
+		var out bytes.Buffer
+		report.Write(&out)
+		require.Contains(t, out.String(), "diffs: 2, regressions: 1")
+		require.Contains(t, out.String(), `! 1-advert.html price: "1500"`)
+	})
+}
+
+func TestLoadSnapshots(t *testing.T) {
+	ctx := context.Background()
+	repo := repositories.NewFSSnapshotRepo(t.TempDir())
+
+	ok, err := domain.NewSnapshot("https://example.com/1", advertPage, nil, time.Now())
+	require.NoError(t, err)
+	require.NoError(t, repo.Insert(ctx, ok))
+
+	failed, err := domain.NewSnapshot("https://example.com/2", bannedPage, errors.New("parser: title parsing error"), time.Now())
+	require.NoError(t, err)
+	require.NoError(t, repo.Insert(ctx, failed))
+
+	pages, err := LoadSnapshots(ctx, repo, false)
+	require.NoError(t, err)
+	require.Len(t, pages, 2)
+
+	pages, err = LoadSnapshots(ctx, repo, true)
+	require.NoError(t, err)
+	require.Len(t, pages, 1)
+	require.Equal(t, "https://example.com/2", pages[0].URL)
+	require.Equal(t, bannedPage, pages[0].HTML)
+}
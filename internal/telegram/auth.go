@@ -0,0 +1,122 @@
+package telegram
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMalformedAuthData = errors.New("telegram auth data is malformed")
+	ErrInvalidSignature  = errors.New("telegram auth data signature is invalid")
+	ErrAuthDataExpired   = errors.New("telegram auth data is expired")
+)
+
+// Key of HMAC that derives Web App secret from bot token
+const webAppSecretKey = "WebAppData"
+
+// User is telegram user whose identity is proved by data signed by Telegram
+type User struct {
+	ID           int64  `json:"id"`
+	Username     string `json:"username"`
+	FirstName    string `json:"first_name"`
+	LanguageCode string `json:"language_code"`
+	AuthDate     time.Time
+}
+
+// VerifyLogin checks data of Telegram Login Widget.
+// See https://core.telegram.org/widgets/login#checking-authorization
+func VerifyLogin(botToken string, data url.Values, maxAge time.Duration, now time.Time) (*User, error) {
+	secret := sha256.Sum256([]byte(botToken))
+
+	authDate, err := verifyAuthData(secret[:], data, maxAge, now)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := strconv.ParseInt(data.Get("id"), 10, 64)
+	if err != nil {
+		return nil, ErrMalformedAuthData
+	}
+
+	return &User{
+		ID:        id,
+		Username:  data.Get("username"),
+		FirstName: data.Get("first_name"),
+		AuthDate:  authDate,
+	}, nil
+}
+
+// VerifyWebAppData checks initData of Telegram Web App.
+// See https://core.telegram.org/bots/webapps#validating-data-received-via-the-mini-app
+func VerifyWebAppData(botToken, initData string, maxAge time.Duration, now time.Time) (*User, error) {
+	data, err := url.ParseQuery(initData)
+	if err != nil {
+		return nil, ErrMalformedAuthData
+	}
+
+	mac := hmac.New(sha256.New, []byte(webAppSecretKey))
+	mac.Write([]byte(botToken))
+
+	authDate, err := verifyAuthData(mac.Sum(nil), data, maxAge, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var user User
+	if err := json.Unmarshal([]byte(data.Get("user")), &user); err != nil || user.ID == 0 {
+		return nil, ErrMalformedAuthData
+	}
+
+	user.AuthDate = authDate
+
+	return &user, nil
+}
+
+// Checks hash of data and its freshness. Returns auth_date
+func verifyAuthData(secret []byte, data url.Values, maxAge time.Duration, now time.Time) (time.Time, error) {
+	hash, err := hex.DecodeString(data.Get("hash"))
+	if err != nil || len(hash) == 0 {
+		return time.Time{}, ErrMalformedAuthData
+	}
+
+	// Fields except hash sorted by key as key=value lines
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		if key != "hash" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		lines = append(lines, key+"="+data.Get(key))
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strings.Join(lines, "\n")))
+
+	if !hmac.Equal(mac.Sum(nil), hash) {
+		return time.Time{}, ErrInvalidSignature
+	}
+
+	unix, err := strconv.ParseInt(data.Get("auth_date"), 10, 64)
+	if err != nil {
+		return time.Time{}, ErrMalformedAuthData
+	}
+
+	authDate := time.Unix(unix, 0)
+	if maxAge > 0 && now.Sub(authDate) > maxAge {
+		return time.Time{}, ErrAuthDataExpired
+	}
+
+	return authDate, nil
+}
@@ -0,0 +1,93 @@
+package telegram
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testBotToken = "123456:test-token"
+
+// Signs data the way Telegram does
+func signAuthData(t *testing.T, secret []byte, checkString string) string {
+	t.Helper()
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(checkString))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyLogin(t *testing.T) {
+	now := time.Date(2023, 1, 10, 12, 0, 0, 0, time.UTC)
+	authDate := strconv.FormatInt(now.Add(-time.Minute).Unix(), 10)
+
+	secret := sha256.Sum256([]byte(testBotToken))
+	hash := signAuthData(t, secret[:], "auth_date="+authDate+"\nfirst_name=Ivan\nid=42\nusername=ivan")
+
+	newData := func() url.Values {
+		return url.Values{
+			"id":         {"42"},
+			"first_name": {"Ivan"},
+			"username":   {"ivan"},
+			"auth_date":  {authDate},
+			"hash":       {hash},
+		}
+	}
+
+	user, err := VerifyLogin(testBotToken, newData(), time.Hour, now)
+	require.NoError(t, err)
+	require.Equal(t, int64(42), user.ID)
+	require.Equal(t, "ivan", user.Username)
+
+	tampered := newData()
+	tampered.Set("id", "43")
+	_, err = VerifyLogin(testBotToken, tampered, time.Hour, now)
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	_, err = VerifyLogin("654321:other-token", newData(), time.Hour, now)
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	_, err = VerifyLogin(testBotToken, newData(), time.Hour, now.Add(2*time.Hour))
+	require.ErrorIs(t, err, ErrAuthDataExpired)
+
+	noHash := newData()
+	noHash.Del("hash")
+	_, err = VerifyLogin(testBotToken, noHash, time.Hour, now)
+	require.ErrorIs(t, err, ErrMalformedAuthData)
+}
+
+func TestVerifyWebAppData(t *testing.T) {
+	now := time.Date(2023, 1, 10, 12, 0, 0, 0, time.UTC)
+	authDate := strconv.FormatInt(now.Add(-time.Minute).Unix(), 10)
+	userJSON := `{"id":42,"first_name":"Ivan","language_code":"en"}`
+
+	mac := hmac.New(sha256.New, []byte("WebAppData"))
+	mac.Write([]byte(testBotToken))
+	hash := signAuthData(t, mac.Sum(nil), "auth_date="+authDate+"\nquery_id=AAH\nuser="+userJSON)
+
+	initData := url.Values{
+		"query_id":  {"AAH"},
+		"user":      {userJSON},
+		"auth_date": {authDate},
+		"hash":      {hash},
+	}.Encode()
+
+	user, err := VerifyWebAppData(testBotToken, initData, time.Hour, now)
+	require.NoError(t, err)
+	require.Equal(t, int64(42), user.ID)
+	require.Equal(t, "en", user.LanguageCode)
+
+	// Signed for another bot
+	_, err = VerifyWebAppData("654321:other-token", initData, time.Hour, now)
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	_, err = VerifyWebAppData(testBotToken, "%zz", time.Hour, now)
+	require.ErrorIs(t, err, ErrMalformedAuthData)
+}
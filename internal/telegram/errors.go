@@ -0,0 +1,52 @@
+package telegram
+
+import (
+	"errors"
+	"strings"
+
+	tg "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+var (
+	// User has blocked the bot
+	ErrBotBlocked = errors.New("bot was blocked by the user")
+	// Chat is deleted or bot never had a conversation with it
+	ErrChatNotFound = errors.New("chat not found")
+	// User's telegram account is deleted
+	ErrUserDeactivated = errors.New("user is deactivated")
+)
+
+// Telegram API puts the reason of failure into description.
+// e.g. {"ok":false,"error_code":403,"description":"Forbidden: bot was blocked by the user"}
+var descriptions = map[string]error{
+	"bot was blocked by the user": ErrBotBlocked,
+	"user is deactivated":         ErrUserDeactivated,
+	"chat not found":              ErrChatNotFound,
+	"bot was kicked":              ErrBotBlocked,
+}
+
+// classifyError maps raw telegram API error to one of package's errors.
+// If err is unknown it's returned as is
+func classifyError(err error) error {
+	var apiErr *tg.Error
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+
+	msg := strings.ToLower(apiErr.Message)
+	for description, classified := range descriptions {
+		if strings.Contains(msg, description) {
+			return classified
+		}
+	}
+
+	return err
+}
+
+// IsUnreachable reports whether err means that messages
+// could not be delivered to the chat until user does something (e.g. /start)
+func IsUnreachable(err error) bool {
+	return errors.Is(err, ErrBotBlocked) ||
+		errors.Is(err, ErrChatNotFound) ||
+		errors.Is(err, ErrUserDeactivated)
+}
@@ -0,0 +1,65 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	tg "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		expected    error
+		unreachable bool
+	}{
+		{
+			name:        "blocked",
+			err:         &tg.Error{Code: 403, Message: "Forbidden: bot was blocked by the user"},
+			expected:    ErrBotBlocked,
+			unreachable: true,
+		},
+		{
+			name:        "deactivated",
+			err:         &tg.Error{Code: 403, Message: "Forbidden: user is deactivated"},
+			expected:    ErrUserDeactivated,
+			unreachable: true,
+		},
+		{
+			name:        "chat not found",
+			err:         &tg.Error{Code: 400, Message: "Bad Request: chat not found"},
+			expected:    ErrChatNotFound,
+			unreachable: true,
+		},
+		{
+			name:        "wrapped",
+			err:         fmt.Errorf("send: %w", &tg.Error{Code: 403, Message: "Forbidden: bot was blocked by the user"}),
+			expected:    ErrBotBlocked,
+			unreachable: true,
+		},
+		{
+			name:        "too many requests",
+			err:         &tg.Error{Code: 429, Message: "Too Many Requests: retry after 5"},
+			unreachable: false,
+		},
+		{
+			name:        "network",
+			err:         errors.New("connection reset by peer"),
+			unreachable: false,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			classified := classifyError(tc.err)
+			if tc.expected != nil {
+				require.ErrorIs(t, classified, tc.expected)
+			}
+			require.Equal(t, tc.unreachable, IsUnreachable(classified))
+		})
+	}
+}
@@ -1,41 +1,109 @@
 package telegram
 
 import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"parser/internal/logger"
+	"strings"
+	"sync"
+	"time"
 
 	tg "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
 var (
-	ErrNoToken = errors.New("token must not be empty")
+	ErrNoToken      = errors.New("token must not be empty")
+	ErrNotConnected = errors.New("bot is not connected")
 )
 
 const (
 	pollTimeout int = 60
+
+	// Maximum amount of time for a single command handler
+	commandTimeout = time.Second * 5
+
+	// Telegram puts secret_token passed to setWebhook in this header
+	secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
 )
 
+// Sender is a user that has sent a command
+type Sender struct {
+	TelegramID int64
+	// IETF language tag of user's telegram client, e.g. "ru". Could be empty
+	LanguageCode string
+}
+
+// CommandHandler is executed when user sends a command (e.g. /start) to the bot
+type CommandHandler func(ctx context.Context, sender *Sender) error
+
 type Telegram interface {
 	// TODO: ctx
 	SendMessage(chatIdentifier int64, msg string) error
 
+	// Registers handler for command (without leading slash).
+	// Should be called before Connect
+	HandleCommand(command string, h CommandHandler)
+
 	// Starts the bot to poll telegram api and receive updates
 	Connect(token string) error
+
+	// Registers webhook at telegram api. Updates are received
+	// by handler returned from WebhookHandler, so it doesn't block
+	ConnectWebhook(token string, opts *WebhookOptions) error
+
+	// Returns http handler that receives updates in webhook mode.
+	// Requests without valid secretToken are rejected
+	WebhookHandler(secretToken string) http.HandlerFunc
+
+	// Checks that bot is connected and telegram api is reachable
+	Health(ctx context.Context) error
+
 	Close()
 }
 
+type WebhookOptions struct {
+	// Public URL telegram sends updates to
+	URL string
+	// Telegram sends it back in every update request
+	SecretToken string
+}
+
 type telegram struct {
 	client *tg.BotAPI
 	debug  bool
+	log    logger.Logger
+
+	// Protects client and commands
+	mu       *sync.RWMutex
+	commands map[string]CommandHandler
 }
 
-func NewTelegram(debug bool) Telegram {
+// In debug mode requests to bot api are logged as well
+func NewTelegram(debug bool, log logger.Logger) Telegram {
+	if debug {
+		// Bot api client logs through package level logger
+		tg.SetLogger(&botLogger{log: log})
+	}
+
 	return &telegram{
-		client: nil,
-		debug:  debug,
+		client:   nil,
+		debug:    debug,
+		log:      log,
+		mu:       new(sync.RWMutex),
+		commands: make(map[string]CommandHandler),
 	}
 }
 
+func (t *telegram) HandleCommand(command string, h CommandHandler) {
+	t.mu.Lock()
+	t.commands[command] = h
+	t.mu.Unlock()
+}
+
 func (t *telegram) Connect(token string) error {
 	if token == "" {
 		return ErrNoToken
@@ -47,35 +115,163 @@ func (t *telegram) Connect(token string) error {
 	}
 
 	bot.Debug = t.debug
-	t.client = bot
+	t.setClient(bot)
+
+	// Telegram refuses to give updates via polling while webhook is set
+	_, err = bot.Request(tg.DeleteWebhookConfig{})
+	if err != nil {
+		return fmt.Errorf("unable to delete webhook: %w", err)
+	}
+
+	t.log.Info("polling updates", logger.String("bot", bot.Self.UserName))
 
 	updates := bot.GetUpdatesChan(tg.UpdateConfig{
 		Timeout: pollTimeout,
 	})
 
 	for update := range updates {
-		fmt.Printf("ID: %d\n", update.SentFrom().ID)
+		t.handleUpdate(update)
+	}
+
+	return nil
+}
+
+func (t *telegram) ConnectWebhook(token string, opts *WebhookOptions) error {
+	if token == "" {
+		return ErrNoToken
+	}
+
+	bot, err := tg.NewBotAPI(token)
+	if err != nil {
+		return fmt.Errorf("unable to connect: %w", err)
+	}
+
+	bot.Debug = t.debug
+	t.setClient(bot)
+
+	// tg.WebhookConfig has no secret_token yet so make raw request
+	params := make(tg.Params)
+	params["url"] = opts.URL
+	params.AddNonEmpty("secret_token", opts.SecretToken)
+
+	_, err = bot.MakeRequest("setWebhook", params)
+	if err != nil {
+		return fmt.Errorf("unable to set webhook: %w", err)
 	}
 
+	t.log.Info("webhook set", logger.String("bot", bot.Self.UserName))
+
 	return nil
 }
 
+func (t *telegram) WebhookHandler(secretToken string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		candidate := r.Header.Get(secretTokenHeader)
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(secretToken)) != 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var update tg.Update
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		t.handleUpdate(update)
+
+		// Telegram retries delivery on anything but 2xx
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func (t *telegram) Health(ctx context.Context) error {
+	t.mu.RLock()
+	client := t.client
+	t.mu.RUnlock()
+
+	if client == nil {
+		return ErrNotConnected
+	}
+
+	// Bot api client doesn't accept context
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.GetMe()
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("getMe: %w", classifyError(err))
+		}
+
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (t *telegram) Close() {
-	t.client.StopReceivingUpdates()
+	t.mu.RLock()
+	client := t.client
+	t.mu.RUnlock()
+
+	// Has never connected
+	if client == nil {
+		return
+	}
+
+	client.StopReceivingUpdates()
+}
+
+func (t *telegram) setClient(client *tg.BotAPI) {
+	t.mu.Lock()
+	t.client = client
+	t.mu.Unlock()
 }
 
 // TODO: ctx
-// TODO: logger
 func (t *telegram) SendMessage(chatIdentifier int64, msg string) error {
 	m := t.newEmptyMessage(chatIdentifier, msg)
 	err := t.send(m)
 	if err != nil {
-		return fmt.Errorf("unable to send message: %w", err)
+		return fmt.Errorf("unable to send message: %w", classifyError(err))
 	}
 
 	return nil
 }
 
+// handleUpdate is shared by polling and webhook modes
+func (t *telegram) handleUpdate(update tg.Update) {
+	if update.Message == nil || !update.Message.IsCommand() {
+		return
+	}
+
+	t.mu.RLock()
+	h, ok := t.commands[update.Message.Command()]
+	t.mu.RUnlock()
+
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	from := update.SentFrom()
+	sender := &Sender{
+		TelegramID:   from.ID,
+		LanguageCode: from.LanguageCode,
+	}
+
+	if err := h(ctx, sender); err != nil {
+		t.log.Error("command failed",
+			logger.String("command", update.Message.Command()), logger.TelegramID(sender.TelegramID), logger.Err(err))
+	}
+}
+
 func (t *telegram) send(ch tg.Chattable) error {
 	_, err := t.client.Send(ch)
 	return err
@@ -84,3 +280,16 @@ func (t *telegram) send(ch tg.Chattable) error {
 func (t *telegram) newEmptyMessage(chatIdentifier int64, text string) tg.MessageConfig {
 	return tg.NewMessage(chatIdentifier, text)
 }
+
+// Adapts logger to tg.BotLogger. Bot api client only logs in debug mode
+type botLogger struct {
+	log logger.Logger
+}
+
+func (b *botLogger) Println(v ...interface{}) {
+	b.log.Debug(strings.TrimSuffix(fmt.Sprintln(v...), "\n"))
+}
+
+func (b *botLogger) Printf(format string, v ...interface{}) {
+	b.log.Debug(strings.TrimSuffix(fmt.Sprintf(format, v...), "\n"))
+}
@@ -0,0 +1,82 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"parser/internal/logger"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testSecretToken = "secret-token"
+
+	// From testdata/start_update.json
+	startUpdateSenderID int64 = 398710245
+)
+
+func TestWebhookHandler(t *testing.T) {
+	body, err := os.ReadFile("testdata/start_update.json")
+	require.NoError(t, err)
+
+	t.Run("dispatches command to handler", func(t *testing.T) {
+		tg := NewTelegram(false, logger.Nop())
+
+		var calledWith *Sender
+		tg.HandleCommand("start", func(ctx context.Context, sender *Sender) error {
+			calledWith = sender
+			return nil
+		})
+
+		srv := httptest.NewServer(tg.WebhookHandler(testSecretToken))
+		defer srv.Close()
+
+		res := postUpdate(t, srv.URL, testSecretToken, body)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		require.Equal(t, &Sender{TelegramID: startUpdateSenderID, LanguageCode: "ru"}, calledWith)
+	})
+
+	t.Run("rejects invalid secret token", func(t *testing.T) {
+		tg := NewTelegram(false, logger.Nop())
+
+		var called bool
+		tg.HandleCommand("start", func(ctx context.Context, sender *Sender) error {
+			called = true
+			return nil
+		})
+
+		srv := httptest.NewServer(tg.WebhookHandler(testSecretToken))
+		defer srv.Close()
+
+		res := postUpdate(t, srv.URL, "not-a-secret", body)
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		require.False(t, called)
+	})
+
+	t.Run("rejects malformed update", func(t *testing.T) {
+		srv := httptest.NewServer(NewTelegram(false, logger.Nop()).WebhookHandler(testSecretToken))
+		defer srv.Close()
+
+		res := postUpdate(t, srv.URL, testSecretToken, []byte("{not json"))
+		require.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+}
+
+func postUpdate(t *testing.T, url, secretToken string, body []byte) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, url, bytes.NewReader(body))
+	require.NoError(t, err)
+
+	req.Header.Set(secretTokenHeader, secretToken)
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { res.Body.Close() })
+
+	return res
+}
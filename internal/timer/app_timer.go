@@ -1,29 +1,55 @@
 package timer
 
 import (
+	"sync"
 	"time"
+
+	"parser/internal/clock"
 )
 
 type AppTimer struct {
+	clock clock.Clock
+
+	// Protects tickers and shutdown
+	mu       sync.Mutex
+	tickers  []clock.Ticker
 	shutdown chan struct{}
+	stopped  bool
 }
 
 func NewAppTimer() Timer {
+	return NewAppTimerWithClock(clock.Real())
+}
+
+// NewAppTimerWithClock ticks by clock, e.g. by clock.Fake in tests
+func NewAppTimerWithClock(c clock.Clock) Timer {
 	return &AppTimer{
+		clock:    c,
 		shutdown: make(chan struct{}),
 	}
 }
 
 func (at *AppTimer) Every(interval time.Duration, f func()) {
+	at.mu.Lock()
+	defer at.mu.Unlock()
+
+	if at.stopped {
+		return
+	}
+
+	// Created before goroutine starts, so fake clock
+	// advanced right after Every fires it
+	emitter := at.clock.NewTicker(interval)
+	at.tickers = append(at.tickers, emitter)
+
 	go func() {
-		emitter := time.NewTicker(interval)
 		for {
 			select {
 			// Exit emitting goroutine once reached shutdown state
 			case <-at.shutdown:
 				return
 			// Emit an action (call func)
-			case _, ok := <-emitter.C:
+			case _, ok := <-emitter.C():
 				if !ok {
 					return
 				}
@@ -34,6 +60,19 @@ func (at *AppTimer) Every(interval time.Duration, f func()) {
 	}()
 }
 
+// Stop prevents further calls. Call in progress isn't waited for
 func (at *AppTimer) Stop() {
+	at.mu.Lock()
+	defer at.mu.Unlock()
+
+	if at.stopped {
+		return
+	}
+
+	at.stopped = true
+	for _, ticker := range at.tickers {
+		ticker.Stop()
+	}
+
 	close(at.shutdown)
 }
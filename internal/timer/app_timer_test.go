@@ -0,0 +1,46 @@
+package timer
+
+import (
+	"testing"
+	"time"
+
+	"parser/internal/clock"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppTimer(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	timer := NewAppTimerWithClock(clk)
+
+	calls := make(chan struct{})
+	timer.Every(time.Minute, func() { calls <- struct{}{} })
+
+	clk.Advance(time.Minute)
+	<-calls
+
+	clk.Advance(time.Minute)
+	<-calls
+
+	timer.Stop()
+	// Second stop is no-op
+	timer.Stop()
+
+	clk.Advance(time.Hour)
+	select {
+	case <-calls:
+		t.Fatal("called after stop")
+	default:
+	}
+
+	// Stopped timer doesn't start new schedules
+	timer.Every(time.Minute, func() { calls <- struct{}{} })
+	clk.Advance(time.Minute)
+	select {
+	case <-calls:
+		t.Fatal("called after stop")
+	default:
+	}
+
+	require.Empty(t, calls)
+}
@@ -0,0 +1,131 @@
+// Package tracing sets up OpenTelemetry tracing.
+// Components create spans with tracers of global provider (see otel.Tracer),
+// so they need no setup of their own and trace nothing until Setup is called
+package tracing
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"os"
+	"parser/internal/errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const serviceName = "avito-tracker"
+
+const (
+	// Spans are sent to OpenTelemetry collector over OTLP/HTTP
+	ExporterOTLP = "otlp"
+	// Spans are printed to stdout
+	ExporterStdout = "stdout"
+)
+
+var ErrUnknownExporter = goerrors.New("unknown tracing exporter")
+
+type Options struct {
+	// ExporterOTLP or ExporterStdout. Tracing is disabled if empty
+	Exporter string
+	// Address of collector, e.g. localhost:4318.
+	// OTEL_EXPORTER_OTLP_ENDPOINT is used if empty
+	Endpoint string
+	// Send spans over plain http
+	Insecure bool
+	// Fraction of traces recorded
+	SampleRatio float64
+}
+
+// Setup installs global tracer provider and W3C trace context propagator.
+// Returned func flushes spans left, it should be called before exit
+func Setup(ctx context.Context, opts *Options) (func(ctx context.Context) error, error) {
+	if opts.Exporter == "" {
+		return func(ctx context.Context) error { return nil }, nil
+	}
+
+	var (
+		exporter sdktrace.SpanExporter
+		err      error
+	)
+
+	switch opts.Exporter {
+	case ExporterOTLP:
+		var options []otlptracehttp.Option
+		if opts.Endpoint != "" {
+			options = append(options, otlptracehttp.WithEndpoint(opts.Endpoint))
+		}
+
+		if opts.Insecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+
+		exporter, err = otlptracehttp.New(ctx, options...)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	default:
+		return nil, ErrUnknownExporter
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceNameKey.String(serviceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		// Parent decides for spans of incoming requests, so traces are not cut in the middle
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return provider.Shutdown, nil
+}
+
+// RecordError marks span as failed. Kind, code, operations, stack and fields
+// of errors.ApplicationError are attached as attributes
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+
+	var attrs []attribute.KeyValue
+
+	var appErr *errors.ApplicationError
+	if goerrors.As(err, &appErr) {
+		attrs = append(attrs,
+			attribute.String("error.kind", appErr.Kind().K),
+			attribute.String("error.code", appErr.Code()),
+			attribute.String("error.trace", appErr.PrintStacktrace()),
+			attribute.String("error.stack", appErr.StackTrace()),
+		)
+
+		for _, f := range appErr.Fields() {
+			attrs = append(attrs, attribute.String("error."+f.Key, fmt.Sprint(f.Value)))
+		}
+	}
+
+	span.RecordError(err, trace.WithAttributes(attrs...))
+	span.SetStatus(codes.Error, err.Error())
+}
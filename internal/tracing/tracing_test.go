@@ -0,0 +1,57 @@
+package tracing
+
+import (
+	"context"
+	goerrors "errors"
+	"parser/internal/errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSetup(t *testing.T) {
+	t.Run("disabled without exporter", func(t *testing.T) {
+		shutdown, err := Setup(context.Background(), &Options{})
+		require.NoError(t, err)
+		require.NoError(t, shutdown(context.Background()))
+	})
+
+	t.Run("rejects unknown exporter", func(t *testing.T) {
+		_, err := Setup(context.Background(), &Options{Exporter: "jaeger"})
+		require.ErrorIs(t, err, ErrUnknownExporter)
+	})
+}
+
+func TestRecordError(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	_, span := tracer.Start(context.Background(), "ok")
+	RecordError(span, nil)
+	span.End()
+
+	err := errors.Chain(errors.WrapInternal(goerrors.New("connection refused"), "advertRepo.Update").With("advert_id", "42"), "handleUpdate")
+
+	_, span = tracer.Start(context.Background(), "failed")
+	RecordError(span, err)
+	span.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	require.Equal(t, codes.Unset, spans[0].Status().Code)
+	require.Empty(t, spans[0].Events())
+
+	require.Equal(t, codes.Error, spans[1].Status().Code)
+	require.Equal(t, "connection refused", spans[1].Status().Description)
+	require.Subset(t, spans[1].Events()[0].Attributes, []attribute.KeyValue{
+		attribute.String("error.kind", "internal"),
+		attribute.String("error.code", "internal"),
+		attribute.String("error.trace", "handleUpdate.advertRepo.Update"),
+		attribute.String("error.advert_id", "42"),
+	})
+}
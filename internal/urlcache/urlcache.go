@@ -3,6 +3,8 @@ package urlcache
 import (
 	"sync"
 	"time"
+
+	"parser/internal/clock"
 )
 
 // UrlCache is responsible for providing cache-like functional.
@@ -26,18 +28,25 @@ type UrlCache struct {
 	mu    *sync.RWMutex
 	cache map[string]time.Time
 	ttl   time.Duration
+	clock clock.Clock
 }
 
 func NewUrlCache(ttl time.Duration) UrlCacher {
+	return NewUrlCacheWithClock(ttl, clock.Real())
+}
+
+// NewUrlCacheWithClock expires urls by clock, e.g. by clock.Fake in tests
+func NewUrlCacheWithClock(ttl time.Duration, c clock.Clock) UrlCacher {
 	return &UrlCache{
 		mu:    new(sync.RWMutex),
 		cache: make(map[string]time.Time, 0),
 		ttl:   ttl,
+		clock: c,
 	}
 }
 
 func (u *UrlCache) Set(url string) {
-	expiresAt := time.Now().Add(u.ttl)
+	expiresAt := u.clock.Now().Add(u.ttl)
 	u.mu.Lock()
 	u.cache[url] = expiresAt
 	u.mu.Unlock()
@@ -49,7 +58,7 @@ func (u *UrlCache) ShouldParse(url string) bool {
 	itemExpiresAt := u.cache[url].Unix()
 	u.mu.RUnlock()
 
-	curr := time.Now().Unix()
+	curr := u.clock.Now().Unix()
 
 	// Not yet expired
 	// --------CURR---ITEMEXP--->t
@@ -0,0 +1,27 @@
+package urlcache
+
+import (
+	"testing"
+	"time"
+
+	"parser/internal/clock"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUrlCache(t *testing.T) {
+	clk := clock.NewFake(time.Date(2023, 1, 20, 12, 0, 0, 0, time.UTC))
+	cache := NewUrlCacheWithClock(time.Minute, clk)
+
+	require.True(t, cache.ShouldParse("https://www.avito.ru/1"))
+
+	cache.Set("https://www.avito.ru/1")
+	require.False(t, cache.ShouldParse("https://www.avito.ru/1"))
+	require.True(t, cache.ShouldParse("https://www.avito.ru/2"))
+
+	clk.Advance(time.Minute - time.Second)
+	require.False(t, cache.ShouldParse("https://www.avito.ru/1"))
+
+	clk.Advance(time.Second)
+	require.True(t, cache.ShouldParse("https://www.avito.ru/1"))
+}
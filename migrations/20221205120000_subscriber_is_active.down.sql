@@ -0,0 +1 @@
+ALTER TABLE "subscribers" DROP COLUMN IF EXISTS "is_active";
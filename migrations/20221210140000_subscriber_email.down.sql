@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "email_confirmations" CASCADE;
+ALTER TABLE "subscribers" DROP COLUMN IF EXISTS "email";
@@ -0,0 +1,14 @@
+-- Holds only confirmed email. NULL until subscriber confirms it
+ALTER TABLE "subscribers" ADD COLUMN IF NOT EXISTS "email" varchar(255) DEFAULT NULL;
+
+CREATE TABLE IF NOT EXISTS "email_confirmations"(
+    "token" varchar(64) PRIMARY KEY UNIQUE,
+    "subscriber_id" UUID NOT NULL,
+    "email" varchar(255) NOT NULL,
+    "expires_at" TIMESTAMP NOT NULL
+);
+
+ALTER TABLE "email_confirmations" ADD CONSTRAINT "confirmation_subscriber_id_fk"
+    FOREIGN KEY("subscriber_id")
+    REFERENCES subscribers("subscriber_id")
+    ON DELETE CASCADE;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS "webhooks"(
+    "webhook_id" UUID PRIMARY KEY UNIQUE,
+    "subscriber_id" UUID NOT NULL,
+    "url" varchar(2048) NOT NULL,
+    -- Key for HMAC signature of payloads
+    "secret" varchar(64) NOT NULL,
+    "is_active" BOOLEAN NOT NULL DEFAULT TRUE,
+    -- Failed deliveries in a row
+    "failures" INTEGER NOT NULL DEFAULT 0
+);
+
+ALTER TABLE "webhooks" ADD CONSTRAINT "webhook_subscriber_id_fk"
+    FOREIGN KEY("subscriber_id")
+    REFERENCES subscribers("subscriber_id")
+    ON DELETE CASCADE;
@@ -0,0 +1,2 @@
+ALTER TABLE "subscriptions" DROP COLUMN IF EXISTS "channels";
+ALTER TABLE "subscribers" DROP COLUMN IF EXISTS "channels";
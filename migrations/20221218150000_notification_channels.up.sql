@@ -0,0 +1,4 @@
+ALTER TABLE "subscribers" ADD COLUMN IF NOT EXISTS "channels" TEXT[] NOT NULL DEFAULT '{telegram,email,webhook}';
+
+-- NULL means subscriber's channels are used
+ALTER TABLE "subscriptions" ADD COLUMN IF NOT EXISTS "channels" TEXT[] DEFAULT NULL;
@@ -0,0 +1 @@
+ALTER TABLE "subscribers" DROP COLUMN IF EXISTS "locale";
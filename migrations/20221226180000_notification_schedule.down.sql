@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS "pending_notifications";
+
+ALTER TABLE "subscribers" DROP COLUMN IF EXISTS "last_digest_at";
+ALTER TABLE "subscribers" DROP COLUMN IF EXISTS "digest_mode";
+ALTER TABLE "subscribers" DROP COLUMN IF EXISTS "quiet_to";
+ALTER TABLE "subscribers" DROP COLUMN IF EXISTS "quiet_from";
+ALTER TABLE "subscribers" DROP COLUMN IF EXISTS "timezone";
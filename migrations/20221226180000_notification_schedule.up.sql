@@ -0,0 +1,31 @@
+ALTER TABLE "subscribers" ADD COLUMN IF NOT EXISTS "timezone" varchar(64) NOT NULL DEFAULT 'Europe/Moscow';
+-- Minutes since local midnight. NULL if subscriber has no quiet hours
+ALTER TABLE "subscribers" ADD COLUMN IF NOT EXISTS "quiet_from" SMALLINT;
+ALTER TABLE "subscribers" ADD COLUMN IF NOT EXISTS "quiet_to" SMALLINT;
+-- off | hourly | daily
+ALTER TABLE "subscribers" ADD COLUMN IF NOT EXISTS "digest_mode" varchar(8) NOT NULL DEFAULT 'off';
+ALTER TABLE "subscribers" ADD COLUMN IF NOT EXISTS "last_digest_at" TIMESTAMPTZ;
+
+-- Alerts held until subscriber's digest
+CREATE TABLE IF NOT EXISTS "pending_notifications"(
+    "notification_id" UUID PRIMARY KEY UNIQUE,
+    "subscriber_id" UUID NOT NULL,
+    "advert_id" UUID NOT NULL,
+    "title" varchar(255) NOT NULL,
+    "url" varchar(255) NOT NULL,
+    "old_price" REAL NOT NULL,
+    "new_price" REAL NOT NULL,
+    "created_at" TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE "pending_notifications" ADD CONSTRAINT "pending_notification_subscriber_id_fk"
+    FOREIGN KEY("subscriber_id")
+    REFERENCES subscribers("subscriber_id")
+    ON DELETE CASCADE;
+
+ALTER TABLE "pending_notifications" ADD CONSTRAINT "pending_notification_advert_id_fk"
+    FOREIGN KEY("advert_id")
+    REFERENCES adverts("advert_id")
+    ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS "pending_notifications_subscriber_id_idx" ON "pending_notifications"("subscriber_id", "created_at");
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "delivered_notifications";
+
+ALTER TABLE "pending_notifications" DROP COLUMN IF EXISTS "kind";
@@ -0,0 +1,27 @@
+-- digest | coalesce. See domain.PendingKind
+ALTER TABLE "pending_notifications" ADD COLUMN IF NOT EXISTS "kind" varchar(8) NOT NULL DEFAULT 'digest';
+
+-- The last alert delivered to subscriber about advert per channel.
+-- Used to suppress alerts that repeat it
+CREATE TABLE IF NOT EXISTS "delivered_notifications"(
+    "subscriber_id" UUID NOT NULL,
+    "advert_id" UUID NOT NULL,
+    "channel" varchar(16) NOT NULL,
+    -- See domain.AlertFingerprint
+    "fingerprint" varchar(64) NOT NULL,
+    "delivered_at" TIMESTAMPTZ NOT NULL,
+    -- Alerts suppressed as repeats
+    "suppressed" INTEGER NOT NULL DEFAULT 0,
+    "last_suppressed_at" TIMESTAMPTZ,
+    PRIMARY KEY("subscriber_id", "advert_id", "channel")
+);
+
+ALTER TABLE "delivered_notifications" ADD CONSTRAINT "delivered_notification_subscriber_id_fk"
+    FOREIGN KEY("subscriber_id")
+    REFERENCES subscribers("subscriber_id")
+    ON DELETE CASCADE;
+
+ALTER TABLE "delivered_notifications" ADD CONSTRAINT "delivered_notification_advert_id_fk"
+    FOREIGN KEY("advert_id")
+    REFERENCES adverts("advert_id")
+    ON DELETE CASCADE;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "subscriptions_subscriber_id_idx";
+
+ALTER TABLE "subscriptions" DROP COLUMN IF EXISTS "created_at";
+ALTER TABLE "subscriptions" DROP COLUMN IF EXISTS "is_muted";
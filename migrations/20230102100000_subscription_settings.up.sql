@@ -0,0 +1,5 @@
+-- Muted subscriptions don't receive alerts
+ALTER TABLE "subscriptions" ADD COLUMN IF NOT EXISTS "is_muted" BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE "subscriptions" ADD COLUMN IF NOT EXISTS "created_at" TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE INDEX IF NOT EXISTS "subscriptions_subscriber_id_idx" ON "subscriptions"("subscriber_id", "created_at");
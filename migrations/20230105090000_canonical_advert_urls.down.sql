@@ -0,0 +1 @@
+-- Original urls are not kept, canonicalization could not be reverted
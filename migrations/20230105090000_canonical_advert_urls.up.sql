@@ -0,0 +1,52 @@
+-- Canonicalizes urls of existing adverts like domain.ParseAdvertURL does.
+-- Query is dropped completely, avito advert pages don't depend on it.
+-- Adverts that turn out to be the same are merged into one
+
+CREATE TEMPORARY TABLE "advert_merge" AS
+SELECT
+    c."advert_id",
+    c."canonical_url",
+    -- Parsed advert is kept if any
+    first_value(c."advert_id") OVER (
+        PARTITION BY c."canonical_url"
+        ORDER BY c."is_parsed" DESC, c."advert_id"
+    ) AS "keep_id"
+FROM (
+    SELECT
+        "advert_id",
+        "is_parsed",
+        'https://www.avito.ru' || rtrim(
+            regexp_replace(
+                regexp_replace("url", '^(https?://)?(www\.|m\.)?avito\.ru\.?', '', 'i'),
+                '[?#].*$', ''
+            ),
+            '/'
+        ) AS "canonical_url"
+    FROM "adverts"
+    WHERE "url" ~* '^(https?://)?(www\.|m\.)?avito\.ru\.?/'
+) c;
+
+-- Move subscribers to kept adverts
+UPDATE "subscriptions" sp
+SET "advert_id" = am."keep_id"
+FROM "advert_merge" am
+WHERE sp."advert_id" = am."advert_id" AND am."advert_id" <> am."keep_id";
+
+-- Subscriber could have been subscribed to several urls of the same advert
+DELETE FROM "subscriptions" a
+USING "subscriptions" b
+WHERE a."subscriber_id" = b."subscriber_id"
+    AND a."advert_id" = b."advert_id"
+    AND a."ctid" > b."ctid";
+
+-- Held and delivered alerts of merged adverts are deleted by cascade
+DELETE FROM "adverts" ad
+USING "advert_merge" am
+WHERE ad."advert_id" = am."advert_id" AND am."advert_id" <> am."keep_id";
+
+UPDATE "adverts" ad
+SET "url" = am."canonical_url"
+FROM "advert_merge" am
+WHERE ad."advert_id" = am."keep_id" AND ad."url" <> am."canonical_url";
+
+DROP TABLE "advert_merge";
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS "api_keys"(
+    "api_key_id" UUID PRIMARY KEY UNIQUE,
+    "name" varchar(255) NOT NULL,
+    -- SHA-256 of plain key. Plain key is never stored
+    "key_hash" varchar(64) NOT NULL UNIQUE,
+    -- First characters of plain key to tell keys apart
+    "hint" varchar(16) NOT NULL,
+    "scopes" TEXT[] NOT NULL,
+    "created_at" TIMESTAMPTZ NOT NULL DEFAULT now(),
+    "last_used_at" TIMESTAMPTZ NULL,
+    -- Revoked keys are kept for audit
+    "revoked_at" TIMESTAMPTZ NULL
+);
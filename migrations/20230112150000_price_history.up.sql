@@ -0,0 +1,16 @@
+-- Every price change of parsed adverts. Streams of changes are resumed from it
+CREATE TABLE IF NOT EXISTS "price_history"(
+    -- Position in stream of changes across adverts
+    "change_id" BIGSERIAL PRIMARY KEY,
+    "advert_id" UUID NOT NULL,
+    "old_price" REAL NOT NULL,
+    "new_price" REAL NOT NULL,
+    "changed_at" TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE "price_history" ADD CONSTRAINT "price_history_advert_id_fk"
+    FOREIGN KEY("advert_id")
+    REFERENCES adverts("advert_id")
+    ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS "price_history_advert_id_idx" ON "price_history"("advert_id", "change_id");
@@ -0,0 +1,13 @@
+-- Pages that failed to parse, kept for inspection
+CREATE TABLE IF NOT EXISTS "dead_letters"(
+    "dead_letter_id" BIGSERIAL PRIMARY KEY,
+    "url" TEXT NOT NULL,
+    -- Class of failure, e.g. malformed
+    "class" TEXT NOT NULL,
+    "error" TEXT NOT NULL,
+    -- Gzip compressed html
+    "page" BYTEA NOT NULL,
+    "created_at" TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS "dead_letters_url_idx" ON "dead_letters"("url", "created_at");
@@ -0,0 +1,2 @@
+SELECT lo_unlink("page_oid") FROM "snapshots";
+DROP TABLE IF EXISTS "snapshots";
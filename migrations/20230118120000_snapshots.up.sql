@@ -0,0 +1,14 @@
+-- Html of parsed pages, kept to debug parser when markup changes
+CREATE TABLE IF NOT EXISTS "snapshots"(
+    "snapshot_id" BIGSERIAL PRIMARY KEY,
+    "url" TEXT NOT NULL,
+    -- Error of parser. Empty if advert was extracted
+    "error" TEXT NOT NULL DEFAULT '',
+    -- Length of html before compression
+    "size" INTEGER NOT NULL,
+    -- Large object with gzip compressed html
+    "page_oid" OID NOT NULL,
+    "created_at" TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS "snapshots_url_idx" ON "snapshots"("url", "created_at");
@@ -0,0 +1,3 @@
+UPDATE "subscribers" SET "is_active" = FALSE WHERE "telegram_reachable" = FALSE;
+
+ALTER TABLE "subscribers" DROP COLUMN IF EXISTS "telegram_reachable";
@@ -0,0 +1,5 @@
+ALTER TABLE "subscribers" ADD COLUMN IF NOT EXISTS "telegram_reachable" BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- Subscribers were deactivated only because bot couldn't reach them.
+-- Email and webhooks are delivered to them again
+UPDATE "subscribers" SET "telegram_reachable" = FALSE, "is_active" = TRUE WHERE "is_active" = FALSE;
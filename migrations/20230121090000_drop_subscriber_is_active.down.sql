@@ -0,0 +1 @@
+ALTER TABLE "subscribers" ADD COLUMN IF NOT EXISTS "is_active" BOOLEAN NOT NULL DEFAULT TRUE;
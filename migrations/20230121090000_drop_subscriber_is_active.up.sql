@@ -0,0 +1,3 @@
+-- Unreachable subscribers are tracked by telegram_reachable,
+-- nothing deactivates subscriber as a whole anymore
+ALTER TABLE "subscribers" DROP COLUMN IF EXISTS "is_active";